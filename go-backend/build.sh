@@ -18,27 +18,34 @@ if ! command -v go &> /dev/null; then
     exit 0
 fi
 
-echo "Building session-viewer for multiple platforms..."
+# Build metadata embedded via -ldflags (reported by `session-viewer version`)
+VERSION_PKG="github.com/tadschnitzer/universal-session-viewer/go-backend/internal/version"
+VERSION="${VERSION:-$(git describe --tags --always --dirty 2>/dev/null || echo dev)}"
+COMMIT="$(git rev-parse --short HEAD 2>/dev/null || echo unknown)"
+BUILD_DATE="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+LDFLAGS="-X $VERSION_PKG.Version=$VERSION -X $VERSION_PKG.Commit=$COMMIT -X $VERSION_PKG.BuildDate=$BUILD_DATE"
+
+echo "Building session-viewer $VERSION ($COMMIT) for multiple platforms..."
 
 # macOS Apple Silicon
 echo "Building for darwin/arm64..."
-GOOS=darwin GOARCH=arm64 go build -o "$OUTPUT_DIR/session-viewer-darwin-arm64" $CMD_PATH
+GOOS=darwin GOARCH=arm64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/session-viewer-darwin-arm64" $CMD_PATH
 
 # macOS Intel
 echo "Building for darwin/amd64..."
-GOOS=darwin GOARCH=amd64 go build -o "$OUTPUT_DIR/session-viewer-darwin-amd64" $CMD_PATH
+GOOS=darwin GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/session-viewer-darwin-amd64" $CMD_PATH
 
 # Linux
 echo "Building for linux/amd64..."
-GOOS=linux GOARCH=amd64 go build -o "$OUTPUT_DIR/session-viewer-linux-amd64" $CMD_PATH
+GOOS=linux GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/session-viewer-linux-amd64" $CMD_PATH
 
 # Windows
 echo "Building for windows/amd64..."
-GOOS=windows GOARCH=amd64 go build -o "$OUTPUT_DIR/session-viewer-windows-amd64.exe" $CMD_PATH
+GOOS=windows GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/session-viewer-windows-amd64.exe" $CMD_PATH
 
 # Development binary (current platform)
 echo "Building for current platform..."
-go build -o "$OUTPUT_DIR/session-viewer" $CMD_PATH
+go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/session-viewer" $CMD_PATH
 
 echo "Build complete! Binaries in $OUTPUT_DIR/"
 ls -lh "$OUTPUT_DIR/"
@@ -0,0 +1,639 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/cache"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/language"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/prompts"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/window"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/version"
+)
+
+// defaultMaxRetries is the number of analysis attempts made before giving up
+const defaultMaxRetries = 3
+
+// transportRetries is how many times a failed CLI call (timeout, non-zero exit) is
+// retried within each analysis attempt, independently of conversational-response retries
+const transportRetries = 2
+
+// analyzeOptions holds the per-invocation settings for handleAnalyze
+type analyzeOptions struct {
+	maxRetries      int
+	redactPaths     bool
+	redact          bool    // Mask secrets in the content before it is sent to the model
+	rejectOnExhaust bool    // Fail instead of returning a rejected summary when retries run out
+	both            bool    // Also request a structured analysis, concurrently with the summary
+	outputLanguage  string  // ISO 639-1 code the summary is written in; "auto" follows the session
+	sortEpisodes    string  // Episode ordering for the structured analysis (line, confidence, time)
+	minEpisodes     int     // Structured analyses with fewer episodes are rejected and retried
+	minConfidence   float64 // Episodes below this confidence are dropped from a valid analysis
+	cache           bool    // Reuse and store summaries in the response cache
+	progress        bool    // Report each analyzed file or window on stderr
+	dryRun          bool    // Print the prompts that would be sent instead of calling the model
+	truncate        string  // Cut content over CLAUDE_MAX_INPUT_TOKENS to its head, tail, or middle instead of refusing it
+	tier            int     // Structured analysis tier with --both; 0 selects it by size
+	model           string  // Model to call: --model, else the profile's, else cfg.Claude.Model
+	transport       llm.ProcessingConfig
+	templates       *prompts.Set // Prompt templates, with any overrides from the prompts directory
+}
+
+// resolveAnalyzeOptions builds analysis settings from defaults, then the profile
+// selected by --profile, then explicit flags, each overriding the last.
+// cfg is not modified: a profile model, or one given with --model, is kept in the
+// options and only used by this invocation's analyzer.
+func resolveAnalyzeOptions(cfg *config.Config, flags map[string]string) (*analyzeOptions, error) {
+	opts := &analyzeOptions{
+		maxRetries: defaultMaxRetries,
+		model:      cfg.Claude.Model,
+		transport: llm.ProcessingConfig{
+			MaxRetries:    transportRetries,
+			RetryDelay:    cfg.Claude.RetryBaseDelay,
+			RetryMaxDelay: cfg.Claude.RetryMaxDelay,
+		},
+	}
+
+	if name := flags["profile"]; name != "" {
+		profile, err := config.LoadProfile(cfg.Paths.ProfilesFile, name)
+		if err != nil {
+			return nil, err
+		}
+		if profile.Model != "" {
+			opts.model = profile.Model
+		}
+		if profile.MaxRetries > 0 {
+			opts.maxRetries = profile.MaxRetries
+		}
+		opts.redactPaths = profile.RedactPaths
+		opts.tier = profile.Tier
+		if err := applyProfileOutput(profile.Output); err != nil {
+			return nil, err
+		}
+	}
+	if model := flags["model"]; model != "" {
+		opts.model = model
+	}
+
+	maxRetries, err := parseIntFlag(flags, "max-retries", opts.maxRetries)
+	if err != nil {
+		return nil, err
+	}
+	if maxRetries < 1 {
+		return nil, fmt.Errorf("Invalid max-retries: must be at least 1")
+	}
+	opts.maxRetries = maxRetries
+
+	if flags["redact-paths"] != "" {
+		opts.redactPaths = true
+	}
+	opts.redact = flags["redact"] != ""
+	opts.rejectOnExhaust = flags["reject-on-exhaust"] != ""
+	opts.both = flags["both"] != ""
+	opts.cache = flags["cache"] != ""
+	opts.progress = flags["progress"] != ""
+	opts.dryRun = flags["dry-run"] != ""
+	opts.outputLanguage = flags["output-language"]
+
+	if err := validateEpisodeSort(flags["sort-episodes"]); err != nil {
+		return nil, err
+	}
+	opts.sortEpisodes = flags["sort-episodes"]
+
+	if opts.tier, err = parseIntFlag(flags, "tier", opts.tier); err != nil {
+		return nil, err
+	}
+	if opts.tier < 0 || opts.tier > config.MaxTier {
+		return nil, fmt.Errorf("Invalid tier: %d (expected 1 to %d)", opts.tier, config.MaxTier)
+	}
+
+	if err := validateTruncate(flags["truncate"]); err != nil {
+		return nil, err
+	}
+	opts.truncate = flags["truncate"]
+
+	if opts.minEpisodes, err = parseIntFlag(flags, "min-episodes", 0); err != nil {
+		return nil, err
+	}
+	if opts.minConfidence, err = parseConfidenceFlag(flags, "min-confidence"); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// handleAnalyze processes session analysis using Claude Haiku
+func handleAnalyze(cfg *config.Config) {
+	if len(os.Args) < 4 {
+		respondError("Usage: session-viewer analyze --session-id <id> --content <content> | --file <path> | --dir <path> [--concurrency <n>] [--limit <n>] [--start-line <n>] [--end-line <n>] [--redact-paths] [--redact] [--profile <name>] [--model <name>] [--max-retries <n>] [--reject-on-exhaust] [--both] [--tier 1|2|3] [--output-language auto|<code>] [--sort-episodes line|confidence|time] [--max-chars <n>] [--truncate head|tail|middle] [--min-episodes <n>] [--min-confidence <0-1>] [--cache] [--progress] [--dry-run]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:], "redact-paths", "redact", "reject-on-exhaust", "both", "cache", "progress", "dry-run")
+	if flags["dir"] != "" {
+		handleAnalyzeDir(cfg, flags)
+		return
+	}
+
+	sessionID := flags["session-id"]
+	content := flags["content"]
+	filePath := flags["file"]
+
+	if filePath != "" && content != "" {
+		respondError("Use either --content or --file, not both")
+		return
+	}
+	// A session file names the session unless --session-id overrides it
+	if filePath != "" && sessionID == "" {
+		sessionID = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+	if sessionID == "" || (content == "" && filePath == "") {
+		respondError("Missing required arguments")
+		return
+	}
+	if err := llm.ValidateSessionID(sessionID); err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	startLine, endLine, err := parseLineRange(flags)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	run, err := newAnalyzeRun(cfg, flags)
+	if err != nil {
+		respondSetupError(err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), run.timeout())
+	defer cancel()
+
+	if filePath != "" {
+		// The line range selects source lines of the JSONL file, as in the filter command
+		limit, err := parseIntFlag(flags, "limit", defaultMessageLimit)
+		if err != nil {
+			respondError(err.Error())
+			return
+		}
+		content, err = sessionFileContent(ctx, filePath, limit, FilterOptions{StartLine: startLine, EndLine: endLine})
+		if err != nil {
+			respondError(fmt.Sprintf("Error filtering file: %v", err))
+			return
+		}
+	} else {
+		content = sliceLines(content, startLine, endLine)
+	}
+	if strings.TrimSpace(content) == "" {
+		respondError("No content within the requested line range")
+		return
+	}
+
+	maxChars, err := parseIntFlag(flags, "max-chars", 0)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	content = trimToMaxChars(content, maxChars)
+
+	if run.opts.dryRun {
+		response, err := run.dryRun(sessionID, content)
+		if err != nil {
+			respondError(err.Error())
+			return
+		}
+		respondJSON(response)
+		return
+	}
+
+	respondJSON(run.analyze(ctx, sessionID, content))
+}
+
+// structuredTier is the --both processing tier for content of tokenCount estimated
+// tokens: the one chosen by --tier or the profile, otherwise the one its size calls for
+func (o *analyzeOptions) structuredTier(tokenCount int) int {
+	if o.tier != 0 {
+		return o.tier
+	}
+	return llm.SelectTier(tokenCount)
+}
+
+// analyzeRun holds what every session analyzed by one invocation shares
+type analyzeRun struct {
+	cfg      *config.Config
+	opts     *analyzeOptions
+	rules    *ResponseRules
+	analyzer llm.Analyzer
+	store    *cache.Store // nil unless --cache
+	redactor *Redactor    // nil unless --redact
+}
+
+// newAnalyzeRun resolves options from flags and loads the prompt templates, checks the
+// configured backend, and loads the response rules, returning an error message suitable
+// for respondError. A dry run never calls the model, so it needs neither a backend nor
+// response rules.
+func newAnalyzeRun(cfg *config.Config, flags map[string]string) (*analyzeRun, error) {
+	opts, err := resolveAnalyzeOptions(cfg, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.templates, err = prompts.LoadDir(cfg.Paths.PromptsDir); err != nil {
+		return nil, fmt.Errorf("Failed to load prompt templates: %v", err)
+	}
+
+	run := &analyzeRun{cfg: cfg, opts: opts}
+	if opts.redact {
+		if run.redactor, err = loadRedactor(cfg.Paths.RedactRulesFile); err != nil {
+			return nil, fmt.Errorf("Failed to load redact rules: %v", err)
+		}
+	}
+	if opts.dryRun {
+		return run, nil
+	}
+
+	// Catch a missing or non-executable binary before any prompt is built
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	rules, err := loadResponseRules(cfg.Paths.FilterRulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load filter rules: %v", err)
+	}
+
+	analyzer, err := newAnalyzer(cfg, opts.model, validator.Options{MinEpisodes: opts.minEpisodes})
+	if err != nil {
+		return nil, err
+	}
+
+	run.rules, run.analyzer = rules, analyzer
+	if opts.cache {
+		run.store = cache.NewStore(cfg.Paths.CacheDir)
+	}
+	return run, nil
+}
+
+// timeout bounds the analysis of a single session, including retries: each summary
+// attempt may run the CLI once plus its transport retries, each call up to
+// CLAUDE_TIMEOUT and each retry after a backoff of at most CLAUDE_RETRY_MAX_DELAY.
+// The structured analysis of --both runs alongside, so it adds nothing.
+func (r *analyzeRun) timeout() time.Duration {
+	perAttempt := time.Duration(transportRetries+1)*r.cfg.Claude.Timeout + time.Duration(transportRetries)*r.cfg.Claude.RetryMaxDelay
+	return time.Duration(r.opts.maxRetries) * perAttempt
+}
+
+// analyze summarizes one session's content, and with --both also requests its
+// structured analysis. Failures are reported in the response's Error field.
+func (r *analyzeRun) analyze(ctx context.Context, sessionID, content string) SessionAnalysisResponse {
+	cfg, analyzer := r.cfg, r.analyzer
+
+	// Secrets are masked before anything else sees the content, including the cache key
+	var redactions *RedactionCounts
+	if r.redactor != nil {
+		content, redactions = r.redactor.Redact(content)
+	}
+
+	// --truncate cuts oversized content down to the budget, so the check below passes
+	var truncation *llm.TruncationInfo
+	if r.opts.truncate != "" {
+		content, truncation = truncateToTokens(content, cfg.Claude.MaxInputTokens, r.opts.truncate)
+	}
+
+	tokenCount := llm.EstimateTokens(content)
+	if err := checkInputBudget(tokenCount, cfg.Claude.MaxInputTokens); err != nil {
+		response := failedResponse(sessionID, err.Error())
+		response.ErrorKind = errorKindTooLarge
+		response.Redactions = redactions
+		return response
+	}
+
+	warnings := preflightWarnings(content)
+	if truncation != nil {
+		warnings = append(warnings, truncationWarning(truncation, cfg.Claude.MaxInputTokens))
+	}
+	loops := llm.DetectLoops(contentMessages(content))
+
+	// Each session gets its own copy so "auto" resolves to that session's language
+	opts := *r.opts
+	detectedLanguage := language.DetectLanguage(content)
+	if opts.outputLanguage == "auto" {
+		opts.outputLanguage = detectedLanguage
+	}
+
+	// With --both the structured analysis runs alongside the summary instead of after it.
+	// Sessions too large for one direct prompt are analyzed in overlapping windows.
+	var analysis *llm.Analysis
+	var analysisErr error
+	var wg sync.WaitGroup
+	start := time.Now()
+	if opts.both {
+		tier := opts.structuredTier(tokenCount)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tier == 1 {
+				analysis, analysisErr = requestStructuredAnalysis(ctx, analyzer, opts.templates, content)
+			} else {
+				analysis, analysisErr = requestWindowedAnalysis(ctx, analyzer, opts.templates, content, sessionID, opts.progress)
+			}
+			if analysisErr == nil {
+				analysis.Metadata.Model = opts.model
+				analysis.Metadata.TokenCount = tokenCount
+				analysis.Metadata.ProcessingTime = time.Since(start).Seconds()
+				analysis.Metadata.Timestamp = time.Now().UTC()
+				// Hierarchical (tier 3) analysis isn't implemented; windows stand in for it
+				if tier != analysis.Metadata.ProcessingTier {
+					analysis.Metadata.SetInfo(llm.InfoSelectedTier, tier)
+				}
+			}
+		}()
+	}
+
+	result, err := cachedSummary(ctx, analyzer, content, &opts, r.rules, r.store, opts.model)
+	summary, rejectedReasons := result.summary, result.rejectedReasons
+	usedFallback := result.attempts > 0 && prompts.SummaryTemplate(result.attempts) == llm.PromptSummaryRetry
+	metadata := &llm.AnalysisMetadata{
+		ProcessingTier:  1,
+		TokenCount:      tokenCount,
+		ProcessingTime:  time.Since(start).Seconds(),
+		Model:           opts.model,
+		AnalysisVersion: version.Version,
+		Timestamp:       time.Now().UTC(),
+		Truncation:      truncation,
+	}
+	wg.Wait()
+
+	if err != nil {
+		response := SessionAnalysisResponse{
+			SessionID:       sessionID,
+			Language:        detectedLanguage,
+			Summary:         "Analysis failed - " + err.Error(),
+			Error:           err.Error(),
+			RejectedReasons: rejectedReasons,
+			Warnings:        warnings,
+			Metadata:        metadata,
+			Loops:           loops,
+			Redactions:      redactions,
+		}
+		response.Attempts, response.UsedFallbackPrompt = result.attempts, usedFallback
+		response.ErrorKind = classifyError(err)
+		return response
+	}
+
+	// Mask usernames in absolute paths before the summary leaves the process
+	if opts.redactPaths {
+		summary = redactPaths(summary)
+	}
+
+	response := SessionAnalysisResponse{
+		SessionID:       sessionID,
+		Language:        detectedLanguage,
+		Summary:         summary,
+		KeyTasks:        extractKeyTasks(summary),
+		RejectedReasons: rejectedReasons,
+		Warnings:        warnings,
+		Metadata:        metadata,
+		Loops:           loops,
+		Redactions:      redactions,
+	}
+	response.Attempts, response.UsedFallbackPrompt = result.attempts, usedFallback
+	if opts.both {
+		if analysisErr != nil {
+			response.AnalysisError = analysisErr.Error()
+		} else {
+			if opts.redactPaths {
+				redactAnalysisPaths(analysis)
+			}
+			// Trimmed after validation, so low-confidence episodes never cause a rejection
+			if opts.minConfidence > 0 {
+				dropLowConfidence(analysis, opts.minConfidence)
+			}
+			sortEpisodes(analysis.Episodes, opts.sortEpisodes)
+			response.Analysis = analysis
+		}
+	}
+	return response
+}
+
+// summaryResult is a summary along with how it was obtained
+type summaryResult struct {
+	summary         string
+	rejectedReasons []string // Why each rejected attempt was rejected
+	attempts        int      // Prompts sent to the model; 0 for a cached summary
+}
+
+// requestSummary asks the model for a free-text summary, retrying up to opts.maxRetries
+// times with a stricter prompt whenever rules classify the reply as conversational.
+// The result is filled in as far as the attempts got, even when an error is returned.
+func requestSummary(ctx context.Context, analyzer llm.Analyzer, content string, opts *analyzeOptions, rules *ResponseRules) (summaryResult, error) {
+	// Retry mechanism: try up to maxRetries times with increasingly explicit prompts
+	maxRetries := opts.maxRetries
+	var summary string
+	var rejectedReasons []string
+	var err error
+	attempts := 0
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		var prompt string
+		prompt, err = summaryPrompt(opts.templates, attempt, content, opts.outputLanguage)
+		if err != nil {
+			break
+		}
+
+		attempts = attempt
+		summary, err = sendWithRetry(ctx, analyzer, prompt, opts.transport)
+
+		if err != nil {
+			// Transient CLI failures were already retried; a stricter prompt won't help
+			break
+		}
+
+		// Check if response is an error message instead of a summary
+		isError, reason := rules.isErrorResponse(summary)
+
+		if !isError {
+			// Valid summary received
+			break
+		}
+		rejectedReasons = append(rejectedReasons, fmt.Sprintf("attempt %d: %s", attempt, reason))
+
+		// Invalid response detected, retry unless this was the last attempt
+		if attempt < maxRetries {
+			continue
+		}
+	}
+
+	// Every attempt was rejected; by default the last rejected text is still returned
+	if err == nil && opts.rejectOnExhaust && len(rejectedReasons) == maxRetries {
+		err = fmt.Errorf("all %d %w", maxRetries, errConversationalExhausted)
+	}
+
+	return summaryResult{summary: summary, rejectedReasons: rejectedReasons, attempts: attempts}, err
+}
+
+// summaryPrompt renders the summary template selected for an attempt, with increasing
+// explicitness on retries: every attempt after the first uses the strict prompt
+func summaryPrompt(templates *prompts.Set, attempt int, content, outputLanguage string) (string, error) {
+	prompt, err := templates.Render(prompts.SummaryTemplate(attempt), prompts.Data{Content: content})
+	if err != nil {
+		return "", err
+	}
+	return languageInstruction(outputLanguage) + prompt, nil
+}
+
+// cachedSummary returns the cached summary for content when store is set, and
+// otherwise requests one. Only accepted summaries are stored, so a conversational
+// reply is never replayed from the cache.
+func cachedSummary(ctx context.Context, analyzer llm.Analyzer, content string, opts *analyzeOptions, rules *ResponseRules, store *cache.Store, model string) (summaryResult, error) {
+	if store == nil {
+		return requestSummary(ctx, analyzer, content, opts, rules)
+	}
+
+	key, err := summaryCacheKey(opts, model, content)
+	if err != nil {
+		return summaryResult{}, err
+	}
+	if entry, ok := store.Get(key); ok {
+		return summaryResult{summary: entry.Response}, nil
+	}
+
+	result, err := requestSummary(ctx, analyzer, content, opts, rules)
+	if err == nil && len(result.rejectedReasons) < opts.maxRetries {
+		// A failed write only costs a model call next time
+		_ = store.Put(cache.Entry{Key: key, Model: model, Response: result.summary})
+	}
+	return result, err
+}
+
+// summaryCacheKey derives the cache key of a summary from the model and the rendered
+// first and retry prompts, which cover the content, the output language, and any
+// template overrides, so editing a template invalidates summaries built from the old one
+func summaryCacheKey(opts *analyzeOptions, model, content string) (string, error) {
+	parts := []string{model}
+	for attempt := 1; attempt <= 2; attempt++ {
+		prompt, err := summaryPrompt(opts.templates, attempt, content, opts.outputLanguage)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, prompt)
+	}
+	return cache.Key(parts...), nil
+}
+
+// languageInstruction tells the model which language to write in. English, the
+// prompts' own language, needs no instruction.
+func languageInstruction(code string) string {
+	if code == "" || code == language.Default {
+		return ""
+	}
+	name := language.Name(code)
+	if name == "" {
+		name = code
+	}
+	return fmt.Sprintf("Write the entire summary in %s.\n\n", name)
+}
+
+// requestStructuredAnalysis asks the model for episode-level Analysis JSON
+func requestStructuredAnalysis(ctx context.Context, analyzer llm.Analyzer, templates *prompts.Set, content string) (*llm.Analysis, error) {
+	prompt, err := structuredPrompt(templates, content)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := analyzer.SendStructuredPrompt(ctx, prompt, "")
+	if err != nil {
+		return nil, err
+	}
+	analysis.Metadata.ProcessingTier = 1
+	analysis.Metadata.SetInfo(llm.InfoPromptTemplate, string(llm.PromptTier1Direct))
+	return analysis, nil
+}
+
+// structuredPrompt renders the tier 1 prompt asking for a whole session's Analysis JSON
+func structuredPrompt(templates *prompts.Set, content string) (string, error) {
+	return templates.Render(llm.PromptTier1Direct, prompts.Data{Content: content})
+}
+
+// sessionFileContent filters a JSONL session like the filter command and joins the
+// last limit user and assistant messages as "role: content" lines
+func sessionFileContent(ctx context.Context, filePath string, limit int, opts FilterOptions) (string, error) {
+	result, err := filterJSONLFile(ctx, filePath, limit, opts)
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(result.Messages))
+	for _, msg := range result.Messages {
+		lines = append(lines, msg.Type+": "+msg.Content)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// requestWindowedAnalysis splits content into overlapping windows of messages, analyzes
+// them concurrently, and merges the per-window episodes. With showProgress each window
+// is reported on stderr as it starts.
+func requestWindowedAnalysis(ctx context.Context, analyzer llm.Analyzer, templates *prompts.Set, content, sessionID string, showProgress bool) (*llm.Analysis, error) {
+	windows, processing := analysisWindows(content)
+	analyze := window.ClaudeAnalyzer(analyzer, templates, len(windows))
+	if progress := newProgress(showProgress, len(windows)); progress != nil {
+		analyzeWindow := analyze
+		analyze = func(ctx context.Context, w window.Window) (*llm.WindowResult, error) {
+			progress.step(fmt.Sprintf("window %d of %s", w.Index+1, sessionID))
+			return analyzeWindow(ctx, w)
+		}
+	}
+	results, err := window.AnalyzeWindows(ctx, windows, processing, analyze)
+	if err != nil {
+		return nil, err
+	}
+	return window.MergeWindowResults(results), nil
+}
+
+// contentMessages turns analyze content into messages for windowing. Content is either
+// the JSON array of session entries sent by the frontend, or plain text where each
+// non-empty line is one message, optionally prefixed with its role ("user: ...").
+func contentMessages(content string) []FilteredMessage {
+	var entries []map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &entries); err == nil {
+		var messages []FilteredMessage
+		for i, entry := range entries {
+			messages = append(messages, extractMessages(i+1, entry, FilterOptions{})...)
+		}
+		return messages
+	}
+
+	var messages []FilteredMessage
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		msg := FilteredMessage{Type: "text", Content: line, Line: i + 1}
+		if role, text, found := strings.Cut(line, ":"); found && (role == "user" || role == "assistant") {
+			msg.Type, msg.Content = role, strings.TrimSpace(text)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// analysisWindows splits content into the windows requestWindowedAnalysis analyzes
+func analysisWindows(content string) ([]window.Window, llm.ProcessingConfig) {
+	processing := llm.ProcessingConfig{
+		WindowSize:      window.DefaultWindowSize,
+		OverlapSize:     window.DefaultOverlapSize,
+		ParallelWindows: window.DefaultParallelWindows,
+	}
+	return window.SplitIntoWindows(contentMessages(content), processing), processing
+}
@@ -0,0 +1,660 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// writeTestProfiles writes a profiles file and returns a config pointing at it
+func writeTestProfiles(t *testing.T, content string) *config.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write profiles: %v", err)
+	}
+	t.Setenv("SESSION_VIEWER_PROFILES", path)
+	return &config.Config{
+		Claude: config.ClaudeConfig{Model: "default-model"},
+		Paths:  config.PathsConfig{ProfilesFile: path},
+	}
+}
+
+// TestResolveAnalyzeOptions tests profile application and flag overrides
+func TestResolveAnalyzeOptions(t *testing.T) {
+	profiles := `{"deep": {"model": "deep-model", "max_retries": 5, "redact_paths": true}}`
+
+	t.Run("Defaults without profile", func(t *testing.T) {
+		cfg := writeTestProfiles(t, profiles)
+		opts, err := resolveAnalyzeOptions(cfg, map[string]string{})
+		if err != nil {
+			t.Fatalf("resolveAnalyzeOptions failed: %v", err)
+		}
+		if opts.maxRetries != defaultMaxRetries || opts.redactPaths {
+			t.Errorf("Expected defaults, got %+v", opts)
+		}
+		if opts.model != "default-model" {
+			t.Errorf("Expected configured model, got %q", opts.model)
+		}
+	})
+
+	t.Run("Profile applies bundled settings", func(t *testing.T) {
+		cfg := writeTestProfiles(t, profiles)
+		opts, err := resolveAnalyzeOptions(cfg, map[string]string{"profile": "deep"})
+		if err != nil {
+			t.Fatalf("resolveAnalyzeOptions failed: %v", err)
+		}
+		if opts.maxRetries != 5 {
+			t.Errorf("Expected profile max retries 5, got %d", opts.maxRetries)
+		}
+		if !opts.redactPaths {
+			t.Error("Expected profile to enable path redaction")
+		}
+		if opts.model != "deep-model" {
+			t.Errorf("Expected profile model, got %q", opts.model)
+		}
+		if cfg.Claude.Model != "default-model" {
+			t.Errorf("Expected shared config unchanged, got %q", cfg.Claude.Model)
+		}
+	})
+
+	t.Run("Flag overrides profile", func(t *testing.T) {
+		cfg := writeTestProfiles(t, profiles)
+		opts, err := resolveAnalyzeOptions(cfg, map[string]string{"profile": "deep", "max-retries": "2"})
+		if err != nil {
+			t.Fatalf("resolveAnalyzeOptions failed: %v", err)
+		}
+		if opts.maxRetries != 2 {
+			t.Errorf("Expected flag max retries 2, got %d", opts.maxRetries)
+		}
+		if opts.model != "deep-model" {
+			t.Errorf("Expected non-overridden profile model kept, got %q", opts.model)
+		}
+	})
+
+	t.Run("Model flag overrides profile", func(t *testing.T) {
+		cfg := writeTestProfiles(t, profiles)
+		opts, err := resolveAnalyzeOptions(cfg, map[string]string{"profile": "deep", "model": "big-model"})
+		if err != nil {
+			t.Fatalf("resolveAnalyzeOptions failed: %v", err)
+		}
+		if opts.model != "big-model" {
+			t.Errorf("Expected flag model, got %q", opts.model)
+		}
+		if cfg.Claude.Model != "default-model" {
+			t.Errorf("Expected shared config unchanged, got %q", cfg.Claude.Model)
+		}
+	})
+
+	t.Run("Profile tier and output format", func(t *testing.T) {
+		cfg := writeTestProfiles(t, `{"wide": {"tier": 2, "output": "text"}}`)
+		defer func(format string, given bool) { outputFormat, outputFlagGiven = format, given }(outputFormat, outputFlagGiven)
+
+		outputFormat, outputFlagGiven = outputJSON, false
+		opts, err := resolveAnalyzeOptions(cfg, map[string]string{"profile": "wide"})
+		if err != nil {
+			t.Fatalf("resolveAnalyzeOptions failed: %v", err)
+		}
+		if opts.tier != 2 || opts.structuredTier(10) != 2 || outputFormat != outputText {
+			t.Errorf("Expected profile tier 2 and text output, got %d and %q", opts.tier, outputFormat)
+		}
+
+		// --tier and --output win over the profile
+		outputFormat, outputFlagGiven = outputPretty, true
+		opts, err = resolveAnalyzeOptions(cfg, map[string]string{"profile": "wide", "tier": "1"})
+		if err != nil {
+			t.Fatalf("resolveAnalyzeOptions failed: %v", err)
+		}
+		if opts.tier != 1 || outputFormat != outputPretty {
+			t.Errorf("Expected flag tier 1 and pretty output, got %d and %q", opts.tier, outputFormat)
+		}
+
+		if _, err := resolveAnalyzeOptions(cfg, map[string]string{"tier": "4"}); err == nil {
+			t.Error("Expected error for tier 4")
+		}
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		cfg := writeTestProfiles(t, profiles)
+		if _, err := resolveAnalyzeOptions(cfg, map[string]string{"profile": "missing"}); err == nil {
+			t.Error("Expected error for unknown profile")
+		}
+	})
+
+	t.Run("Zero max retries rejected", func(t *testing.T) {
+		cfg := writeTestProfiles(t, profiles)
+		if _, err := resolveAnalyzeOptions(cfg, map[string]string{"max-retries": "0"}); err == nil {
+			t.Error("Expected error for zero max retries")
+		}
+	})
+}
+
+// TestAnalyzeProfileRetries tests that the profile's retry budget drives the analyze loop
+func TestAnalyzeProfileRetries(t *testing.T) {
+	conversational := "You're absolutely right! I made an error and will fix the configuration for you now."
+
+	tests := []struct {
+		name            string
+		args            []string
+		expectedPrompts int
+	}{
+		{
+			name:            "Profile limits attempts",
+			args:            []string{"--profile", "quick"},
+			expectedPrompts: 1,
+		},
+		{
+			name:            "Flag overrides profile attempts",
+			args:            []string{"--profile", "quick", "--max-retries", "2"},
+			expectedPrompts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promptFile := installFakeClaude(t, conversational)
+			writeTestProfiles(t, `{"quick": {"max_retries": 1}}`)
+
+			args := append([]string{"analyze", "--session-id", "profile-test", "--content", "conversation"}, tt.args...)
+			runMain(t, args...)
+
+			if prompts := readPrompts(t, promptFile); len(prompts) != tt.expectedPrompts {
+				t.Errorf("Expected %d prompts, got %d", tt.expectedPrompts, len(prompts))
+			}
+		})
+	}
+}
+
+// TestAnalyzeRejectOnExhaust tests the failure marker when every attempt is conversational
+func TestAnalyzeRejectOnExhaust(t *testing.T) {
+	conversational := "You're absolutely right! I made an error and will fix the configuration for you now."
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectError   bool
+		expectSummary string
+	}{
+		{
+			name:          "Default returns last rejected text",
+			args:          nil,
+			expectError:   false,
+			expectSummary: conversational,
+		},
+		{
+			name:          "Reject on exhaust returns error marker",
+			args:          []string{"--reject-on-exhaust"},
+			expectError:   true,
+			expectSummary: "Analysis failed - all 3 attempts produced conversational responses",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promptFile := installFakeClaude(t, conversational)
+
+			args := append([]string{"analyze", "--session-id", "exhaust-test", "--content", "conversation"}, tt.args...)
+			output := runMain(t, args...)
+
+			var response SessionAnalysisResponse
+			if err := json.Unmarshal([]byte(output), &response); err != nil {
+				t.Fatalf("Invalid JSON output: %v: %s", err, output)
+			}
+
+			if (response.Error != "") != tt.expectError {
+				t.Errorf("Expected error=%v, got %q", tt.expectError, response.Error)
+			}
+			if response.Summary != tt.expectSummary {
+				t.Errorf("Expected summary %q, got %q", tt.expectSummary, response.Summary)
+			}
+			if len(response.RejectedReasons) != defaultMaxRetries {
+				t.Errorf("Expected %d rejected reasons, got %v", defaultMaxRetries, response.RejectedReasons)
+			}
+			if prompts := readPrompts(t, promptFile); len(prompts) != defaultMaxRetries {
+				t.Errorf("Expected %d attempts, got %d", defaultMaxRetries, len(prompts))
+			}
+		})
+	}
+}
+
+// TestAnalyzeRejectOnExhaustRecovers tests that a later valid attempt is not marked as failed
+func TestAnalyzeRejectOnExhaustRecovers(t *testing.T) {
+	installFakeClaude(t, "Short text", validSummary)
+
+	output := runMain(t, "analyze", "--session-id", "exhaust-test", "--content", "conversation", "--reject-on-exhaust")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if response.Error != "" {
+		t.Errorf("Expected no error after recovery, got %q", response.Error)
+	}
+	if response.Summary != validSummary {
+		t.Errorf("Expected valid summary, got %q", response.Summary)
+	}
+}
+
+// validAnalysisJSON is a structured analysis that passes validator.ValidateAnalysisJSON
+const validAnalysisJSON = `{"episodes":[{"id":"ep1","phase":"implementation","confidence":0.9,"description":"Added streaming filter","start_line":1,"end_line":3}],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":["Add tests"],"metadata":{}}`
+
+// TestAnalyzeBoth tests that --both issues both prompts and merges the results
+func TestAnalyzeBoth(t *testing.T) {
+	promptFile := installRoutingFakeClaude(t,
+		fakeRoute{Marker: "Reply with JSON only", Response: validAnalysisJSON},
+		fakeRoute{Marker: "concise summary", Response: validSummary},
+	)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: add streaming", "--both")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, output)
+	}
+
+	if response.Summary != validSummary {
+		t.Errorf("Expected summary, got %q", response.Summary)
+	}
+	if response.Analysis == nil || len(response.Analysis.Episodes) != 1 || response.Analysis.Episodes[0].Phase != "implementation" {
+		t.Fatalf("Expected parsed analysis, got %+v (error %q)", response.Analysis, response.AnalysisError)
+	}
+	if template := response.Analysis.Metadata.PromptTemplate(); template != llm.PromptTier1Direct {
+		t.Errorf("Expected prompt template %s, got %q", llm.PromptTier1Direct, template)
+	}
+	if variant := response.Analysis.Metadata.HierarchicalInfo[llm.InfoPromptVariant]; variant != llm.PromptVariantInitial {
+		t.Errorf("Expected initial prompt variant, got %v", variant)
+	}
+
+	if prompts := readPrompts(t, promptFile); len(prompts) != 2 {
+		t.Errorf("Expected summary and structured prompts, got %d prompts", len(prompts))
+	}
+}
+
+// TestAnalyzeBothStructuredFailure tests that an invalid structured reply doesn't fail the summary
+func TestAnalyzeBothStructuredFailure(t *testing.T) {
+	installRoutingFakeClaude(t,
+		fakeRoute{Marker: "Reply with JSON only", Response: "not json"},
+		fakeRoute{Marker: "concise summary", Response: validSummary},
+	)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: add streaming", "--both")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, output)
+	}
+	if response.Summary != validSummary || response.Error != "" {
+		t.Errorf("Expected summary to succeed, got %+v", response)
+	}
+	if response.Analysis != nil || response.AnalysisError == "" {
+		t.Errorf("Expected analysis error, got %+v", response)
+	}
+}
+
+// TestAnalyzeBothMinEpisodes tests that a structured reply under --min-episodes is retried
+func TestAnalyzeBothMinEpisodes(t *testing.T) {
+	twoEpisodes := `{"episodes":[{"id":"ep1","phase":"exploration","confidence":0.8,"description":"Read the code","start_line":1,"end_line":2},{"id":"ep2","phase":"implementation","confidence":0.9,"description":"Added streaming filter","start_line":3,"end_line":4}],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":[],"metadata":{}}`
+	promptFile := installRoutingFakeClaude(t,
+		fakeRoute{Marker: "fewer than the minimum of 2", Response: twoEpisodes},
+		fakeRoute{Marker: "Reply with JSON only", Response: validAnalysisJSON},
+		fakeRoute{Marker: "concise summary", Response: validSummary},
+	)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: add streaming", "--both", "--min-episodes", "2")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, output)
+	}
+	if response.Analysis == nil || len(response.Analysis.Episodes) != 2 {
+		t.Fatalf("Expected the retried two-episode analysis, got %+v (error %q)", response.Analysis, response.AnalysisError)
+	}
+	if variant := response.Analysis.Metadata.HierarchicalInfo[llm.InfoPromptVariant]; variant != llm.PromptVariantCorrective {
+		t.Errorf("Expected corrective prompt variant, got %v", variant)
+	}
+	if prompts := readPrompts(t, promptFile); len(prompts) != 3 {
+		t.Errorf("Expected summary plus two structured prompts, got %d prompts", len(prompts))
+	}
+}
+
+// TestAnalyzeInvalidMinEpisodes tests that a malformed --min-episodes is rejected
+func TestAnalyzeInvalidMinEpisodes(t *testing.T) {
+	installFakeClaude(t, validSummary)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: hi", "--min-episodes", "many")
+	if !strings.Contains(output, "Invalid min-episodes: many") {
+		t.Errorf("Expected invalid min-episodes error, got %s", output)
+	}
+}
+
+// TestAnalyzeBothWindowedForLargeContent tests that content over the tier 1 threshold
+// is analyzed in windows and the metadata records the tier and token count
+func TestAnalyzeBothWindowedForLargeContent(t *testing.T) {
+	promptFile := installRoutingFakeClaude(t,
+		fakeRoute{Marker: "Analyze window", Response: validAnalysisJSON},
+		fakeRoute{Marker: "concise summary", Response: validSummary},
+	)
+
+	content := windowedContent(t)
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", content, "--both")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, output)
+	}
+	if response.Analysis == nil {
+		t.Fatalf("Expected windowed analysis, got error %q", response.AnalysisError)
+	}
+	meta := response.Analysis.Metadata
+	if meta.ProcessingTier != 2 || meta.PromptTemplate() != llm.PromptTier2Window {
+		t.Errorf("Expected tier 2 window analysis, got tier %d template %q", meta.ProcessingTier, meta.PromptTemplate())
+	}
+	if meta.TokenCount != llm.EstimateTokens(content) || meta.WindowCount < 2 {
+		t.Errorf("Unexpected metadata: %+v", meta)
+	}
+
+	windowPrompts := 0
+	for _, prompt := range readPrompts(t, promptFile) {
+		if strings.Contains(prompt, "Analyze window") {
+			windowPrompts++
+		}
+	}
+	if windowPrompts != meta.WindowCount {
+		t.Errorf("Expected one prompt per window (%d), got %d", meta.WindowCount, windowPrompts)
+	}
+}
+
+// windowedContent returns content large enough to be analyzed in windows (tier 2)
+func windowedContent(t *testing.T) string {
+	t.Helper()
+
+	// Symbol-dense lines push the estimate past tier 1 while staying under the argv limit
+	var lines []string
+	for i := 0; len(lines) < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("user: step %d {[()]}{[()]}{[()]}{[()]}", i))
+	}
+	content := strings.Join(lines, "\n")
+	if tokens := llm.EstimateTokens(content); llm.SelectTier(tokens) != 2 {
+		t.Fatalf("Test content estimates %d tokens, expected tier 2", tokens)
+	}
+	return content
+}
+
+// TestContentMessages tests conversion of analyze content into windowable messages
+func TestContentMessages(t *testing.T) {
+	t.Run("Frontend JSON entries", func(t *testing.T) {
+		content := `[{"type":"user","message":{"content":"Fix it"}},{"type":"summary"},{"type":"assistant","message":{"content":"Done"}}]`
+		messages := contentMessages(content)
+		if len(messages) != 2 || messages[0].Line != 1 || messages[1].Line != 3 || messages[1].Type != "assistant" {
+			t.Errorf("Unexpected messages: %+v", messages)
+		}
+	})
+
+	t.Run("Plain text lines", func(t *testing.T) {
+		messages := contentMessages("user: Fix it\n\nassistant: Done\nnote: ratio 1:2")
+		if len(messages) != 3 {
+			t.Fatalf("Expected 3 messages, got %+v", messages)
+		}
+		if messages[0].Type != "user" || messages[0].Content != "Fix it" || messages[1].Line != 3 {
+			t.Errorf("Unexpected role parsing: %+v", messages[:2])
+		}
+		if messages[2].Type != "text" || messages[2].Content != "note: ratio 1:2" {
+			t.Errorf("Expected unknown prefixes kept as text, got %+v", messages[2])
+		}
+	})
+}
+
+// TestAnalyzeMetadata tests that timing and token metadata are filled in
+func TestAnalyzeMetadata(t *testing.T) {
+	content := "user: add streaming to the filter command"
+
+	t.Run("Summary", func(t *testing.T) {
+		installRoutingFakeClaude(t,
+			fakeRoute{Marker: "Reply with JSON only", Response: validAnalysisJSON},
+			fakeRoute{Marker: "concise summary", Response: validSummary},
+		)
+		t.Setenv("CLAUDE_MODEL", "test-model")
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", content, "--both")), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+
+		meta := response.Metadata
+		if meta == nil {
+			t.Fatal("Expected response metadata")
+		}
+		if meta.TokenCount != llm.EstimateTokens(content) || meta.ProcessingTier != 1 || meta.Model != "test-model" {
+			t.Errorf("Unexpected metadata: %+v", meta)
+		}
+		if meta.ProcessingTime <= 0 || meta.Timestamp.IsZero() {
+			t.Errorf("Expected processing time and timestamp, got %+v", meta)
+		}
+		if response.Analysis == nil || response.Analysis.Metadata.ProcessingTime <= 0 || response.Analysis.Metadata.TokenCount != meta.TokenCount {
+			t.Errorf("Expected structured analysis timing and tokens, got %+v", response.Analysis)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		installFakeClaude(t, "")
+		t.Setenv("CLAUDE_RETRY_BASE_DELAY", "1ms")
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", content)), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if response.Error == "" || response.Metadata == nil || response.Metadata.TokenCount == 0 {
+			t.Errorf("Expected an error with metadata, got %+v", response)
+		}
+	})
+}
+
+// TestAnalyzeFile tests analyzing a JSONL session directly, without a separate filter step
+func TestAnalyzeFile(t *testing.T) {
+	file := writeFixture(t, "abc-123.jsonl", `{"type":"user","message":{"content":"Please add a --file flag"}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Added it."},{"type":"tool_use","name":"Edit","input":{}}]}}
+{"type":"user","message":{"content":"Thanks, now add tests"}}
+`)
+
+	t.Run("Whole session", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--file", file)), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if response.SessionID != "abc-123" || response.Summary != validSummary {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		prompt := readPrompts(t, promptFile)[0]
+		want := "user: Please add a --file flag\nassistant: Added it.\nuser: Thanks, now add tests"
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected filtered conversation in prompt, got %q", prompt)
+		}
+		if strings.Contains(prompt, "tool_use") {
+			t.Errorf("Expected tool entries to be filtered out, got %q", prompt)
+		}
+	})
+
+	t.Run("Limit and explicit session ID", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--file", file, "--limit", "1", "--session-id", "s9")), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if response.SessionID != "s9" {
+			t.Errorf("Expected explicit session ID, got %q", response.SessionID)
+		}
+		prompt := readPrompts(t, promptFile)[0]
+		if strings.Contains(prompt, "Please add a --file flag") || !strings.Contains(prompt, "user: Thanks, now add tests") {
+			t.Errorf("Expected only the last message, got %q", prompt)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		installFakeClaude(t, validSummary)
+
+		if output := runMain(t, "analyze", "--file", file, "--content", "x"); !strings.Contains(output, "either --content or --file") {
+			t.Errorf("Expected conflict error, got %s", output)
+		}
+		if output := runMain(t, "analyze", "--file", "/nonexistent.jsonl"); !strings.Contains(output, "Error filtering file") {
+			t.Errorf("Expected read error, got %s", output)
+		}
+	})
+}
+
+// TestAnalyzeWithoutBoth tests that only the summary prompt is sent by default
+func TestAnalyzeWithoutBoth(t *testing.T) {
+	promptFile := installFakeClaude(t, validSummary)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: add streaming")
+	if strings.Contains(output, `"analysis"`) {
+		t.Errorf("Expected no analysis without --both, got %s", output)
+	}
+	if prompts := readPrompts(t, promptFile); len(prompts) != 1 {
+		t.Errorf("Expected one prompt, got %d", len(prompts))
+	}
+}
+
+// TestAnalyzeOutputLanguage tests language detection and the auto output language
+func TestAnalyzeOutputLanguage(t *testing.T) {
+	spanish := "user: El usuario pidió ayuda para corregir el error en la base de datos y el asistente propuso una solución con los índices."
+
+	t.Run("Detected but not applied by default", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", spanish)), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if response.Language != "es" {
+			t.Errorf("Expected detected language es, got %q", response.Language)
+		}
+		if prompt := readPrompts(t, promptFile)[0]; strings.Contains(prompt, "Write the entire summary in") {
+			t.Errorf("Expected no language instruction by default, got %q", prompt)
+		}
+	})
+
+	t.Run("Auto follows the session", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+		runMain(t, "analyze", "--session-id", "s1", "--content", spanish, "--output-language", "auto")
+		if prompt := readPrompts(t, promptFile)[0]; !strings.HasPrefix(prompt, "Write the entire summary in Spanish.") {
+			t.Errorf("Expected Spanish instruction, got %q", prompt)
+		}
+	})
+
+	t.Run("Explicit language", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+		runMain(t, "analyze", "--session-id", "s1", "--content", "user: fix the bug", "--output-language", "fr")
+		if prompt := readPrompts(t, promptFile)[0]; !strings.HasPrefix(prompt, "Write the entire summary in French.") {
+			t.Errorf("Expected French instruction, got %q", prompt)
+		}
+	})
+}
+
+// TestAnalyzeMissingBinary tests that a missing binary is reported before analysis
+func TestAnalyzeMissingBinary(t *testing.T) {
+	t.Setenv("CLAUDE_BINARY_PATH", "/nonexistent/claude")
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: hi")
+	if !strings.Contains(output, `"error"`) || !strings.Contains(output, "CLAUDE_BINARY_PATH") {
+		t.Errorf("Expected descriptive binary error, got %s", output)
+	}
+}
+
+// TestAnalyzeLoops tests that a repeated failing exchange is reported in the response
+func TestAnalyzeLoops(t *testing.T) {
+	installFakeClaude(t, validSummary)
+
+	content := strings.Join([]string{
+		"user: Run the build",
+		"user: The build still fails with exit code 2",
+		"assistant: Fixing the import path and rebuilding",
+		"user: The build still fails with exit code 2",
+		"assistant: Fixing the import path and rebuilding",
+		"user: The build still fails with exit code 2",
+		"assistant: Fixing the import path and rebuilding",
+	}, "\n")
+	output := runMain(t, "analyze", "--session-id", "loop-test", "--content", content)
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if len(response.Loops) != 1 {
+		t.Fatalf("Expected 1 loop, got %+v", response.Loops)
+	}
+	if loop := response.Loops[0]; loop.StartLine != 2 || loop.EndLine != 7 || loop.Repetitions != 3 {
+		t.Errorf("Expected 3 repetitions on lines 2-7, got %+v", loop)
+	}
+}
+
+// TestAnalyzeModelFlag tests that --model is used for the call and recorded in the response
+func TestAnalyzeModelFlag(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	t.Setenv("CLAUDE_MODEL", "configured-model")
+
+	var response SessionAnalysisResponse
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: hello", "--model", "big-model")
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if response.Metadata == nil || response.Metadata.Model != "big-model" {
+		t.Errorf("Expected effective model big-model in metadata, got %+v", response.Metadata)
+	}
+}
+
+// TestAnalyzeTimeout tests that the per-session deadline follows CLAUDE_TIMEOUT and the
+// retry budget instead of a fixed limit
+func TestAnalyzeTimeout(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	t.Setenv("CLAUDE_TIMEOUT", "15m")
+	t.Setenv("CLAUDE_RETRY_MAX_DELAY", "30s")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	run, err := newAnalyzeRun(cfg, map[string]string{"max-retries": "1"})
+	if err != nil {
+		t.Fatalf("newAnalyzeRun failed: %v", err)
+	}
+	// One attempt: the first call and two transport retries, with two backoffs
+	if got, want := run.timeout(), 3*15*time.Minute+2*30*time.Second; got != want {
+		t.Errorf("Expected a %v deadline, got %v", want, got)
+	}
+
+	run, err = newAnalyzeRun(cfg, map[string]string{})
+	if err != nil {
+		t.Fatalf("newAnalyzeRun failed: %v", err)
+	}
+	if got := run.timeout(); got <= 15*time.Minute*defaultMaxRetries {
+		t.Errorf("Expected the deadline to cover every summary attempt, got %v", got)
+	}
+}
+
+// TestAnalyzeProfileOutput tests that a profile's output format applies unless --output
+// is passed
+func TestAnalyzeProfileOutput(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	writeTestProfiles(t, `{"readable": {"output": "text"}}`)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: hi", "--profile", "readable")
+	if strings.HasPrefix(output, "{") || !strings.Contains(output, "Session: s1") {
+		t.Errorf("Expected text output from the profile, got %s", output)
+	}
+
+	output = runMain(t, "analyze", "--session-id", "s1", "--content", "user: hi", "--profile", "readable", "--output", "json")
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Errorf("Expected --output json to win, got %s", output)
+	}
+}
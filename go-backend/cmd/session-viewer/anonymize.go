@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of identifying value replaced by an Anonymizer
+const (
+	pseudonymUser   = "user"
+	pseudonymAuthor = "author"
+	pseudonymHost   = "host"
+)
+
+var (
+	// shellPromptPattern matches user@host in a shell prompt such as alice@macbook:~/src$.
+	// Hosts containing a dot are left alone so SSH remotes like git@github.com:org/repo
+	// keep their meaning.
+	shellPromptPattern = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_.-]*)@([A-Za-z0-9][A-Za-z0-9-]*):[~/]`)
+
+	// gitAuthorPattern matches "Name <email>" identities in git log output and commit
+	// trailers
+	gitAuthorPattern = regexp.MustCompile(`\b(?:Author|Commit|Committer|Co-authored-by|Signed-off-by):[ \t]+([^<\n]*[^<\s])[ \t]*<([^>\n]*)>`)
+
+	// localHostPattern matches machine names on a local network, such as
+	// Alices-MacBook-Pro.local. The surrounding groups keep file names like
+	// settings.local.json and .env.local from matching.
+	localHostPattern = regexp.MustCompile(`(^|[^\w.\-/])([A-Za-z0-9][A-Za-z0-9-]*)(\.(?:local|lan|localdomain|home\.arpa))($|[^\w.\-/])`)
+)
+
+// AnonymizationSummary reports what --anonymize replaced, without the real values
+type AnonymizationSummary struct {
+	Replacements int            `json:"replacements"` // Occurrences replaced
+	Pseudonyms   map[string]int `json:"pseudonyms"`   // Distinct values replaced, by kind: user, author, host
+}
+
+// Anonymizer replaces usernames in home-directory paths and shell prompts, git author
+// identities, and local hostnames with pseudonyms such as user1. Pseudonyms are stable:
+// every occurrence of a value, across all the content one Anonymizer sees, gets the
+// same one. This targets personal information; secrets are Redactor's job.
+type Anonymizer struct {
+	pseudonyms   map[string]map[string]string // Kind -> real value -> pseudonym
+	replacements int
+}
+
+// NewAnonymizer returns an Anonymizer that has assigned no pseudonyms yet
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{pseudonyms: map[string]map[string]string{}}
+}
+
+// pseudonym returns the pseudonym of a value of kind, assigning the next one on first sight
+func (a *Anonymizer) pseudonym(kind, value string) string {
+	a.replacements++
+	byValue := a.pseudonyms[kind]
+	if byValue == nil {
+		byValue = map[string]string{}
+		a.pseudonyms[kind] = byValue
+	}
+	if name, ok := byValue[value]; ok {
+		return name
+	}
+	name := fmt.Sprintf("%s%d", kind, len(byValue)+1)
+	byValue[value] = name
+	return name
+}
+
+// Anonymize replaces the identifying values in content
+func (a *Anonymizer) Anonymize(content string) string {
+	// Usernames are the path segment after the directory matched by the groups
+	for _, pattern := range homePathPatterns {
+		content = replaceMatches(content, pattern, func(m []int) string {
+			return content[m[0]:m[5]] + a.pseudonym(pseudonymUser, content[m[5]:m[1]])
+		})
+	}
+
+	content = replaceMatches(content, shellPromptPattern, func(m []int) string {
+		return a.pseudonym(pseudonymUser, content[m[2]:m[3]]) + "@" + a.pseudonym(pseudonymHost, content[m[4]:m[5]]) + content[m[5]:m[1]]
+	})
+
+	content = replaceMatches(content, gitAuthorPattern, func(m []int) string {
+		name := a.pseudonym(pseudonymAuthor, content[m[2]:m[3]])
+		return content[m[0]:m[2]] + name + " <" + name + "@example.invalid>"
+	})
+
+	content = replaceMatches(content, localHostPattern, func(m []int) string {
+		host := strings.ToLower(content[m[4]:m[5]])
+		return content[m[0]:m[4]] + a.pseudonym(pseudonymHost, host) + content[m[5]:m[1]]
+	})
+	return content
+}
+
+// Summary reports how many values of each kind have been replaced so far
+func (a *Anonymizer) Summary() *AnonymizationSummary {
+	summary := &AnonymizationSummary{Replacements: a.replacements, Pseudonyms: map[string]int{}}
+	for kind, byValue := range a.pseudonyms {
+		summary.Pseudonyms[kind] = len(byValue)
+	}
+	return summary
+}
+
+// replaceMatches replaces each match of re in content with replace's result for the
+// match's submatch indexes
+func replaceMatches(content string, re *regexp.Regexp, replace func(m []int) string) string {
+	matches := re.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(content[last:m[0]])
+		b.WriteString(replace(m))
+		last = m[1]
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
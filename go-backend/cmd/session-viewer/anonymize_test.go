@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestAnonymize tests each kind of identifying value and its pseudonym
+func TestAnonymize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Home path", "Edit /Users/alice/src/app/main.go", "Edit /Users/user1/src/app/main.go"},
+		{"Windows home path", `Open C:\Users\alice\notes.txt`, `Open C:\Users\user1\notes.txt`},
+		{"Shell prompt", "alice@macbook:~/src$ go test", "user1@host1:~/src$ go test"},
+		{"Git author", "commit abc123\nAuthor: Alice Smith <alice@example.com>", "commit abc123\nAuthor: author1 <author1@example.invalid>"},
+		{"Trailer", "Co-authored-by: Bob Jones <bob@corp.example>", "Co-authored-by: author1 <author1@example.invalid>"},
+		{"Local hostname", "ping Alices-MacBook-Pro.local failed", "ping host1.local failed"},
+		{"SSH remote kept", "git@github.com:org/repo.git", "git@github.com:org/repo.git"},
+		{"Local config file kept", "edit settings.local.json and .env.local", "edit settings.local.json and .env.local"},
+		{"Relative path kept", "see src/home/alice/readme", "see src/home/alice/readme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAnonymizer().Anonymize(tt.input); got != tt.expected {
+				t.Errorf("Anonymize(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAnonymizerStablePseudonyms tests that a value keeps its pseudonym across content
+func TestAnonymizerStablePseudonyms(t *testing.T) {
+	a := NewAnonymizer()
+	first := a.Anonymize("/home/alice/x and /home/bob/y")
+	second := a.Anonymize("alice@devbox:/home/alice/z")
+	if first != "/home/user1/x and /home/user2/y" || second != "user1@host1:/home/user1/z" {
+		t.Errorf("Unexpected pseudonyms: %q, %q", first, second)
+	}
+
+	summary := a.Summary()
+	if summary.Replacements != 5 || summary.Pseudonyms[pseudonymUser] != 2 || summary.Pseudonyms[pseudonymHost] != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+// TestFilterAndStatsAnonymize tests --anonymize on the filter and stats commands
+func TestFilterAndStatsAnonymize(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", strings.Join([]string{
+		`{"type":"user","message":{"content":"Fix /Users/alice/app/main.go"}}`,
+		`{"type":"assistant","message":{"content":"Fixed /Users/alice/app/main.go on alice-mbp.local"}}`,
+	}, "\n"))
+
+	var result FilterResult
+	output := runMain(t, "filter", "--file", path, "--anonymize")
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if strings.Contains(output, "alice") {
+		t.Errorf("Expected no real names in the output: %s", output)
+	}
+	if result.Messages[1].Content != "Fixed /Users/user1/app/main.go on host1.local" {
+		t.Errorf("Unexpected content: %q", result.Messages[1].Content)
+	}
+	if result.Anonymized == nil || result.Anonymized.Replacements != 3 || result.Anonymized.Pseudonyms[pseudonymUser] != 1 {
+		t.Errorf("Unexpected summary: %+v", result.Anonymized)
+	}
+
+	var plain FilterResult
+	if err := json.Unmarshal([]byte(runMain(t, "filter", "--file", path)), &plain); err != nil || plain.Anonymized != nil {
+		t.Errorf("Expected no summary without --anonymize, got %+v (%v)", plain.Anonymized, err)
+	}
+
+	var stats SessionStats
+	output = runMain(t, "stats", "--file", path, "--anonymize")
+	if err := json.Unmarshal([]byte(output), &stats); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if stats.File != path || stats.Anonymized == nil || stats.Anonymized.Replacements != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/claude"
+)
+
+// AskResponse represents the ask command output
+type AskResponse struct {
+	SessionID string `json:"session_id"` // Pass back with --session-id to continue the conversation
+	Model     string `json:"model"`      // Model that answered, from --model or the configuration
+	Response  string `json:"response"`
+}
+
+// handleAsk sends a prompt into a Claude CLI session, so an analysis can be followed up
+// ("expand on episode 3") without re-sending the whole conversation. Without
+// --session-id a new session is started and its ID returned for reuse; a given ID must
+// name a session the CLI still has. --model overrides the configured model for this call.
+func handleAsk(cfg *config.Config) {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer ask --prompt <text> [--session-id <id>] [--model <name>]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	prompt := flags["prompt"]
+	sessionID := flags["session-id"]
+
+	if prompt == "" {
+		respondError("Missing prompt")
+		return
+	}
+	if err := llm.ValidateSessionID(sessionID); err != nil {
+		respondError(err.Error())
+		return
+	}
+	// The HTTP API is stateless, so only the CLI can carry a conversation across calls
+	if !cfg.Claude.UsesCLI() {
+		respondError(fmt.Sprintf("The ask command requires the %s backend, not %s", config.BackendCLI, cfg.Claude.Backend))
+		return
+	}
+
+	wrapper := claude.NewWrapper(cfg)
+	wrapper.SetModel(flags["model"])
+	wrapper.SetLogger(newLogger(cfg))
+
+	if sessionID == "" {
+		id, err := wrapper.NewSessionID()
+		if err != nil {
+			respondError(fmt.Sprintf("Failed to start session: %v", err))
+			return
+		}
+		sessionID = id
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	response, err := wrapper.SendConversationalPrompt(ctx, prompt, sessionID)
+	if err != nil {
+		respondError(fmt.Sprintf("Error sending prompt: %v", err))
+		return
+	}
+
+	respondJSON(AskResponse{SessionID: sessionID, Model: wrapper.Model(), Response: response})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+)
+
+// TestAskCommand tests that a first prompt starts a session and returns its ID, and
+// that a follow-up keeps the ID it was given and can switch models
+func TestAskCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	promptFile := installFakeClaude(t, "Episode 3 was a debugging detour.")
+
+	var first AskResponse
+	output := runMain(t, "ask", "--prompt", "Expand on episode 3")
+	if err := json.Unmarshal([]byte(output), &first); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(first.SessionID) {
+		t.Errorf("Expected a generated session ID, got %q", first.SessionID)
+	}
+	if strings.TrimSpace(first.Response) != "Episode 3 was a debugging detour." {
+		t.Errorf("Unexpected response %q", first.Response)
+	}
+
+	// Stand in for the transcript the real CLI writes in the dated analysis directory
+	entries, err := os.ReadDir(os.Getenv("ANALYSIS_DIR"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one dated analysis directory, got %v (%v)", entries, err)
+	}
+	analysisDir := filepath.Join(os.Getenv("ANALYSIS_DIR"), entries[0].Name())
+	project := filepath.Join(home, ".claude", "projects", strings.ReplaceAll(analysisDir, string(filepath.Separator), "-"))
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(project, first.SessionID+".jsonl"), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	var followUp AskResponse
+	output = runMain(t, "ask", "--session-id", first.SessionID, "--prompt", "Why?", "--model", "big-model")
+	if err := json.Unmarshal([]byte(output), &followUp); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if followUp.SessionID != first.SessionID {
+		t.Errorf("Expected session %s to be reused, got %s", first.SessionID, followUp.SessionID)
+	}
+	if first.Model != config.DefaultModel || followUp.Model != "big-model" {
+		t.Errorf("Expected the configured then the --model model, got %q and %q", first.Model, followUp.Model)
+	}
+
+	prompts := readPrompts(t, promptFile)
+	if len(prompts) != 2 || prompts[0] != "Expand on episode 3" || prompts[1] != "Why?" {
+		t.Errorf("Expected the prompts to be sent as given, got %q", prompts)
+	}
+}
+
+// TestAskCommandErrors tests argument validation
+func TestAskCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		args    []string
+		want    string
+	}{
+		{"Missing prompt", "", []string{"--session-id", "s1"}, "Missing prompt"},
+		{"Unsafe session ID", "", []string{"--session-id", "../s1", "--prompt", "hi"}, "invalid session ID"},
+		{"Unknown session", "", []string{"--session-id", "s1", "--prompt", "hi"}, "session not found: s1"},
+		{"Stateless backend", config.BackendAnthropic, []string{"--prompt", "hi"}, "requires the cli backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("ANALYSIS_DIR", t.TempDir())
+			if tt.backend != "" {
+				t.Setenv("CLAUDE_BACKEND", tt.backend)
+				t.Setenv(config.AnthropicAPIKeyEnv, "test-key")
+			}
+			output := runMain(t, append([]string{"ask"}, tt.args...)...)
+			if !strings.Contains(output, `"error"`) || !strings.Contains(output, tt.want) {
+				t.Errorf("Expected error %q, got: %s", tt.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/anthropic"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/claude"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// transportRetrier is implemented by analyzers that retry their own transient failures
+// (timeouts, failed CLI calls, rate limits) with backoff, like *claude.Wrapper and
+// *anthropic.Client
+type transportRetrier interface {
+	SendWithRetry(ctx context.Context, prompt string, sessionID string, cfg llm.ProcessingConfig) (string, error)
+}
+
+// newAnalyzer returns the model backend selected by cfg.Claude.Backend, calling model
+// (cfg.Claude.Model when empty). Structured replies must also meet validation.
+func newAnalyzer(cfg *config.Config, model string, validation validator.Options) (llm.Analyzer, error) {
+	switch cfg.Claude.Backend {
+	case "", config.BackendCLI:
+		wrapper := claude.NewWrapper(cfg)
+		wrapper.SetModel(model)
+		wrapper.SetValidationOptions(validation)
+		wrapper.SetLogger(newLogger(cfg))
+		return wrapper, nil
+	case config.BackendAnthropic:
+		client, err := anthropic.NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		client.SetModel(model)
+		client.SetValidationOptions(validation)
+		return client, nil
+	}
+	return nil, fmt.Errorf("Unsupported backend: %s", cfg.Claude.Backend)
+}
+
+// sendWithRetry sends a conversational prompt, using the analyzer's own transport
+// retries when it has them and a single attempt otherwise
+func sendWithRetry(ctx context.Context, analyzer llm.Analyzer, prompt string, cfg llm.ProcessingConfig) (string, error) {
+	if retrier, ok := analyzer.(transportRetrier); ok {
+		return retrier.SendWithRetry(ctx, prompt, "", cfg)
+	}
+	return analyzer.SendConversationalPrompt(ctx, prompt, "")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/claude"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// stubAnalyzer is an llm.Analyzer without transport retries
+type stubAnalyzer struct {
+	calls int
+	err   error
+}
+
+func (s *stubAnalyzer) SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error) {
+	s.calls++
+	return "reply", s.err
+}
+
+func (s *stubAnalyzer) SendStructuredPrompt(ctx context.Context, prompt string, sessionID string) (*llm.Analysis, error) {
+	return nil, errors.New("not implemented")
+}
+
+// TestNewAnalyzer tests backend selection
+func TestNewAnalyzer(t *testing.T) {
+	for _, backend := range []string{"", config.BackendCLI} {
+		analyzer, err := newAnalyzer(&config.Config{Claude: config.ClaudeConfig{Backend: backend}}, "", validator.Options{})
+		if err != nil {
+			t.Fatalf("newAnalyzer(%q) failed: %v", backend, err)
+		}
+		if _, ok := analyzer.(*claude.Wrapper); !ok {
+			t.Errorf("Expected the CLI wrapper for backend %q, got %T", backend, analyzer)
+		}
+	}
+
+	if _, err := newAnalyzer(&config.Config{Claude: config.ClaudeConfig{Backend: "smoke-signals"}}, "", validator.Options{}); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
+
+// TestSendWithRetryWithoutRetrier tests that analyzers without transport retries get one attempt
+func TestSendWithRetryWithoutRetrier(t *testing.T) {
+	stub := &stubAnalyzer{err: errors.New("boom")}
+	if _, err := sendWithRetry(context.Background(), stub, "prompt", llm.ProcessingConfig{MaxRetries: 3}); err == nil {
+		t.Fatal("Expected the stub's error")
+	}
+	if stub.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", stub.calls)
+	}
+}
+
+// TestAnalyzeInvalidBackend tests that an unknown CLAUDE_BACKEND is reported before analysis
+func TestAnalyzeInvalidBackend(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	t.Setenv("CLAUDE_BACKEND", "smoke-signals")
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "hello")
+	if !strings.Contains(output, `"error"`) || !strings.Contains(output, "CLAUDE_BACKEND") {
+		t.Errorf("Expected backend error, got %s", output)
+	}
+}
+
+// TestAnalyzeAnthropicBackend tests a summary requested through the Messages API
+func TestAnalyzeAnthropicBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"content":[{"type":"text","text":%q}]}`, validSummary)
+	}))
+	defer server.Close()
+
+	t.Setenv("CLAUDE_BACKEND", config.BackendAnthropic)
+	t.Setenv("CLAUDE_BINARY_PATH", "/nonexistent/claude")
+	t.Setenv(config.AnthropicAPIKeyEnv, "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+
+	var response SessionAnalysisResponse
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: hello")
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if response.Error != "" || response.Summary != validSummary {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
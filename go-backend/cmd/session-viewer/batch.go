@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+)
+
+// defaultBatchConcurrency is how many sessions analyze --dir analyzes at once
+const defaultBatchConcurrency = 3
+
+// handleAnalyzeDir analyzes every *.jsonl session in the --dir directory and responds
+// with one SessionAnalysisResponse per file, in file name order. A file that can't be
+// read or analyzed gets an Error instead of stopping the batch.
+func handleAnalyzeDir(cfg *config.Config, flags map[string]string) {
+	if flags["file"] != "" || flags["content"] != "" {
+		respondError("Use only one of --content, --file, or --dir")
+		return
+	}
+	if flags["dry-run"] != "" {
+		respondError("--dry-run can't be combined with --dir; dry-run one session with --file")
+		return
+	}
+
+	limit, err := parseIntFlag(flags, "limit", defaultMessageLimit)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	startLine, endLine, err := parseLineRange(flags)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	maxChars, err := parseIntFlag(flags, "max-chars", 0)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	concurrency, err := parseIntFlag(flags, "concurrency", defaultBatchConcurrency)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	if concurrency < 1 {
+		respondError("Invalid concurrency: must be at least 1")
+		return
+	}
+
+	files, err := sessionFiles(flags["dir"])
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	run, err := newAnalyzeRun(cfg, flags)
+	if err != nil {
+		respondSetupError(err)
+		return
+	}
+
+	filter := FilterOptions{StartLine: startLine, EndLine: endLine}
+	progress := newProgress(run.opts.progress, len(files))
+	responses := analyzeFiles(files, concurrency, func(filePath string) SessionAnalysisResponse {
+		progress.step(filepath.Base(filePath))
+		sessionID := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+
+		ctx, cancel := context.WithTimeout(context.Background(), run.timeout())
+		defer cancel()
+
+		content, err := sessionFileContent(ctx, filePath, limit, filter)
+		if err != nil {
+			return failedResponse(sessionID, fmt.Sprintf("Error filtering file: %v", err))
+		}
+		if strings.TrimSpace(content) == "" {
+			return failedResponse(sessionID, "No content within the requested line range")
+		}
+
+		return run.analyze(ctx, sessionID, trimToMaxChars(content, maxChars))
+	})
+
+	respondJSON(responses)
+}
+
+// sessionFiles returns the *.jsonl files directly inside dir, sorted by name
+func sessionFiles(dir string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Not a directory: %s", dir)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No .jsonl files in %s", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// analyzeFiles runs analyze over every file using at most concurrency goroutines and
+// returns the responses in file order, each tagged with its file name
+func analyzeFiles(files []string, concurrency int, analyze func(filePath string) SessionAnalysisResponse) []SessionAnalysisResponse {
+	responses := make([]SessionAnalysisResponse, len(files))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, filePath := range files {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, filePath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			response := analyze(filePath)
+			response.File = filepath.Base(filePath)
+			responses[i] = response
+		}(i, filePath)
+	}
+
+	wg.Wait()
+	return responses
+}
+
+// failedResponse reports a session that couldn't be analyzed
+func failedResponse(sessionID, message string) SessionAnalysisResponse {
+	return SessionAnalysisResponse{
+		SessionID: sessionID,
+		Summary:   "Analysis failed - " + message,
+		Error:     message,
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeSessionDir creates a directory of JSONL sessions named by the keys of sessions
+func writeSessionDir(t *testing.T, sessions map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range sessions {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write session: %v", err)
+		}
+	}
+	return dir
+}
+
+// TestAnalyzeDir tests that every session is analyzed and failures stay per file
+func TestAnalyzeDir(t *testing.T) {
+	dir := writeSessionDir(t, map[string]string{
+		"b-session.jsonl": `{"type":"user","message":{"content":"Fix the parser bug"}}` + "\n",
+		"a-session.jsonl": `{"type":"user","message":{"content":"Add a cache layer"}}` + "\n",
+		"empty.jsonl":     "",
+		"notes.txt":       "not a session",
+	})
+	promptFile := installRoutingFakeClaude(t,
+		fakeRoute{Marker: "cache layer", Response: "Domain: Go. Key Tasks: Added a cache layer. Complexity: Simple."},
+		fakeRoute{Marker: "parser bug", Response: "Domain: Go. Key Tasks: Fixed a parser bug. Complexity: Simple."},
+	)
+
+	output := runMain(t, "analyze", "--dir", dir, "--concurrency", "2")
+
+	var responses []SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &responses); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("Expected 3 responses, got %d: %s", len(responses), output)
+	}
+
+	wantFiles := []string{"a-session.jsonl", "b-session.jsonl", "empty.jsonl"}
+	for i, want := range wantFiles {
+		if responses[i].File != want {
+			t.Errorf("Response %d: expected file %s, got %s", i, want, responses[i].File)
+		}
+	}
+
+	if responses[0].SessionID != "a-session" || !strings.Contains(responses[0].Summary, "cache layer") {
+		t.Errorf("Unexpected first response: %+v", responses[0])
+	}
+	if responses[1].Error != "" || !strings.Contains(responses[1].Summary, "parser bug") {
+		t.Errorf("Unexpected second response: %+v", responses[1])
+	}
+	if responses[2].Error == "" {
+		t.Errorf("Expected an error for the empty session, got %+v", responses[2])
+	}
+
+	if prompts := readPrompts(t, promptFile); len(prompts) != 2 {
+		t.Errorf("Expected 2 prompts, got %d", len(prompts))
+	}
+}
+
+// TestAnalyzeDirErrors tests argument and directory errors
+func TestAnalyzeDirErrors(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	dir := writeSessionDir(t, map[string]string{"notes.txt": "not a session"})
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"No sessions", []string{"--dir", dir}, "No .jsonl files"},
+		{"Missing directory", []string{"--dir", filepath.Join(dir, "missing")}, "Error reading directory"},
+		{"Conflicting input", []string{"--dir", dir, "--content", "x"}, "only one of"},
+		{"Invalid concurrency", []string{"--dir", dir, "--concurrency", "0"}, "Invalid concurrency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runMain(t, append([]string{"analyze"}, tt.args...)...)
+			if !strings.Contains(output, `"error"`) || !strings.Contains(output, tt.want) {
+				t.Errorf("Expected error containing %q, got %s", tt.want, output)
+			}
+		})
+	}
+}
+
+// TestAnalyzeFilesConcurrency tests that no more than the requested number of files run at once
+func TestAnalyzeFilesConcurrency(t *testing.T) {
+	files := []string{"a.jsonl", "b.jsonl", "c.jsonl", "d.jsonl", "e.jsonl"}
+	var (
+		mu            sync.Mutex
+		running, peak int
+	)
+
+	responses := analyzeFiles(files, 2, func(filePath string) SessionAnalysisResponse {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return SessionAnalysisResponse{SessionID: filePath}
+	})
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent analyses, saw %d", peak)
+	}
+	for i, response := range responses {
+		if response.File != files[i] {
+			t.Errorf("Response %d: expected file %s, got %s", i, files[i], response.File)
+		}
+	}
+}
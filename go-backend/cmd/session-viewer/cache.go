@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/cache"
+)
+
+// CacheListResponse describes the entries in the response cache
+type CacheListResponse struct {
+	Dir            string            `json:"dir"`
+	Entries        []cache.EntryInfo `json:"entries"`
+	Count          int               `json:"count"`
+	TotalSizeBytes int64             `json:"total_size_bytes"`
+}
+
+// CacheClearResponse reports how many cache entries were removed
+type CacheClearResponse struct {
+	Dir     string `json:"dir"`
+	Removed int    `json:"removed"`
+}
+
+// handleCache lists or clears the response cache written by analyze --cache
+func handleCache(cfg *config.Config) {
+	const usage = "Usage: session-viewer cache list | cache clear [--older-than <duration>]"
+	if len(os.Args) < 3 {
+		respondError(usage)
+		return
+	}
+
+	store := cache.NewStore(cfg.Paths.CacheDir)
+	switch os.Args[2] {
+	case "list":
+		entries, err := store.List()
+		if err != nil {
+			respondError(fmt.Sprintf("Error reading cache: %v", err))
+			return
+		}
+		response := CacheListResponse{Dir: store.Dir(), Entries: entries, Count: len(entries)}
+		for _, entry := range entries {
+			response.TotalSizeBytes += entry.SizeBytes
+		}
+		respondJSON(response)
+	case "clear":
+		flags := parseFlags(os.Args[3:])
+		var olderThan time.Duration
+		if value, ok := flags["older-than"]; ok {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				respondError(fmt.Sprintf("Invalid older-than: %s (expected a duration such as 24h)", value))
+				return
+			}
+			olderThan = d
+		}
+
+		removed, err := store.Clear(olderThan)
+		if err != nil {
+			respondError(fmt.Sprintf("Error clearing cache: %v", err))
+			return
+		}
+		respondJSON(CacheClearResponse{Dir: store.Dir(), Removed: removed})
+	default:
+		respondError(fmt.Sprintf("Unknown cache action: %s", os.Args[2]))
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAnalyzeCache tests that analyze --cache reuses a stored summary and that the
+// cache command lists and clears it
+func TestAnalyzeCache(t *testing.T) {
+	t.Setenv("SESSION_VIEWER_CACHE_DIR", t.TempDir())
+	promptFile := installFakeClaude(t, validSummary)
+
+	for i := 0; i < 2; i++ {
+		output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: hello", "--cache")
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(output), &response); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, output)
+		}
+		if response.Summary != validSummary {
+			t.Fatalf("Unexpected summary on run %d: %q", i+1, response.Summary)
+		}
+		if wantAttempts := 1 - i; response.Attempts != wantAttempts || response.UsedFallbackPrompt {
+			t.Errorf("Run %d: expected %d attempt(s) without the fallback prompt, got %d (%v)", i+1, wantAttempts, response.Attempts, response.UsedFallbackPrompt)
+		}
+	}
+	if prompts := readPrompts(t, promptFile); len(prompts) != 1 {
+		t.Errorf("Expected the second run to be served from cache, got %d prompts", len(prompts))
+	}
+
+	var list CacheListResponse
+	if err := json.Unmarshal([]byte(runMain(t, "cache", "list")), &list); err != nil {
+		t.Fatalf("Invalid list JSON: %v", err)
+	}
+	if list.Count != 1 || list.TotalSizeBytes == 0 || list.Entries[0].Model == "" {
+		t.Errorf("Unexpected list: %+v", list)
+	}
+
+	var cleared CacheClearResponse
+	json.Unmarshal([]byte(runMain(t, "cache", "clear", "--older-than", "1h")), &cleared)
+	if cleared.Removed != 0 {
+		t.Errorf("Expected a fresh entry to be kept, removed %d", cleared.Removed)
+	}
+	json.Unmarshal([]byte(runMain(t, "cache", "clear")), &cleared)
+	if cleared.Removed != 1 {
+		t.Errorf("Expected 1 removed, got %d", cleared.Removed)
+	}
+}
+
+// TestAnalyzeCacheTemplateOverride tests that editing a prompt template override
+// misses the cache instead of replaying a summary built from the old prompt
+func TestAnalyzeCacheTemplateOverride(t *testing.T) {
+	t.Setenv("SESSION_VIEWER_CACHE_DIR", t.TempDir())
+	dir := t.TempDir()
+	t.Setenv("SESSION_VIEWER_PROMPTS_DIR", dir)
+	promptFile := installFakeClaude(t, validSummary)
+
+	for i, template := range []string{"Summarize:\n{{.Content}}", "Summarize briefly:\n{{.Content}}", "Summarize briefly:\n{{.Content}}"} {
+		if err := os.WriteFile(filepath.Join(dir, "summary.tmpl"), []byte(template), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runMain(t, "analyze", "--session-id", "s1", "--content", "user: hello", "--cache")
+		if prompts, want := readPrompts(t, promptFile), min(i+1, 2); len(prompts) != want {
+			t.Fatalf("Run %d: expected %d prompts sent in total, got %d", i+1, want, len(prompts))
+		}
+	}
+	if prompts := readPrompts(t, promptFile); !strings.HasPrefix(prompts[1], "Summarize briefly:") {
+		t.Errorf("Expected the edited template to be sent, got %q", prompts[1])
+	}
+}
+
+// TestCacheCommandErrors tests missing and unknown actions and a bad duration
+func TestCacheCommandErrors(t *testing.T) {
+	t.Setenv("SESSION_VIEWER_CACHE_DIR", t.TempDir())
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"cache"}, "Usage"},
+		{[]string{"cache", "purge"}, "Unknown cache action"},
+		{[]string{"cache", "clear", "--older-than", "soon"}, "Invalid older-than"},
+	}
+	for _, tt := range tests {
+		output := runMain(t, tt.args...)
+		if !strings.Contains(output, tt.want) {
+			t.Errorf("%v: expected %q, got %s", tt.args, tt.want, output)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// quotedSpanPattern matches text inside straight or curly double quotes, which is
+// the model quoting the conversation rather than speaking conversationally itself
+var quotedSpanPattern = regexp.MustCompile(`"[^"\n]*"|“[^”\n]*”`)
+
+// ResponseRules holds the phrase lists used to detect conversational or out-of-scope
+// responses. Lists omitted from a rules file keep their built-in defaults.
+type ResponseRules struct {
+	ErrorPhrases    []string `json:"error_phrases"`    // Matched anywhere in the response
+	ActionStarts    []string `json:"action_starts"`    // Matched at the start of the response
+	QuestionPhrases []string `json:"question_phrases"` // Questions directed at the user
+}
+
+// defaultResponseRules are the built-in phrase lists
+var defaultResponseRules = ResponseRules{
+	// Limitation/error phrases
+	ErrorPhrases: []string{
+		"i've hit a technical limitation",
+		"i can't access",
+		"i cannot access",
+		"i don't have access",
+		"i'm unable to access",
+		"technical limitation",
+		"i need you to",
+		"please run",
+		"please share",
+		"let me ",           // AI offering to do something (e.g., "Let me revert my changes")
+		"i'll ",             // AI committing to action
+		"i will ",           // AI committing to action
+		"the fix should",    // AI providing implementation advice instead of analysis
+		"you should",        // AI giving instructions instead of analyzing
+		"you need to",       // AI giving instructions
+		"you're right",      // AI validating user in conversation (e.g., "You're absolutely right!")
+		"you're absolutely", // AI giving strong validation
+		"you're correct",    // AI agreeing with user
+		"i made a",          // AI admitting errors in active conversation
+		"i apologize for",   // AI apologizing for mistakes
+		"should i ",         // AI asking for permission/direction
+		"shall i ",          // AI asking for direction
+	},
+	// Action-oriented or conversational openings (checked in first 100 chars)
+	ActionStarts: []string{
+		"here's the",
+		"here is the",
+		"i've created",
+		"i've updated",
+		"i've implemented",
+		"no!",       // Conversational disagreement (e.g., "No! We're **not** removing...")
+		"yes!",      // Conversational agreement
+		"we're not", // Conversational discussion about code
+		"we're ",    // General conversational "we"
+	},
+	// Questions directed at user
+	QuestionPhrases: []string{
+		"can you either:",
+		"can you ",
+		"could you ",
+		"would you ",
+		"can you please",
+	},
+}
+
+// loadResponseRules returns the rules from the given JSON file, or the built-in
+// defaults when path is empty
+func loadResponseRules(path string) (*ResponseRules, error) {
+	// Copy the default lists so callers can't mutate the shared defaults
+	rules := ResponseRules{
+		ErrorPhrases:    append([]string(nil), defaultResponseRules.ErrorPhrases...),
+		ActionStarts:    append([]string(nil), defaultResponseRules.ActionStarts...),
+		QuestionPhrases: append([]string(nil), defaultResponseRules.QuestionPhrases...),
+	}
+	if path == "" {
+		return &rules, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter rules %s: %w", path, err)
+	}
+
+	var override ResponseRules
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("failed to parse filter rules %s: %w", path, err)
+	}
+
+	if override.ErrorPhrases != nil {
+		rules.ErrorPhrases = override.ErrorPhrases
+	}
+	if override.ActionStarts != nil {
+		rules.ActionStarts = override.ActionStarts
+	}
+	if override.QuestionPhrases != nil {
+		rules.QuestionPhrases = override.QuestionPhrases
+	}
+
+	return &rules, nil
+}
+
+// isErrorResponse checks a response against the built-in rules
+func isErrorResponse(response string) (bool, string) {
+	return defaultResponseRules.isErrorResponse(response)
+}
+
+// isErrorResponse checks if Claude's response is an out-of-scope error message
+// instead of a proper analysis summary. When it is, the second return value names
+// the rule that matched.
+func (r *ResponseRules) isErrorResponse(response string) (bool, string) {
+	responseLower := strings.ToLower(response)
+	unquotedLower := quotedSpanPattern.ReplaceAllString(responseLower, " ")
+
+	// Very short responses are likely errors
+	if len(strings.TrimSpace(response)) < 50 {
+		return true, "response too short"
+	}
+
+	// Check for limitation/error phrases
+	for _, phrase := range r.ErrorPhrases {
+		if containsPhrase(unquotedLower, phrase) {
+			return true, "matched phrase: " + strings.TrimSpace(phrase)
+		}
+	}
+
+	// Check if response starts with action-oriented or conversational phrases (first 100 chars)
+	responseStart := responseLower
+	if len(responseStart) > 100 {
+		responseStart = responseLower[:100]
+	}
+	for _, phrase := range r.ActionStarts {
+		if hasPhrasePrefix(responseStart, phrase) {
+			return true, "matched opening: " + strings.TrimSpace(phrase)
+		}
+	}
+
+	// Check for exclamation marks in first sentence (very conversational)
+	firstSentence := responseStart
+	if dotPos := strings.Index(responseStart, "."); dotPos > 0 && dotPos < 100 {
+		firstSentence = responseStart[:dotPos]
+	}
+	if strings.Contains(firstSentence, "!") {
+		return true, "exclamation in first sentence"
+	}
+
+	// Check for questions directed at user
+	for _, phrase := range r.QuestionPhrases {
+		if containsPhrase(unquotedLower, phrase) {
+			return true, "question to user: " + strings.TrimSpace(phrase)
+		}
+	}
+
+	// Check for code blocks suggesting commands to run
+	if strings.Contains(response, "```bash") ||
+		strings.Contains(response, "```sh") ||
+		(strings.Contains(response, "```") && strings.Contains(responseLower, "cd /")) {
+		return true, "shell command code block"
+	}
+
+	// Valid summary received
+	return false, ""
+}
+
+// containsPhrase reports whether phrase occurs in text on word boundaries, so that
+// "i will" does not match inside "the api will" and "will" does not match "willingness"
+func containsPhrase(text, phrase string) bool {
+	phrase = strings.TrimSpace(phrase)
+	if phrase == "" {
+		return false
+	}
+
+	for offset := 0; offset < len(text); {
+		idx := strings.Index(text[offset:], phrase)
+		if idx < 0 {
+			return false
+		}
+		start := offset + idx
+		end := start + len(phrase)
+		if boundaryBefore(text, start, phrase) && boundaryAfter(text, end, phrase) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+	return false
+}
+
+// hasPhrasePrefix reports whether text starts with phrase followed by a word boundary
+func hasPhrasePrefix(text, phrase string) bool {
+	phrase = strings.TrimSpace(phrase)
+	return phrase != "" && strings.HasPrefix(text, phrase) && boundaryAfter(text, len(phrase), phrase)
+}
+
+// boundaryBefore reports whether the match at start is not preceded by a word character.
+// Phrases that begin with punctuation need no boundary.
+func boundaryBefore(text string, start int, phrase string) bool {
+	first, _ := utf8.DecodeRuneInString(phrase)
+	if !isWordChar(first) || start == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(text[:start])
+	return !isWordChar(prev)
+}
+
+// boundaryAfter reports whether the match ending at end is not followed by a word character.
+// Phrases that end with punctuation need no boundary.
+func boundaryAfter(text string, end int, phrase string) bool {
+	last, _ := utf8.DecodeLastRuneInString(phrase)
+	if !isWordChar(last) || end >= len(text) {
+		return true
+	}
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordChar(next)
+}
+
+// isWordChar reports whether r can be part of a word
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
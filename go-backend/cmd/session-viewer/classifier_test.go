@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadResponseRulesDefaults tests fallback to the built-in rules
+func TestLoadResponseRulesDefaults(t *testing.T) {
+	rules, err := loadResponseRules("")
+	if err != nil {
+		t.Fatalf("loadResponseRules failed: %v", err)
+	}
+
+	if len(rules.ErrorPhrases) != len(defaultResponseRules.ErrorPhrases) {
+		t.Errorf("Expected %d default error phrases, got %d", len(defaultResponseRules.ErrorPhrases), len(rules.ErrorPhrases))
+	}
+
+	// Mutating the loaded rules must not affect the defaults
+	rules.ErrorPhrases[0] = "changed"
+	if defaultResponseRules.ErrorPhrases[0] == "changed" {
+		t.Error("loadResponseRules returned the shared default slice")
+	}
+}
+
+// TestLoadResponseRulesFromFile tests overriding phrase lists from JSON
+func TestLoadResponseRulesFromFile(t *testing.T) {
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	// Override error phrases only; "you should" is no longer a rejection trigger
+	data := `{"error_phrases": ["i cannot access", "please run"]}`
+	if err := os.WriteFile(rulesPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+
+	rules, err := loadResponseRules(rulesPath)
+	if err != nil {
+		t.Fatalf("loadResponseRules failed: %v", err)
+	}
+
+	if len(rules.ErrorPhrases) != 2 {
+		t.Errorf("Expected 2 error phrases from file, got %d", len(rules.ErrorPhrases))
+	}
+	if len(rules.QuestionPhrases) != len(defaultResponseRules.QuestionPhrases) {
+		t.Error("Expected omitted question phrases to keep defaults")
+	}
+
+	summary := "Domain: Team onboarding documentation. The guide explains what you should configure first. Complexity: Simple."
+	if rejected, _ := isErrorResponse(summary); !rejected {
+		t.Fatal("Expected built-in rules to reject quoted 'you should'")
+	}
+	if rejected, _ := rules.isErrorResponse(summary); rejected {
+		t.Error("Expected custom rules to accept summary quoting 'you should'")
+	}
+	if rejected, _ := rules.isErrorResponse("I cannot access the file you referenced, so the analysis could not be completed."); !rejected {
+		t.Error("Expected custom error phrase to still be rejected")
+	}
+}
+
+// TestLoadResponseRulesErrors tests unreadable and malformed rules files
+func TestLoadResponseRulesErrors(t *testing.T) {
+	if _, err := loadResponseRules("/nonexistent/rules.json"); err == nil {
+		t.Error("Expected error for missing rules file")
+	}
+
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(rulesPath, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+	_, err := loadResponseRules(rulesPath)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse filter rules") {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+}
+
+// TestIsErrorResponseReasons tests that each rejection names the rule that matched
+func TestIsErrorResponseReasons(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       string
+		expectedReason string
+	}{
+		{
+			name:           "Too short",
+			response:       "Short text",
+			expectedReason: "response too short",
+		},
+		{
+			name:           "Error phrase",
+			response:       "Domain: Go. The assistant said you're right about the config and moved on to other work.",
+			expectedReason: "matched phrase: you're right",
+		},
+		{
+			name:           "Conversational opening",
+			response:       "Here's the summary of what happened in the session, covering the main refactoring work done.",
+			expectedReason: "matched opening: here's the",
+		},
+		{
+			name:           "Exclamation",
+			response:       "Great session overall! The work covered refactoring of the parser and improved its tests.",
+			expectedReason: "exclamation in first sentence",
+		},
+		{
+			name:           "Question to user",
+			response:       "The session covered parser work. Could you clarify which of the branches should be analyzed?",
+			expectedReason: "question to user: could you",
+		},
+		{
+			name:           "Shell code block",
+			response:       "Domain: Build tooling. Main Topic: CI setup.\n```sh\nmake build\n```\nComplexity: Simple.",
+			expectedReason: "shell command code block",
+		},
+		{
+			name:           "Valid summary",
+			response:       validSummary,
+			expectedReason: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rejected, reason := isErrorResponse(tt.response)
+			if rejected != (tt.expectedReason != "") {
+				t.Fatalf("isErrorResponse(%q) rejected = %v, reason %q", tt.response, rejected, reason)
+			}
+			if reason != tt.expectedReason {
+				t.Errorf("Expected reason %q, got %q", tt.expectedReason, reason)
+			}
+		})
+	}
+}
+
+// TestAnalyzeRejectedReasons tests that rejected attempts are reported in the response
+func TestAnalyzeRejectedReasons(t *testing.T) {
+	installFakeClaude(t,
+		"You're absolutely right! I made an error and will fix the configuration for you now.",
+		"Short text",
+		validSummary,
+	)
+
+	output := runMain(t, "analyze", "--session-id", "reasons-test", "--content", "conversation")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+
+	if response.Summary != validSummary {
+		t.Errorf("Expected final valid summary, got %q", response.Summary)
+	}
+	if response.Attempts != 3 || !response.UsedFallbackPrompt {
+		t.Errorf("Expected 3 attempts using the fallback prompt, got %d (%v)", response.Attempts, response.UsedFallbackPrompt)
+	}
+
+	expected := []string{
+		"attempt 1: matched phrase: you're absolutely",
+		"attempt 2: response too short",
+	}
+	if len(response.RejectedReasons) != len(expected) {
+		t.Fatalf("Expected rejected reasons %v, got %v", expected, response.RejectedReasons)
+	}
+	for i := range expected {
+		if response.RejectedReasons[i] != expected[i] {
+			t.Errorf("Reason %d = %q, want %q", i, response.RejectedReasons[i], expected[i])
+		}
+	}
+}
+
+// TestIsErrorResponseWordBoundaries tests that analytical summaries aren't rejected
+// for phrases appearing inside other words or quoted from the conversation
+func TestIsErrorResponseWordBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		isError  bool
+	}{
+		{
+			name:     "Phrase inside another word",
+			response: "Domain: Backend services. The API will retry failed requests, and the team showed willingness to refactor. Complexity: Moderate.",
+			isError:  false,
+		},
+		{
+			name:     "Quoted phrase from the conversation",
+			response: `Domain: Code review. The reviewer wrote "you should add tests" and the user then added unit tests for the parser. Complexity: Simple.`,
+			isError:  false,
+		},
+		{
+			name:     "Curly-quoted question from the conversation",
+			response: "Domain: Support triage. The user asked “can you reproduce this?” and the assistant traced the crash to a nil map. Complexity: Simple.",
+			isError:  false,
+		},
+		{
+			name:     "Summary describing what the user did",
+			response: "Domain: Python scripting. The user said they will migrate the cron jobs; the outlet metrics were reviewed. Complexity: Simple.",
+			isError:  false,
+		},
+		{
+			name:     "Opening phrase followed by a longer word",
+			response: "Here's theory behind the design: the session documented the cache layer and its eviction policy in detail.",
+			isError:  false,
+		},
+		{
+			name:     "Unquoted instruction still rejected",
+			response: "The configuration is wrong and you should update it before running the build command again.",
+			isError:  true,
+		},
+		{
+			name:     "Unquoted first person still rejected",
+			response: "The analysis is incomplete, so I will need the remaining log files to finish the summary.",
+			isError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, reason := isErrorResponse(tt.response)
+			if result != tt.isError {
+				t.Errorf("isErrorResponse(%q) = %v (%s), want %v", tt.response, result, reason, tt.isError)
+			}
+		})
+	}
+}
+
+// TestContainsPhrase tests word-boundary phrase matching
+func TestContainsPhrase(t *testing.T) {
+	tests := []struct {
+		text     string
+		phrase   string
+		expected bool
+	}{
+		{text: "i will do it", phrase: "i will ", expected: true},
+		{text: "the api will do it", phrase: "i will ", expected: false},
+		{text: "later the api will do it, i will too", phrase: "i will", expected: true},
+		{text: "willingness", phrase: "will", expected: false},
+		{text: "can you either: a or b", phrase: "can you either:", expected: true},
+		{text: "no!", phrase: "no!", expected: true},
+		{text: "", phrase: "anything", expected: false},
+		{text: "anything", phrase: "   ", expected: false},
+	}
+
+	for _, tt := range tests {
+		if result := containsPhrase(tt.text, tt.phrase); result != tt.expected {
+			t.Errorf("containsPhrase(%q, %q) = %v, want %v", tt.text, tt.phrase, result, tt.expected)
+		}
+	}
+}
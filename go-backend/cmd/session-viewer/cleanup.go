@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/claude"
+)
+
+// CleanupResponse lists the orphaned temp directories that were removed
+type CleanupResponse struct {
+	Removed     int      `json:"removed"`
+	Directories []string `json:"directories"`
+}
+
+// handleCleanup removes temp analysis directories left behind by killed runs
+func handleCleanup(cfg *config.Config) {
+	flags := parseFlags(os.Args[2:])
+
+	maxAge := orphanAge(cfg)
+	if value, ok := flags["older-than"]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			respondError(fmt.Sprintf("Invalid older-than: %s (expected a duration such as 1h)", value))
+			return
+		}
+		maxAge = d
+	}
+
+	removed, err := claude.CleanupOrphanedTempDirs(maxAge, newLogger(cfg))
+	if err != nil {
+		respondError(fmt.Sprintf("Error scanning temp directory: %v", err))
+		return
+	}
+	respondJSON(CleanupResponse{Removed: len(removed), Directories: removed})
+}
+
+// cleanupOrphansAtStartup removes orphaned temp directories before a command runs.
+// Failures are ignored; the cleanup command reports them.
+func cleanupOrphansAtStartup(cfg *config.Config) {
+	claude.CleanupOrphanedTempDirs(orphanAge(cfg), newLogger(cfg))
+}
+
+// orphanAge is how old a temp directory must be to be treated as orphaned. It is never
+// shorter than twice the CLI timeout, so directories of running calls are left alone.
+func orphanAge(cfg *config.Config) time.Duration {
+	return max(claude.DefaultOrphanAge, 2*cfg.Claude.Timeout)
+}
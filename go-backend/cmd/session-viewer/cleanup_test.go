@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCleanupCommand tests removing orphaned temp directories, both explicitly and
+// at the start of another command
+func TestCleanupCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+
+	orphan := filepath.Join(os.TempDir(), "claude-analysis-stale")
+	makeOrphan := func() {
+		if err := os.MkdirAll(orphan, 0755); err != nil {
+			t.Fatalf("Failed to create orphan: %v", err)
+		}
+		old := time.Now().Add(-3 * time.Hour)
+		os.Chtimes(orphan, old, old)
+	}
+
+	makeOrphan()
+	var response CleanupResponse
+	if err := json.Unmarshal([]byte(runMain(t, "cleanup", "--older-than", "2h")), &response); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if response.Removed != 1 || len(response.Directories) != 1 || response.Directories[0] != orphan {
+		t.Errorf("Expected %s removed, got %+v", orphan, response)
+	}
+
+	makeOrphan()
+	runMain(t, "version")
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("Expected startup cleanup to remove %s", orphan)
+	}
+
+	if output := runMain(t, "cleanup", "--older-than", "soon"); !strings.Contains(output, "Invalid older-than") {
+		t.Errorf("Expected invalid duration error, got %s", output)
+	}
+}
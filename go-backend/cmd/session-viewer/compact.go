@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// zeroTimeJSON is how encoding/json writes a zero time.Time
+const zeroTimeJSON = "0001-01-01T00:00:00Z"
+
+// orderedMember is one member of a JSON object, kept in its original position
+type orderedMember struct {
+	Key   string
+	Value interface{}
+}
+
+// orderedObject is a decoded JSON object that preserves member order
+type orderedObject []orderedMember
+
+// compactJSON rewrites encoded JSON without zero-valued object members: null, false,
+// 0, "", zero timestamps, and arrays or objects that are empty (after compaction).
+// This omits absent optional fields uniformly, whatever their omitempty tags say.
+// Array elements are compacted but never dropped, since their positions matter.
+func compactJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeOrdered(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCompact(&buf, compactValue(value)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes the next JSON value, keeping objects as orderedObject
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := orderedObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedMember{Key: key.(string), Value: value})
+		}
+		_, err := decoder.Token() // Closing brace
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token() // Closing bracket
+		return array, err
+	}
+	return token, nil
+}
+
+// compactValue drops zero-valued members from objects at every depth
+func compactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case orderedObject:
+		compacted := orderedObject{}
+		for _, member := range v {
+			child := compactValue(member.Value)
+			if !isZeroJSON(child) {
+				compacted = append(compacted, orderedMember{Key: member.Key, Value: child})
+			}
+		}
+		return compacted
+	case []interface{}:
+		compacted := make([]interface{}, len(v))
+		for i, item := range v {
+			compacted[i] = compactValue(item)
+		}
+		return compacted
+	}
+	return value
+}
+
+// isZeroJSON reports whether a decoded value is the zero value of its JSON type
+func isZeroJSON(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == "" || v == zeroTimeJSON
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case []interface{}:
+		return len(v) == 0
+	case orderedObject:
+		return len(v) == 0
+	}
+	return false
+}
+
+// writeCompact encodes a decoded value as single-line JSON
+func writeCompact(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case orderedObject:
+		buf.WriteByte('{')
+		for i, member := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(member.Key)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeCompact(buf, member.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCompact(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encoding %v: %w", v, err)
+		}
+		buf.Write(encoded)
+	}
+	return nil
+}
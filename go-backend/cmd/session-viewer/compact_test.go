@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// sparseAnalysis has zero-valued fields with and without omitempty tags
+var sparseAnalysis = &llm.Analysis{
+	Episodes: []*llm.Episode{
+		{ID: "ep1", Phase: "debugging", Confidence: 0.5, EndLine: 4},
+	},
+	Patterns:        &llm.WorkflowPatterns{Workflow: "linear"},
+	Recommendations: []string{},
+}
+
+// TestCompactJSON tests that zero-valued fields are dropped uniformly and order is kept
+func TestCompactJSON(t *testing.T) {
+	data, err := json.Marshal(sparseAnalysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compacted, err := compactJSON(data)
+	if err != nil {
+		t.Fatalf("compactJSON failed: %v", err)
+	}
+
+	want := `{"episodes":[{"id":"ep1","phase":"debugging","confidence":0.5,"end_line":4}],"patterns":{"workflow":"linear"}}`
+	if string(compacted) != want {
+		t.Errorf("compactJSON =\n%s\nwant\n%s", compacted, want)
+	}
+}
+
+// TestCompactJSONKeepsArrayElements tests that zero elements keep their array positions
+func TestCompactJSONKeepsArrayElements(t *testing.T) {
+	compacted, err := compactJSON([]byte(`{"values":[0,"",{"a":null},1],"n":1.50}`))
+	if err != nil {
+		t.Fatalf("compactJSON failed: %v", err)
+	}
+	if string(compacted) != `{"values":[0,"",{},1],"n":1.50}` {
+		t.Errorf("Unexpected output: %s", compacted)
+	}
+}
+
+// TestOutputCompact tests --output compact against the default output
+func TestOutputCompact(t *testing.T) {
+	file := writeFixture(t, "analysis.json", `{"episodes":[{"id":"ep1","phase":"testing","confidence":0.7,"start_line":1,"end_line":3}],"patterns":{},"metadata":{"timestamp":"2025-01-01T00:00:00Z"}}`)
+
+	defaultOutput := runMainWithOutput(t, "validate", "--input", file)
+	for _, field := range []string{`"description":""`, `"start_time":"0001-01-01T00:00:00Z"`, `"duration":""`} {
+		if !strings.Contains(defaultOutput, field) {
+			t.Errorf("Expected %s in default output: %s", field, defaultOutput)
+		}
+	}
+
+	compactOutput := runMainWithOutput(t, "validate", "--input", file, "--output", "compact")
+	for _, field := range []string{"description", "start_time", "duration", "sub_phase", "patterns"} {
+		if strings.Contains(compactOutput, `"`+field+`"`) {
+			t.Errorf("Expected %s to be omitted in compact output: %s", field, compactOutput)
+		}
+	}
+	if !strings.Contains(compactOutput, `"valid":true`) || !strings.Contains(compactOutput, `"confidence":0.7`) {
+		t.Errorf("Expected non-zero fields to be kept: %s", compactOutput)
+	}
+}
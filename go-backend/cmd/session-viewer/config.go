@@ -0,0 +1,28 @@
+package main
+
+import "github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+
+// ConfigResponse represents the config command output
+type ConfigResponse struct {
+	Settings map[string]ConfigSetting `json:"settings"`          // Keyed by config file name, e.g. "model"
+	Problem  string                   `json:"problem,omitempty"` // Why the configuration would fail validation
+}
+
+// ConfigSetting is one resolved setting and where its value came from
+type ConfigSetting struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"` // "env:<VAR>", "file:<path>", or "default"
+}
+
+// handleConfig prints the effective configuration with the source of each value, so
+// env var and config file precedence can be checked without running an analysis
+func handleConfig(cfg *config.Config) {
+	response := ConfigResponse{Settings: make(map[string]ConfigSetting)}
+	for name, value := range cfg.Settings() {
+		response.Settings[name] = ConfigSetting{Value: value, Source: cfg.Source[name]}
+	}
+	if err := cfg.Validate(); err != nil {
+		response.Problem = err.Error()
+	}
+	respondJSON(response)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestConfigCommand tests that resolved settings are shown with their sources
+func TestConfigCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SESSION_VIEWER_CONFIG", "")
+	t.Setenv("CLAUDE_MODEL", "test-model")
+	t.Setenv("CLAUDE_BINARY_PATH", "/nonexistent/claude")
+
+	var response ConfigResponse
+	output := runMain(t, "config")
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+
+	model := response.Settings["model"]
+	if model.Value != "test-model" || model.Source != "env:CLAUDE_MODEL" {
+		t.Errorf("Unexpected model setting: %+v", model)
+	}
+	if timeout := response.Settings["timeout"]; timeout.Value != "10m0s" || timeout.Source != "default" {
+		t.Errorf("Unexpected timeout setting: %+v", timeout)
+	}
+	if response.Problem == "" {
+		t.Error("Expected the missing binary to be reported")
+	}
+}
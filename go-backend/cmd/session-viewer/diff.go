@@ -0,0 +1,264 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// AnalysisDiff describes how a newer analysis of a session differs from an older one
+type AnalysisDiff struct {
+	Identical       bool               `json:"identical"`
+	Episodes        EpisodeDiff        `json:"episodes"`
+	Patterns        []FieldChange      `json:"patterns"`
+	Recommendations RecommendationDiff `json:"recommendations"`
+}
+
+// EpisodeDiff lists episodes only in the new analysis, only in the old one, and
+// matched episodes whose fields changed
+type EpisodeDiff struct {
+	Added     []*llm.Episode  `json:"added"`
+	Removed   []*llm.Episode  `json:"removed"`
+	Changed   []EpisodeChange `json:"changed"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// EpisodeChange is a matched pair of episodes and the fields that differ between them
+type EpisodeChange struct {
+	Old    *llm.Episode  `json:"old"`
+	New    *llm.Episode  `json:"new"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// FieldChange is one field whose value differs between the old and new analysis
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// RecommendationDiff lists recommendations only in the new or only in the old analysis
+type RecommendationDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// handleDiff compares two saved analyses of the same session
+func handleDiff() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer diff --old <analysis.json> --new <analysis.json>")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	if flags["old"] == "" || flags["new"] == "" {
+		respondError("Missing --old or --new path")
+		return
+	}
+
+	old, err := readAnalysisFile(flags["old"])
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	updated, err := readAnalysisFile(flags["new"])
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	respondJSON(diffAnalyses(old, updated))
+}
+
+// diffAnalyses compares episodes, patterns, and recommendations. Episodes are matched
+// when they share a phase and their line ranges overlap, pairing the largest overlaps
+// first; unmatched episodes are reported as added or removed.
+func diffAnalyses(old, updated *llm.Analysis) *AnalysisDiff {
+	diff := &AnalysisDiff{
+		Episodes: EpisodeDiff{
+			Added:   []*llm.Episode{},
+			Removed: []*llm.Episode{},
+			Changed: []EpisodeChange{},
+		},
+		Patterns:        diffPatterns(old.Patterns, updated.Patterns),
+		Recommendations: diffRecommendations(old.Recommendations, updated.Recommendations),
+	}
+
+	oldEpisodes, newEpisodes := nonNilEpisodes(old.Episodes), nonNilEpisodes(updated.Episodes)
+	matchedOld := make([]bool, len(oldEpisodes))
+	matchedNew := make([]bool, len(newEpisodes))
+	for _, pair := range matchEpisodes(oldEpisodes, newEpisodes) {
+		matchedOld[pair[0]], matchedNew[pair[1]] = true, true
+		oldEp, newEp := oldEpisodes[pair[0]], newEpisodes[pair[1]]
+		if fields := diffEpisodeFields(oldEp, newEp); len(fields) > 0 {
+			diff.Episodes.Changed = append(diff.Episodes.Changed, EpisodeChange{Old: oldEp, New: newEp, Fields: fields})
+		} else {
+			diff.Episodes.Unchanged++
+		}
+	}
+	for i, ep := range oldEpisodes {
+		if !matchedOld[i] {
+			diff.Episodes.Removed = append(diff.Episodes.Removed, ep)
+		}
+	}
+	for i, ep := range newEpisodes {
+		if !matchedNew[i] {
+			diff.Episodes.Added = append(diff.Episodes.Added, ep)
+		}
+	}
+	// Keep changes in the new analysis's order for display
+	sort.SliceStable(diff.Episodes.Changed, func(i, j int) bool {
+		return diff.Episodes.Changed[i].New.StartLine < diff.Episodes.Changed[j].New.StartLine
+	})
+
+	diff.Identical = len(diff.Episodes.Added) == 0 && len(diff.Episodes.Removed) == 0 &&
+		len(diff.Episodes.Changed) == 0 && len(diff.Patterns) == 0 &&
+		len(diff.Recommendations.Added) == 0 && len(diff.Recommendations.Removed) == 0
+	return diff
+}
+
+// nonNilEpisodes drops nil entries, which a model reply may contain
+func nonNilEpisodes(episodes []*llm.Episode) []*llm.Episode {
+	result := make([]*llm.Episode, 0, len(episodes))
+	for _, ep := range episodes {
+		if ep != nil {
+			result = append(result, ep)
+		}
+	}
+	return result
+}
+
+// matchEpisodes pairs old and new episodes of the same phase with overlapping line
+// ranges, returned as [old index, new index]. Each episode is matched at most once,
+// greedily by the number of shared lines.
+func matchEpisodes(oldEpisodes, newEpisodes []*llm.Episode) [][2]int {
+	type candidate struct {
+		oldIndex, newIndex, overlap int
+	}
+	var candidates []candidate
+	for i, oldEp := range oldEpisodes {
+		for j, newEp := range newEpisodes {
+			if oldEp.Phase != newEp.Phase {
+				continue
+			}
+			if overlap := lineOverlap(oldEp, newEp); overlap > 0 {
+				candidates = append(candidates, candidate{i, j, overlap})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].overlap > candidates[b].overlap
+	})
+
+	matchedOld := make(map[int]bool)
+	matchedNew := make(map[int]bool)
+	var pairs [][2]int
+	for _, c := range candidates {
+		if matchedOld[c.oldIndex] || matchedNew[c.newIndex] {
+			continue
+		}
+		matchedOld[c.oldIndex], matchedNew[c.newIndex] = true, true
+		pairs = append(pairs, [2]int{c.oldIndex, c.newIndex})
+	}
+	return pairs
+}
+
+// lineOverlap returns how many lines two episodes' inclusive ranges share
+func lineOverlap(a, b *llm.Episode) int {
+	start, end := a.StartLine, a.EndLine
+	if b.StartLine > start {
+		start = b.StartLine
+	}
+	if b.EndLine < end {
+		end = b.EndLine
+	}
+	if end < start {
+		return 0
+	}
+	return end - start + 1
+}
+
+// diffEpisodeFields lists the fields that differ between two matched episodes. Phase
+// is part of the match, and start and end times are derived from the session.
+func diffEpisodeFields(old, updated *llm.Episode) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, oldValue, newValue interface{}) {
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add("id", old.ID, updated.ID)
+	add("sub_phase", old.SubPhase, updated.SubPhase)
+	add("confidence", old.Confidence, updated.Confidence)
+	add("start_line", old.StartLine, updated.StartLine)
+	add("end_line", old.EndLine, updated.EndLine)
+	add("duration", old.Duration, updated.Duration)
+	add("description", old.Description, updated.Description)
+	add("resolution", old.Resolution, updated.Resolution)
+	add("key_insights", nonNilStrings(old.KeyInsights), nonNilStrings(updated.KeyInsights))
+	return changes
+}
+
+// diffPatterns lists the workflow pattern fields that differ; a missing patterns
+// object compares as empty
+func diffPatterns(old, updated *llm.WorkflowPatterns) []FieldChange {
+	if old == nil {
+		old = &llm.WorkflowPatterns{}
+	}
+	if updated == nil {
+		updated = &llm.WorkflowPatterns{}
+	}
+
+	changes := []FieldChange{}
+	for _, field := range []struct {
+		name     string
+		old, new string
+	}{
+		{"workflow", old.Workflow, updated.Workflow},
+		{"efficiency", old.Efficiency, updated.Efficiency},
+		{"frustration_level", old.FrustrationLevel, updated.FrustrationLevel},
+		{"learning_pattern", old.LearningPattern, updated.LearningPattern},
+		{"collaboration", old.Collaboration, updated.Collaboration},
+	} {
+		if field.old != field.new {
+			changes = append(changes, FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	return changes
+}
+
+// diffRecommendations compares recommendations as sets of trimmed text, keeping each
+// list's original order
+func diffRecommendations(old, updated []string) RecommendationDiff {
+	return RecommendationDiff{
+		Added:   missingStrings(updated, old),
+		Removed: missingStrings(old, updated),
+	}
+}
+
+// missingStrings returns the entries of from, trimmed, that don't appear in other
+func missingStrings(from, other []string) []string {
+	present := make(map[string]bool, len(other))
+	for _, s := range other {
+		present[strings.TrimSpace(s)] = true
+	}
+	missing := []string{}
+	for _, s := range from {
+		if s = strings.TrimSpace(s); s != "" && !present[s] {
+			missing = append(missing, s)
+			present[s] = true
+		}
+	}
+	return missing
+}
+
+// nonNilStrings returns s, or an empty slice in place of nil so the two compare equal
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
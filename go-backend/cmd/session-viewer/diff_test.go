@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestDiffAnalyses tests episode matching by phase and overlap, and the pattern and
+// recommendation deltas
+func TestDiffAnalyses(t *testing.T) {
+	old := &llm.Analysis{
+		Episodes: []*llm.Episode{
+			{ID: "ep1", Phase: "planning", Confidence: 0.8, StartLine: 1, EndLine: 10, Description: "Planned"},
+			{ID: "ep2", Phase: "debugging", Confidence: 0.6, StartLine: 11, EndLine: 30, Description: "Chased a bug"},
+			{ID: "ep3", Phase: "testing", Confidence: 0.9, StartLine: 31, EndLine: 40},
+		},
+		Patterns:        &llm.WorkflowPatterns{Workflow: "linear", Efficiency: "medium"},
+		Recommendations: []string{"Write tests first", "Use a debugger"},
+	}
+	updated := &llm.Analysis{
+		Episodes: []*llm.Episode{
+			{ID: "ep1", Phase: "planning", Confidence: 0.8, StartLine: 1, EndLine: 10, Description: "Planned"},
+			{ID: "ep2", Phase: "debugging", Confidence: 0.75, StartLine: 11, EndLine: 25, Description: "Chased a bug"},
+			{ID: "ep3", Phase: "implementation", Confidence: 0.7, StartLine: 26, EndLine: 40},
+		},
+		Patterns:        &llm.WorkflowPatterns{Workflow: "linear", Efficiency: "high"},
+		Recommendations: []string{" Write tests first ", "Commit smaller changes"},
+	}
+
+	diff := diffAnalyses(old, updated)
+
+	if diff.Identical || diff.Episodes.Unchanged != 1 {
+		t.Errorf("Expected 1 unchanged episode and a non-identical diff, got %+v", diff)
+	}
+	if len(diff.Episodes.Changed) != 1 {
+		t.Fatalf("Expected 1 changed episode, got %+v", diff.Episodes.Changed)
+	}
+	change := diff.Episodes.Changed[0]
+	if change.Old.ID != "ep2" || len(change.Fields) != 2 || change.Fields[0].Field != "confidence" || change.Fields[1].Field != "end_line" {
+		t.Errorf("Unexpected change: %+v", change)
+	}
+	// A phase change is not a match: the testing episode is gone and implementation is new
+	if len(diff.Episodes.Removed) != 1 || diff.Episodes.Removed[0].Phase != "testing" {
+		t.Errorf("Unexpected removed episodes: %+v", diff.Episodes.Removed)
+	}
+	if len(diff.Episodes.Added) != 1 || diff.Episodes.Added[0].Phase != "implementation" {
+		t.Errorf("Unexpected added episodes: %+v", diff.Episodes.Added)
+	}
+	if len(diff.Patterns) != 1 || diff.Patterns[0] != (FieldChange{Field: "efficiency", Old: "medium", New: "high"}) {
+		t.Errorf("Unexpected pattern changes: %+v", diff.Patterns)
+	}
+	if got := diff.Recommendations; len(got.Added) != 1 || got.Added[0] != "Commit smaller changes" ||
+		len(got.Removed) != 1 || got.Removed[0] != "Use a debugger" {
+		t.Errorf("Unexpected recommendation changes: %+v", got)
+	}
+}
+
+// TestDiffAnalysesMatchesLargestOverlap tests that an episode split in two is matched to
+// the part sharing the most lines
+func TestDiffAnalysesMatchesLargestOverlap(t *testing.T) {
+	old := &llm.Analysis{Episodes: []*llm.Episode{{Phase: "debugging", StartLine: 1, EndLine: 20}}}
+	updated := &llm.Analysis{Episodes: []*llm.Episode{
+		{Phase: "debugging", StartLine: 1, EndLine: 5},
+		{Phase: "debugging", StartLine: 6, EndLine: 20},
+	}}
+
+	diff := diffAnalyses(old, updated)
+	if len(diff.Episodes.Changed) != 1 || diff.Episodes.Changed[0].New.StartLine != 6 {
+		t.Errorf("Expected a match with lines 6-20, got %+v", diff.Episodes.Changed)
+	}
+	if len(diff.Episodes.Added) != 1 || diff.Episodes.Added[0].StartLine != 1 {
+		t.Errorf("Expected lines 1-5 to be added, got %+v", diff.Episodes.Added)
+	}
+}
+
+// TestDiffCommand tests the JSON output for identical inputs and argument errors
+func TestDiffCommand(t *testing.T) {
+	bare := writeFixture(t, "old.json", validAnalysisJSON)
+	wrapped := writeFixture(t, "new.json", `{"session_id":"s1","summary":"x","analysis":`+validAnalysisJSON+`}`)
+
+	output := runMain(t, "diff", "--old", bare, "--new", wrapped)
+	var diff map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if diff["identical"] != true {
+		t.Errorf("Expected identical analyses, got %s", output)
+	}
+	// Empty deltas are arrays, not null, so a UI can iterate them directly
+	if !strings.Contains(output, `"added":[]`) || strings.Contains(output, "null") {
+		t.Errorf("Expected empty arrays in output, got %s", output)
+	}
+
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Missing new", []string{"--old", bare}, "Missing --old or --new path"},
+		{"Unreadable file", []string{"--old", bare, "--new", bare + ".missing"}, "Error reading file"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runMain(t, append([]string{"diff"}, tt.args...)...)
+			if !strings.Contains(output, `"error"`) || !strings.Contains(output, tt.want) {
+				t.Errorf("Expected error %q, got: %s", tt.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// defaultDoctorTimeout bounds the doctor command's test prompt
+const defaultDoctorTimeout = 30 * time.Second
+
+// doctorPrompt is the trivial prompt sent to confirm the model answers at all
+const doctorPrompt = "Reply with OK and nothing else."
+
+// maxDoctorReply is how many characters of the test prompt's reply the report quotes
+const maxDoctorReply = 80
+
+// Outcomes of a doctor check
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip" // Not run, because an earlier check it depends on failed
+)
+
+// DoctorResponse represents doctor output: every check in the order it ran
+type DoctorResponse struct {
+	OK     bool          `json:"ok"` // Every check passed
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// DoctorCheck is the outcome of one check
+type DoctorCheck struct {
+	Name      string  `json:"name"` // binary or api_key, analysis_dir, prompt
+	Status    string  `json:"status"`
+	Detail    string  `json:"detail,omitempty"`
+	ErrorKind string  `json:"error_kind,omitempty"` // Why the prompt failed, as in analyze's error_kind
+	Seconds   float64 `json:"seconds,omitempty"`    // How long the prompt took
+}
+
+// handleDoctor checks that analysis can work end to end: the backend is configured,
+// the analysis directory is writable, and a trivial prompt gets a non-empty reply
+func handleDoctor(cfg *config.Config) {
+	flags := parseFlags(os.Args[2:])
+
+	timeout := defaultDoctorTimeout
+	if value, ok := flags["timeout"]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			respondError(fmt.Sprintf("Invalid timeout: %s (expected a duration such as 30s)", value))
+			return
+		}
+		timeout = d
+	}
+
+	checks := []DoctorCheck{checkBackend(cfg), checkAnalysisDir(cfg.Paths.AnalysisDir)}
+	if checks[0].Status == checkFail || checks[1].Status == checkFail {
+		checks = append(checks, DoctorCheck{Name: "prompt", Status: checkSkip, Detail: "Fix the failed checks first"})
+	} else {
+		checks = append(checks, checkPrompt(cfg, timeout))
+	}
+
+	response := DoctorResponse{OK: true, Checks: checks}
+	for _, check := range checks {
+		if check.Status != checkPass {
+			response.OK = false
+		}
+	}
+	respondJSON(response)
+}
+
+// checkBackend verifies the claude binary for the CLI backend, or the API key for the
+// anthropic backend
+func checkBackend(cfg *config.Config) DoctorCheck {
+	check := DoctorCheck{Name: "binary", Status: checkPass, Detail: cfg.Claude.BinaryPath}
+	if !cfg.Claude.UsesCLI() {
+		check = DoctorCheck{Name: "api_key", Status: checkPass, Detail: config.AnthropicAPIKeyEnv + " is set"}
+	}
+	if err := cfg.Validate(); err != nil {
+		check.Status, check.Detail = checkFail, err.Error()
+	}
+	return check
+}
+
+// checkAnalysisDir verifies that files can be created in the analysis directory,
+// creating it if needed
+func checkAnalysisDir(dir string) DoctorCheck {
+	check := DoctorCheck{Name: "analysis_dir", Status: checkFail}
+	if !filepath.IsAbs(dir) {
+		check.Detail = fmt.Sprintf("%s is not an absolute path; check ANALYSIS_DIR", dir)
+		return check
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		check.Detail = fmt.Sprintf("Cannot create %s: %v", dir, err)
+		return check
+	}
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+		return check
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	check.Status, check.Detail = checkPass, dir
+	return check
+}
+
+// checkPrompt sends doctorPrompt and expects a non-empty reply within timeout. Transport
+// retries are not used, so a failure is reported as soon as it happens.
+func checkPrompt(cfg *config.Config, timeout time.Duration) DoctorCheck {
+	check := DoctorCheck{Name: "prompt", Status: checkFail}
+
+	// A copy, so the configured timeout is only shortened for this check
+	probeCfg := *cfg
+	probeCfg.Claude.Timeout = timeout
+	analyzer, err := newAnalyzer(&probeCfg, "", validator.Options{})
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	reply, err := analyzer.SendConversationalPrompt(ctx, doctorPrompt, "")
+	check.Seconds = time.Since(start).Seconds()
+	if err == nil && strings.TrimSpace(reply) == "" {
+		err = llm.ErrEmptyResponse
+	}
+	if err != nil {
+		check.Detail, check.ErrorKind = err.Error(), classifyError(err)
+		return check
+	}
+
+	reply = strings.TrimSpace(reply)
+	if runes := []rune(reply); len(runes) > maxDoctorReply {
+		reply = string(runes[:maxDoctorReply]) + "..."
+	}
+	check.Status, check.Detail = checkPass, fmt.Sprintf("Replied %q", reply)
+	return check
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runDoctor runs the doctor command with args and decodes its output
+func runDoctor(t *testing.T, args ...string) DoctorResponse {
+	t.Helper()
+	var response DoctorResponse
+	output := runMain(t, append([]string{"doctor"}, args...)...)
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	return response
+}
+
+// checkStatuses lists each check as name=status
+func checkStatuses(response DoctorResponse) string {
+	var statuses []string
+	for _, check := range response.Checks {
+		statuses = append(statuses, check.Name+"="+check.Status)
+	}
+	return strings.Join(statuses, " ")
+}
+
+// TestDoctor tests the pass/fail report of each check
+func TestDoctor(t *testing.T) {
+	t.Run("Healthy", func(t *testing.T) {
+		promptFile := installFakeClaude(t, "OK")
+		response := runDoctor(t)
+		if !response.OK || checkStatuses(response) != "binary=pass analysis_dir=pass prompt=pass" {
+			t.Errorf("Unexpected report: %+v", response)
+		}
+		if prompts := readPrompts(t, promptFile); len(prompts) != 1 || prompts[0] != doctorPrompt {
+			t.Errorf("Expected one test prompt, got %q", prompts)
+		}
+	})
+
+	t.Run("Empty reply", func(t *testing.T) {
+		installFakeClaude(t, "")
+		response := runDoctor(t, "--timeout", "10s")
+		prompt := response.Checks[2]
+		if response.OK || prompt.Status != checkFail || prompt.ErrorKind != errorKindEmptyResponse {
+			t.Errorf("Expected an empty_response failure, got %+v", response)
+		}
+	})
+
+	t.Run("Missing binary", func(t *testing.T) {
+		t.Setenv("CLAUDE_BINARY_PATH", "/nonexistent/claude")
+		t.Setenv("ANALYSIS_DIR", t.TempDir())
+		response := runDoctor(t)
+		if response.OK || checkStatuses(response) != "binary=fail analysis_dir=pass prompt=skip" {
+			t.Errorf("Unexpected report: %+v", response)
+		}
+	})
+
+	t.Run("Analysis dir not writable", func(t *testing.T) {
+		installFakeClaude(t, "OK")
+		file := writeFixture(t, "not-a-dir", "")
+		t.Setenv("ANALYSIS_DIR", filepath.Join(file, "analysis"))
+		response := runDoctor(t)
+		if response.OK || checkStatuses(response) != "binary=pass analysis_dir=fail prompt=skip" {
+			t.Errorf("Unexpected report: %+v", response)
+		}
+	})
+
+	if output := runMain(t, "doctor", "--timeout", "soon"); !strings.Contains(output, "Invalid timeout") {
+		t.Errorf("Expected invalid timeout error, got %s", output)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/language"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/prompts"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/window"
+)
+
+// DryRunResponse represents analyze --dry-run output: the prompts analyze would send
+type DryRunResponse struct {
+	SessionID  string           `json:"session_id"`
+	Model      string           `json:"model"`
+	Prompts    []DryRunPrompt   `json:"prompts"`
+	Warnings   []string         `json:"warnings,omitempty"`   // Including why the real run would be refused
+	Redactions *RedactionCounts `json:"redactions,omitempty"` // Secrets masked before sending, with --redact
+}
+
+// DryRunPrompt is one prompt analyze would send
+type DryRunPrompt struct {
+	Kind            string `json:"kind"`              // "summary", "structured", or "window"
+	Attempt         int    `json:"attempt,omitempty"` // Summary attempt it is first sent on; later retries repeat the last one
+	Window          int    `json:"window,omitempty"`  // 1-based window of a windowed structured analysis
+	Template        string `json:"template,omitempty"`
+	Prompt          string `json:"prompt"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+}
+
+// dryRun builds the prompts analyze would send for content without calling the model:
+// the first summary prompt, the retry prompt when retries are allowed, and with --both
+// the structured prompt or one prompt per window. Validation-driven corrective prompts
+// depend on the model's reply and aren't included.
+func (r *analyzeRun) dryRun(sessionID, content string) (DryRunResponse, error) {
+	response := DryRunResponse{SessionID: sessionID, Model: r.opts.model}
+	if r.redactor != nil {
+		content, response.Redactions = r.redactor.Redact(content)
+	}
+
+	var truncation *llm.TruncationInfo
+	if r.opts.truncate != "" {
+		content, truncation = truncateToTokens(content, r.cfg.Claude.MaxInputTokens, r.opts.truncate)
+	}
+
+	tokenCount := llm.EstimateTokens(content)
+	response.Warnings = preflightWarnings(content)
+	if truncation != nil {
+		response.Warnings = append(response.Warnings, truncationWarning(truncation, r.cfg.Claude.MaxInputTokens))
+	}
+	if err := checkInputBudget(tokenCount, r.cfg.Claude.MaxInputTokens); err != nil {
+		response.Warnings = append(response.Warnings, err.Error())
+	}
+
+	outputLanguage := r.opts.outputLanguage
+	if outputLanguage == "auto" {
+		outputLanguage = language.DetectLanguage(content)
+	}
+
+	add := func(prompt DryRunPrompt) {
+		prompt.EstimatedTokens = llm.EstimateTokens(prompt.Prompt)
+		response.Prompts = append(response.Prompts, prompt)
+	}
+	for attempt := 1; attempt <= min(r.opts.maxRetries, 2); attempt++ {
+		prompt, err := summaryPrompt(r.opts.templates, attempt, content, outputLanguage)
+		if err != nil {
+			return response, err
+		}
+		add(DryRunPrompt{Kind: "summary", Attempt: attempt, Template: string(prompts.SummaryTemplate(attempt)), Prompt: prompt})
+	}
+
+	if r.opts.both {
+		if r.opts.structuredTier(tokenCount) == 1 {
+			prompt, err := structuredPrompt(r.opts.templates, content)
+			if err != nil {
+				return response, err
+			}
+			add(DryRunPrompt{Kind: "structured", Template: string(llm.PromptTier1Direct), Prompt: prompt})
+		} else {
+			windows, _ := analysisWindows(content)
+			for _, w := range windows {
+				prompt, err := window.BuildWindowPrompt(r.opts.templates, w, len(windows))
+				if err != nil {
+					return response, err
+				}
+				add(DryRunPrompt{Kind: "window", Window: w.Index + 1, Template: string(llm.PromptTier2Window), Prompt: prompt})
+			}
+		}
+	}
+	return response, nil
+}
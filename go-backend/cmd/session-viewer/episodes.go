@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// episodeSortKeys are the accepted values of --sort-episodes
+var episodeSortKeys = map[string]bool{
+	"line":       true,
+	"confidence": true,
+	"time":       true,
+}
+
+// validateEpisodeSort checks a --sort-episodes value; empty means keep model order
+func validateEpisodeSort(by string) error {
+	if by == "" || episodeSortKeys[by] {
+		return nil
+	}
+	return fmt.Errorf("Invalid sort-episodes: %s (expected line, confidence, or time)", by)
+}
+
+// sortEpisodes orders episodes in place. Sorting is stable, and ties fall back to
+// StartLine then EndLine (earliest first) before the model's original order:
+//   - line: StartLine ascending
+//   - confidence: Confidence descending
+//   - time: StartTime ascending, episodes without a start time last
+func sortEpisodes(episodes []*llm.Episode, by string) {
+	var primary func(a, b *llm.Episode) (less, equal bool)
+	switch by {
+	case "line":
+		// The StartLine/EndLine tie-breakers below are the whole ordering
+		primary = func(a, b *llm.Episode) (bool, bool) {
+			return false, true
+		}
+	case "confidence":
+		primary = func(a, b *llm.Episode) (bool, bool) {
+			return a.Confidence > b.Confidence, a.Confidence == b.Confidence
+		}
+	case "time":
+		primary = func(a, b *llm.Episode) (bool, bool) {
+			if a.StartTime.IsZero() || b.StartTime.IsZero() {
+				return !a.StartTime.IsZero(), a.StartTime.IsZero() == b.StartTime.IsZero()
+			}
+			return a.StartTime.Before(b.StartTime), a.StartTime.Equal(b.StartTime)
+		}
+	default:
+		return
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		a, b := episodes[i], episodes[j]
+		if less, equal := primary(a, b); !equal {
+			return less
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.EndLine < b.EndLine
+	})
+}
+
+// dropLowConfidence removes episodes whose confidence is below minConfidence and
+// records how many were removed in the analysis metadata under
+// llm.InfoLowConfidenceDropped
+func dropLowConfidence(analysis *llm.Analysis, minConfidence float64) {
+	kept := make([]*llm.Episode, 0, len(analysis.Episodes))
+	dropped := 0
+	for _, ep := range analysis.Episodes {
+		if ep != nil && ep.Confidence < minConfidence {
+			dropped++
+			continue
+		}
+		kept = append(kept, ep)
+	}
+	analysis.Metadata.SetInfo(llm.InfoLowConfidenceDropped, dropped)
+	analysis.Episodes = kept
+}
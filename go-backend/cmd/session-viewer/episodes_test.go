@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// episodeFixture returns episodes with varied lines, confidences, and times
+func episodeFixture() []*llm.Episode {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	return []*llm.Episode{
+		{ID: "a", StartLine: 30, EndLine: 40, Confidence: 0.7, StartTime: base.Add(20 * time.Minute)},
+		{ID: "b", StartLine: 1, EndLine: 10, Confidence: 0.9},
+		{ID: "c", StartLine: 10, EndLine: 30, Confidence: 0.7, StartTime: base},
+		{ID: "d", StartLine: 10, EndLine: 20, Confidence: 0.95, StartTime: base.Add(20 * time.Minute)},
+	}
+}
+
+func episodeIDs(episodes []*llm.Episode) []string {
+	ids := make([]string, len(episodes))
+	for i, ep := range episodes {
+		ids[i] = ep.ID
+	}
+	return ids
+}
+
+// TestSortEpisodes tests each ordering and its tie-breaking
+func TestSortEpisodes(t *testing.T) {
+	tests := []struct {
+		by       string
+		expected []string
+	}{
+		// d and c share StartLine 10; d ends first
+		{by: "line", expected: []string{"b", "d", "c", "a"}},
+		// a and c tie on confidence; c starts earlier
+		{by: "confidence", expected: []string{"d", "b", "c", "a"}},
+		// a and d tie on time; d starts earlier. b has no time and goes last
+		{by: "time", expected: []string{"c", "d", "a", "b"}},
+		{by: "", expected: []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			episodes := episodeFixture()
+			sortEpisodes(episodes, tt.by)
+			got := episodeIDs(episodes)
+			for i := range tt.expected {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("sortEpisodes(%q) = %v, want %v", tt.by, got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateEpisodeSort tests accepted --sort-episodes values
+func TestValidateEpisodeSort(t *testing.T) {
+	for _, by := range []string{"", "line", "confidence", "time"} {
+		if err := validateEpisodeSort(by); err != nil {
+			t.Errorf("validateEpisodeSort(%q) failed: %v", by, err)
+		}
+	}
+	if err := validateEpisodeSort("phase"); err == nil {
+		t.Error("Expected error for unknown sort key")
+	}
+}
+
+// TestDropLowConfidence tests trimming below the threshold and the recorded count
+func TestDropLowConfidence(t *testing.T) {
+	analysis := &llm.Analysis{Episodes: []*llm.Episode{
+		{ID: "ep1", Confidence: 0.4},
+		{ID: "ep2", Confidence: 0.5},
+		{ID: "ep3", Confidence: 0.9},
+		{ID: "ep4", Confidence: 0.1},
+	}}
+
+	dropLowConfidence(analysis, 0.5)
+
+	if len(analysis.Episodes) != 2 || analysis.Episodes[0].ID != "ep2" || analysis.Episodes[1].ID != "ep3" {
+		t.Errorf("Expected ep2 and ep3 to remain, got %+v", analysis.Episodes)
+	}
+	if dropped := analysis.Metadata.HierarchicalInfo[llm.InfoLowConfidenceDropped]; dropped != 2 {
+		t.Errorf("Expected 2 dropped episodes recorded, got %v", dropped)
+	}
+}
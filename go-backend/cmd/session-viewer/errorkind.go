@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"net/url"
+	"os/exec"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/anthropic"
+)
+
+// Machine-readable kinds of analysis failure, reported as error_kind. Timeouts, empty
+// responses, network and API errors, and failed CLI runs are transient: the session can
+// be re-queued. A missing binary, an unusable analysis or temp directory, oversized
+// content, and conversational replies on every attempt won't change on their own, so
+// retrying as-is is pointless.
+const (
+	errorKindTimeout        = "timeout"
+	errorKindBinaryMissing  = "binary_missing"
+	errorKindSetup          = "setup" // A local file or directory couldn't be created or read
+	errorKindEmptyResponse  = "empty_response"
+	errorKindConversational = "conversational" // Retries exhausted, every reply conversational
+	errorKindNetwork        = "network"
+	errorKindAPI            = "api_error"
+	errorKindCommandFailed  = "command_failed" // The CLI exited non-zero
+	errorKindTooLarge       = "too_large"
+	errorKindCanceled       = "canceled"
+	errorKindUnknown        = "unknown"
+)
+
+// errConversationalExhausted is wrapped by requestSummary's error when --reject-on-exhaust
+// is set and every attempt was rejected as conversational
+var errConversationalExhausted = errors.New("attempts produced conversational responses")
+
+// classifyError returns the error_kind of an analysis failure, or "" for a nil error
+func classifyError(err error) string {
+	var apiErr *anthropic.APIError
+	var urlErr *url.Error
+	var netErr net.Error
+	var exitErr *exec.ExitError
+	var execErr *exec.Error
+	var pathErr *fs.PathError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, errConversationalExhausted):
+		return errorKindConversational
+	case errors.Is(err, llm.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return errorKindTimeout
+	case errors.Is(err, context.Canceled):
+		return errorKindCanceled
+	case errors.Is(err, llm.ErrEmptyResponse):
+		return errorKindEmptyResponse
+	// Starting the binary fails with an *exec.Error when it isn't found in PATH, and with
+	// a "fork/exec" *fs.PathError when an explicit path is missing or not executable
+	case errors.Is(err, config.ErrBinaryUnavailable), errors.Is(err, exec.ErrNotFound), errors.As(err, &execErr),
+		errors.As(err, &pathErr) && pathErr.Op == "fork/exec":
+		return errorKindBinaryMissing
+	case errors.As(err, &pathErr), errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		return errorKindSetup
+	case errors.As(err, &apiErr):
+		return errorKindAPI
+	case errors.As(err, &urlErr), errors.As(err, &netErr):
+		return errorKindNetwork
+	case errors.As(err, &exitErr):
+		return errorKindCommandFailed
+	}
+	return errorKindUnknown
+}
+
+// respondSetupError reports an analyze setup failure, with an error_kind when the
+// backend itself is unusable so callers can tell it from a bad flag
+func respondSetupError(err error) {
+	if !errors.Is(err, config.ErrBinaryUnavailable) {
+		respondError(err.Error())
+		return
+	}
+	respondJSON(map[string]interface{}{
+		"error":      err.Error(),
+		"error_kind": errorKindBinaryMissing,
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/anthropic"
+)
+
+// TestClassifyError tests the error_kind of each kind of analysis failure
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind string
+	}{
+		{"No error", nil, ""},
+		{"CLI timeout", fmt.Errorf("%w after 1s", llm.ErrTimeout), errorKindTimeout},
+		{"Deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), errorKindTimeout},
+		{"Canceled", context.Canceled, errorKindCanceled},
+		{"Empty response", llm.ErrEmptyResponse, errorKindEmptyResponse},
+		{"Binary missing", fmt.Errorf("run: %w", exec.ErrNotFound), errorKindBinaryMissing},
+		{"Binary path missing", fmt.Errorf("claude command failed: %w", &fs.PathError{Op: "fork/exec", Path: "/opt/claude", Err: syscall.ENOENT}), errorKindBinaryMissing},
+		{"Binary not executable", fmt.Errorf("claude command failed: %w", &fs.PathError{Op: "fork/exec", Path: "/opt/claude", Err: syscall.EACCES}), errorKindBinaryMissing},
+		{"Analysis dir permission", fmt.Errorf("failed to create analysis directory /srv/a: %w", &fs.PathError{Op: "mkdir", Path: "/srv/a", Err: syscall.EACCES}), errorKindSetup},
+		{"Temp dir missing", fmt.Errorf("failed to create temp analysis directory: %w", fs.ErrNotExist), errorKindSetup},
+		{"Conversational", fmt.Errorf("all 3 %w", errConversationalExhausted), errorKindConversational},
+		{"API error", &anthropic.APIError{StatusCode: 429}, errorKindAPI},
+		{"Network", &url.Error{Op: "Post", URL: "https://api.example", Err: errors.New("connection refused")}, errorKindNetwork},
+		{"Other", errors.New("something else"), errorKindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.kind {
+				t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.kind)
+			}
+		})
+	}
+}
+
+// TestAnalyzeErrorKind tests that analyze reports why it failed
+func TestAnalyzeErrorKind(t *testing.T) {
+	analyze := func(t *testing.T, args ...string) map[string]interface{} {
+		t.Helper()
+		var response map[string]interface{}
+		output := runMain(t, append([]string{"analyze", "--session-id", "s1", "--content", "user: hi"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v: %s", err, output)
+		}
+		return response
+	}
+
+	t.Run("Empty response", func(t *testing.T) {
+		installFakeClaude(t, "")
+		t.Setenv("CLAUDE_RETRY_BASE_DELAY", "1ms")
+		if response := analyze(t); response["error_kind"] != errorKindEmptyResponse {
+			t.Errorf("Expected %q, got %v", errorKindEmptyResponse, response)
+		}
+	})
+
+	t.Run("Conversational", func(t *testing.T) {
+		installFakeClaude(t, "Short text")
+		if response := analyze(t, "--reject-on-exhaust"); response["error_kind"] != errorKindConversational {
+			t.Errorf("Expected %q, got %v", errorKindConversational, response)
+		}
+	})
+
+	t.Run("Binary missing", func(t *testing.T) {
+		t.Setenv("CLAUDE_BINARY_PATH", "/nonexistent/claude")
+		if response := analyze(t); response["error_kind"] != errorKindBinaryMissing {
+			t.Errorf("Expected %q, got %v", errorKindBinaryMissing, response)
+		}
+	})
+
+	t.Run("Analysis dir unusable", func(t *testing.T) {
+		installFakeClaude(t, validSummary)
+		t.Setenv("ANALYSIS_DIR", filepath.Join(writeFixture(t, "not-a-dir", ""), "analysis"))
+		if response := analyze(t); response["error_kind"] != errorKindSetup {
+			t.Errorf("Expected %q, got %v", errorKindSetup, response)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		installFakeClaude(t, validSummary)
+		if response := analyze(t); response["error_kind"] != nil {
+			t.Errorf("Expected no error_kind, got %v", response["error_kind"])
+		}
+	})
+}
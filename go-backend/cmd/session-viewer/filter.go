@@ -0,0 +1,614 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultMessageLimit is the number of most recent messages kept by the filter command
+const defaultMessageLimit = 20
+
+// maxSnippetLength bounds the offending text included in a LineError
+const maxSnippetLength = 80
+
+// contextCheckInterval is how many lines are read between cancellation checks
+const contextCheckInterval = 1000
+
+// FilterOptions controls which JSONL entries filterJSONLFile extracts
+type FilterOptions struct {
+	IncludeTools  bool // Emit tool_use and tool_result entries
+	IncludeSystem bool // Emit system entries, such as the system prompt and session metadata
+	Strict        bool // Fail on the first malformed line instead of skipping it
+	StartLine     int  // First source line to include (0 = from the beginning)
+	EndLine       int  // Last source line to include (0 = through the end)
+	SortByTime    bool // Order messages by timestamp before the limit is applied
+
+	// Role keeps only "user" or "assistant" entries ("" = both). Tool results and tool
+	// uses belong to user and assistant entries respectively, so with IncludeTools they
+	// are kept along with their role; system entries are dropped.
+	Role string
+
+	TimestampLayout string // Go reference layout of timestamp fields ("" = try common layouts)
+
+	// Since and Until keep messages timestamped within that inclusive range (zero = open).
+	// Messages whose timestamp is missing or doesn't parse are kept unless
+	// RequireTimestamp is set.
+	Since            time.Time
+	Until            time.Time
+	RequireTimestamp bool
+
+	// Anonymizer, when set, replaces identifying values in the content of every
+	// extracted message, giving each value one pseudonym across the whole input
+	Anonymizer *Anonymizer
+}
+
+// inLineRange reports whether a source line falls within the configured range
+func (o FilterOptions) inLineRange(lineNum int) bool {
+	if o.StartLine > 0 && lineNum < o.StartLine {
+		return false
+	}
+	if o.EndLine > 0 && lineNum > o.EndLine {
+		return false
+	}
+	return true
+}
+
+// inTimeRange reports whether a message timestamp falls within the configured range
+func (o FilterOptions) inTimeRange(timestamp string) bool {
+	if o.Since.IsZero() && o.Until.IsZero() && !o.RequireTimestamp {
+		return true
+	}
+	at, err := parseTimestamp(timestamp, o.TimestampLayout)
+	if timestamp == "" || err != nil {
+		return !o.RequireTimestamp
+	}
+	if !o.Since.IsZero() && at.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && at.After(o.Until) {
+		return false
+	}
+	return true
+}
+
+// FilterResult holds the filtered messages and how many input lines were dropped
+type FilterResult struct {
+	Messages     []FilteredMessage `json:"messages"`
+	SkippedLines int               `json:"skipped_lines"`
+
+	// Returned messages whose timestamp didn't match TimestampLayout; only checked when
+	// a layout is given. The messages are kept with their original timestamp.
+	TimestampErrors int `json:"timestamp_errors,omitempty"`
+
+	// Messages placed last by SortByTime because their timestamp was missing or didn't parse
+	UntimedMessages int `json:"untimed_messages,omitempty"`
+
+	// What --anonymize replaced in the extracted messages, including ones the limit dropped
+	Anonymized *AnonymizationSummary `json:"anonymized,omitempty"`
+}
+
+// LineError describes a JSONL line that could not be decoded
+type LineError struct {
+	Line    int    // 1-based line number in the source file
+	Snippet string // Leading portion of the offending line
+	Err     error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("malformed JSON on line %d: %v (near %q)", e.Line, e.Err, e.Snippet)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// filterJSONLFile reads a JSONL file and extracts only user/assistant messages.
+// Only the last limit messages are returned; a limit of 0 returns all of them.
+// Reading stops with ctx.Err() soon after ctx is canceled.
+func filterJSONLFile(ctx context.Context, filePath string, limit int, opts FilterOptions) (*FilterResult, error) {
+	file, err := openSession(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return filterJSONL(ctx, file, limit, opts)
+}
+
+// sessionFile is an open session file, read through a decompressor when gzipped
+type sessionFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (f *sessionFile) Close() error {
+	return f.file.Close()
+}
+
+// openSession opens a session file for reading. Files with a .gz extension or the
+// gzip magic header are decompressed as they are read, so archived sessions stream
+// like plain ones.
+func openSession(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := decompressSession(file, filepath.Ext(filePath) == ".gz")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return &sessionFile{Reader: r, file: file}, nil
+}
+
+// decompressSession returns r, wrapped in a gzip reader when gzipped is set or r starts
+// with the gzip magic header
+func decompressSession(r io.Reader, gzipped bool) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gzipped = true
+	}
+	if !gzipped {
+		return buffered, nil
+	}
+	return gzip.NewReader(buffered)
+}
+
+// filterJSONL is filterJSONLFile for JSONL read from r, such as an uploaded session
+func filterJSONL(ctx context.Context, r io.Reader, limit int, opts FilterOptions) (*FilterResult, error) {
+	result := &FilterResult{Messages: []FilteredMessage{}}
+	var err error
+	result.SkippedLines, err = readJSONLEntries(ctx, r, opts.Strict, func(lineNum int, line map[string]interface{}) error {
+		result.Messages = append(result.Messages, extractMessages(lineNum, line, opts)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SortByTime {
+		result.UntimedMessages = sortMessagesByTime(result.Messages, opts.TimestampLayout)
+	}
+	if opts.Anonymizer != nil {
+		result.Anonymized = opts.Anonymizer.Summary()
+	}
+
+	// Return only the last N messages (most recent)
+	if limit > 0 && len(result.Messages) > limit {
+		result.Messages = result.Messages[len(result.Messages)-limit:]
+	}
+
+	if opts.TimestampLayout != "" {
+		for _, msg := range result.Messages {
+			if _, err := parseTimestamp(msg.Timestamp, opts.TimestampLayout); msg.Timestamp != "" && err != nil {
+				result.TimestampErrors++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// filterJSONLStream decodes JSONL from r line by line and writes each filtered message
+// to w as NDJSON. With limit 0 messages are written as soon as they are decoded; otherwise
+// a ring buffer of size limit holds the most recent messages until the input is exhausted,
+// so memory stays bounded regardless of input size. Returns the number of skipped lines.
+// Reading stops with ctx.Err() soon after ctx is canceled.
+func filterJSONLStream(ctx context.Context, r io.Reader, w io.Writer, limit int, opts FilterOptions) (int, error) {
+	encoder := json.NewEncoder(w)
+
+	if limit <= 0 {
+		return readJSONLEntries(ctx, r, opts.Strict, func(lineNum int, line map[string]interface{}) error {
+			for _, msg := range extractMessages(lineNum, line, opts) {
+				if err := encoder.Encode(msg); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	ring := make([]FilteredMessage, 0, limit)
+	next := 0 // Index of the oldest message once the ring is full
+	skipped, err := readJSONLEntries(ctx, r, opts.Strict, func(lineNum int, line map[string]interface{}) error {
+		for _, msg := range extractMessages(lineNum, line, opts) {
+			if len(ring) < limit {
+				ring = append(ring, msg)
+				continue
+			}
+			ring[next] = msg
+			next = (next + 1) % limit
+		}
+		return nil
+	})
+	if err != nil {
+		return skipped, err
+	}
+
+	for _, msg := range append(ring[next:], ring[:next]...) {
+		if err := encoder.Encode(msg); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// readJSONLEntries reads r one line at a time and calls fn with each decoded JSON object
+// and its 1-based line number. Blank lines are ignored. Invalid lines are counted and
+// skipped, or returned as a *LineError when strict is set. Iteration stops at the first
+// error returned by fn.
+//
+// Some export tools write the session as one JSON document instead: an array of
+// entries, or an object holding them in a "messages" array. This is detected from the
+// first non-blank line, and the document's entries are numbered from 1 in order.
+//
+// ctx is checked every contextCheckInterval lines or entries, and its error returned
+// once it is canceled.
+func readJSONLEntries(ctx context.Context, r io.Reader, strict bool, fn func(lineNum int, line map[string]interface{}) error) (int, error) {
+	skipped := 0
+	err := scanJSONLEntries(ctx, r, fn, func(lineErr *LineError) error {
+		if strict {
+			return lineErr
+		}
+		skipped++
+		return nil
+	})
+	return skipped, err
+}
+
+// scanJSONLEntries is readJSONLEntries with each invalid line or entry passed to
+// invalid, which stops iteration by returning an error
+func scanJSONLEntries(ctx context.Context, r io.Reader, fn func(lineNum int, line map[string]interface{}) error, invalid func(*LineError) error) error {
+	reader := bufio.NewReader(r)
+	first := true
+	for lineNum := 1; ; lineNum++ {
+		if lineNum%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		raw, readErr := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 {
+			if first {
+				first = false
+				entries, isDocument, rest := jsonDocumentEntries(trimmed, reader)
+				if isDocument {
+					return readDocumentEntries(ctx, entries, fn, invalid)
+				}
+				reader = bufio.NewReader(rest)
+			}
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(trimmed, &line); err != nil {
+				if err := invalid(&LineError{Line: lineNum, Snippet: snippet(trimmed), Err: err}); err != nil {
+					return err
+				}
+			} else if err := fn(lineNum, line); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// jsonDocumentEntries reports whether input whose first non-blank line is firstLine,
+// followed by rest, is a single JSON document of entries rather than JSONL, and returns
+// its entries if so. Otherwise it returns a reader for the input after firstLine; a
+// first line that is not valid JSON on its own may have caused rest to be read ahead.
+func jsonDocumentEntries(firstLine []byte, rest io.Reader) ([]json.RawMessage, bool, io.Reader) {
+	if firstLine[0] != '[' && firstLine[0] != '{' {
+		return nil, false, rest
+	}
+
+	doc := json.RawMessage(firstLine)
+	if !json.Valid(firstLine) {
+		// Possibly a pretty-printed document; keep what is read so JSONL can be replayed
+		var readAhead bytes.Buffer
+		decoder := json.NewDecoder(io.MultiReader(bytes.NewReader(firstLine), bytes.NewReader([]byte("\n")), io.TeeReader(rest, &readAhead)))
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, false, io.MultiReader(&readAhead, rest)
+		}
+		rest = io.MultiReader(&readAhead, rest)
+	}
+
+	if doc[0] == '[' {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(doc, &entries); err == nil {
+			return entries, true, nil
+		}
+		return nil, false, rest
+	}
+
+	var wrapper struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(doc, &wrapper); err == nil && wrapper.Messages != nil {
+		return wrapper.Messages, true, nil
+	}
+	return nil, false, rest
+}
+
+// readDocumentEntries calls fn with each entry of a JSON document, like
+// scanJSONLEntries does with lines. Entries that aren't objects are passed to invalid.
+func readDocumentEntries(ctx context.Context, entries []json.RawMessage, fn func(lineNum int, line map[string]interface{}) error, invalid func(*LineError) error) error {
+	for i, raw := range entries {
+		if (i+1)%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(raw, &entry); err != nil || entry == nil {
+			if err == nil {
+				err = fmt.Errorf("entry is not an object")
+			}
+			if err := invalid(&LineError{Line: i + 1, Snippet: snippet(raw), Err: err}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(i+1, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snippet truncates raw line content for inclusion in error messages
+func snippet(raw []byte) string {
+	if len(raw) > maxSnippetLength {
+		return string(raw[:maxSnippetLength]) + "..."
+	}
+	return string(raw)
+}
+
+// extractMessages converts a single JSONL entry into zero or more filtered messages,
+// tagging each with the source line number it came from
+func extractMessages(lineNum int, line map[string]interface{}, opts FilterOptions) []FilteredMessage {
+	if !opts.inLineRange(lineNum) {
+		return nil
+	}
+
+	msgType, ok := line["type"].(string)
+	if !ok {
+		return nil
+	}
+	if opts.Role != "" && msgType != opts.Role {
+		return nil
+	}
+
+	if msgType == "system" {
+		if !opts.IncludeSystem {
+			return nil
+		}
+		msg := systemMessage(line)
+		if !opts.inTimeRange(msg.Timestamp) {
+			return nil
+		}
+		msg.Line = lineNum
+		if opts.Anonymizer != nil {
+			msg.Content = opts.Anonymizer.Anonymize(msg.Content)
+		}
+		return []FilteredMessage{msg}
+	}
+
+	message, ok := line["message"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	timestamp, _ := line["timestamp"].(string)
+	if !opts.inTimeRange(timestamp) {
+		return nil
+	}
+
+	var messages []FilteredMessage
+	if msgType == "user" {
+		switch content := message["content"].(type) {
+		case string:
+			messages = append(messages, FilteredMessage{
+				Type:      "user",
+				Content:   content,
+				Timestamp: timestamp,
+			})
+		case []interface{}:
+			// Array content on user entries carries tool results and attachments
+			if opts.IncludeTools {
+				messages = append(messages, extractToolResults(content, timestamp)...)
+			}
+			messages = append(messages, extractAttachments("user", content, timestamp)...)
+		}
+	} else if msgType == "assistant" {
+		switch content := message["content"].(type) {
+		case string:
+			// Some export versions store assistant content as a plain string
+			if content != "" {
+				messages = append(messages, FilteredMessage{
+					Type:      "assistant",
+					Content:   content,
+					Timestamp: timestamp,
+				})
+			}
+		case []interface{}:
+			var textBlocks []string
+			var toolUses []FilteredMessage
+			for _, block := range content {
+				blockMap, ok := block.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				switch blockMap["type"] {
+				case "text":
+					if text, ok := blockMap["text"].(string); ok {
+						textBlocks = append(textBlocks, text)
+					}
+				case "tool_use":
+					if opts.IncludeTools {
+						toolUses = append(toolUses, FilteredMessage{
+							Type:      "tool_use",
+							Content:   formatToolUse(blockMap),
+							Timestamp: timestamp,
+						})
+					}
+				}
+			}
+			if len(textBlocks) > 0 {
+				messages = append(messages, FilteredMessage{
+					Type:      "assistant",
+					Content:   joinStrings(textBlocks, "\n"),
+					Timestamp: timestamp,
+				})
+			}
+			messages = append(messages, toolUses...)
+			messages = append(messages, extractAttachments("assistant", content, timestamp)...)
+		}
+	}
+
+	for i := range messages {
+		messages[i].Line = lineNum
+		if opts.Anonymizer != nil {
+			messages[i].Content = opts.Anonymizer.Anonymize(messages[i].Content)
+		}
+	}
+	return messages
+}
+
+// systemMessage extracts a system entry, whose content is either top-level "content"
+// or nested message content
+func systemMessage(line map[string]interface{}) FilteredMessage {
+	content, ok := line["content"]
+	if !ok {
+		if message, isMap := line["message"].(map[string]interface{}); isMap {
+			content = message["content"]
+		}
+	}
+	timestamp, _ := line["timestamp"].(string)
+	return FilteredMessage{Type: "system", Content: systemContent(content), Timestamp: timestamp}
+}
+
+// systemContent renders system entry content as text. Some entries store a string;
+// others store text blocks, whose text is joined, or a structured object, which is
+// kept as compact JSON.
+func systemContent(content interface{}) string {
+	switch value := content.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []interface{}:
+		var textBlocks []string
+		for _, block := range value {
+			if blockMap, ok := block.(map[string]interface{}); ok {
+				if text, ok := blockMap["text"].(string); ok {
+					textBlocks = append(textBlocks, text)
+				}
+			}
+		}
+		if len(textBlocks) > 0 {
+			return joinStrings(textBlocks, "\n")
+		}
+	case map[string]interface{}:
+		if text, ok := value["text"].(string); ok {
+			return text
+		}
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// formatToolUse serializes a tool_use block's name and input as compact JSON
+func formatToolUse(block map[string]interface{}) string {
+	name, _ := block["name"].(string)
+	data, err := json.Marshal(map[string]interface{}{
+		"name":  name,
+		"input": block["input"],
+	})
+	if err != nil {
+		return name
+	}
+	return string(data)
+}
+
+// attachmentTypes are content block types that reference binary data rather than text
+var attachmentTypes = map[string]bool{
+	"image":    true,
+	"document": true,
+}
+
+// extractAttachments emits a placeholder message for each attachment block so the
+// model knows non-text context was present (e.g. "[image attachment]")
+func extractAttachments(role string, contentArray []interface{}, timestamp string) []FilteredMessage {
+	var messages []FilteredMessage
+	for _, block := range contentArray {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		blockType, _ := blockMap["type"].(string)
+		if !attachmentTypes[blockType] {
+			continue
+		}
+		messages = append(messages, FilteredMessage{
+			Type:      role,
+			Content:   fmt.Sprintf("[%s attachment]", blockType),
+			Timestamp: timestamp,
+		})
+	}
+	return messages
+}
+
+// extractToolResults collects tool_result blocks from a user content array
+func extractToolResults(contentArray []interface{}, timestamp string) []FilteredMessage {
+	var messages []FilteredMessage
+	for _, block := range contentArray {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok || blockMap["type"] != "tool_result" {
+			continue
+		}
+
+		// Result content is either a plain string or an array of text blocks
+		var output string
+		switch content := blockMap["content"].(type) {
+		case string:
+			output = content
+		case []interface{}:
+			var textBlocks []string
+			for _, item := range content {
+				if itemMap, ok := item.(map[string]interface{}); ok {
+					if text, ok := itemMap["text"].(string); ok {
+						textBlocks = append(textBlocks, text)
+					}
+				}
+			}
+			output = joinStrings(textBlocks, "\n")
+		}
+
+		messages = append(messages, FilteredMessage{
+			Type:      "tool_result",
+			Content:   output,
+			Timestamp: timestamp,
+		})
+	}
+	return messages
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildUserJSONL returns n user messages as JSONL, numbered from 0
+func buildUserJSONL(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `{"type":"user","message":{"content":"Message %d"},"timestamp":"2024-01-01T10:00:00Z"}`+"\n", i)
+	}
+	return sb.String()
+}
+
+// decodeNDJSON parses NDJSON output into filtered messages
+func decodeNDJSON(t *testing.T, data []byte) []FilteredMessage {
+	t.Helper()
+	var messages []FilteredMessage
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var msg FilteredMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("Output line is not valid JSON: %q: %v", scanner.Text(), err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// TestFilterJSONLStream tests NDJSON streaming with and without a limit
+func TestFilterJSONLStream(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int
+		limit         int
+		expectedFirst string
+		expectedCount int
+	}{
+		{
+			name:          "Unlimited writes every message",
+			total:         25,
+			limit:         0,
+			expectedFirst: "Message 0",
+			expectedCount: 25,
+		},
+		{
+			name:          "Limit keeps most recent messages in order",
+			total:         25,
+			limit:         20,
+			expectedFirst: "Message 5",
+			expectedCount: 20,
+		},
+		{
+			name:          "Limit larger than input",
+			total:         3,
+			limit:         20,
+			expectedFirst: "Message 0",
+			expectedCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := filterJSONLStream(context.Background(), strings.NewReader(buildUserJSONL(tt.total)), &out, tt.limit, FilterOptions{}); err != nil {
+				t.Fatalf("filterJSONLStream failed: %v", err)
+			}
+
+			messages := decodeNDJSON(t, out.Bytes())
+			if len(messages) != tt.expectedCount {
+				t.Fatalf("Expected %d messages, got %d", tt.expectedCount, len(messages))
+			}
+			if messages[0].Content != tt.expectedFirst {
+				t.Errorf("Expected first message %q, got %q", tt.expectedFirst, messages[0].Content)
+			}
+			last := fmt.Sprintf("Message %d", tt.total-1)
+			if messages[len(messages)-1].Content != last {
+				t.Errorf("Expected last message %q, got %q", last, messages[len(messages)-1].Content)
+			}
+		})
+	}
+}
+
+// TestFilterJSONLStreamSkipsInvalidLines tests that corrupt and blank lines are ignored
+func TestFilterJSONLStreamSkipsInvalidLines(t *testing.T) {
+	input := `{"type":"user","message":{"content":"first"}}
+
+not json at all
+{"type":"user","message":{"content":"second"}}`
+
+	var out bytes.Buffer
+	skipped, err := filterJSONLStream(context.Background(), strings.NewReader(input), &out, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLStream failed: %v", err)
+	}
+	if skipped != 1 {
+		t.Errorf("Expected 1 skipped line, got %d", skipped)
+	}
+
+	messages := decodeNDJSON(t, out.Bytes())
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[1].Content != "second" {
+		t.Errorf("Expected final line without newline to be read, got %q", messages[1].Content)
+	}
+}
+
+// TestFilterJSONLStreamAssistantStringContent tests assistant content stored as a plain string
+func TestFilterJSONLStreamAssistantStringContent(t *testing.T) {
+	input := `{"type":"user","message":{"content":"Question"},"timestamp":"2024-01-01T10:00:00Z"}
+{"type":"assistant","message":{"content":"Plain string answer"},"timestamp":"2024-01-01T10:01:00Z"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Block answer"}]},"timestamp":"2024-01-01T10:02:00Z"}
+{"type":"assistant","message":{"content":""},"timestamp":"2024-01-01T10:03:00Z"}
+`
+
+	var out bytes.Buffer
+	if _, err := filterJSONLStream(context.Background(), strings.NewReader(input), &out, 0, FilterOptions{}); err != nil {
+		t.Fatalf("filterJSONLStream failed: %v", err)
+	}
+
+	messages := decodeNDJSON(t, out.Bytes())
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages (empty assistant string dropped), got %d", len(messages))
+	}
+
+	expected := []FilteredMessage{
+		{Type: "user", Content: "Question", Timestamp: "2024-01-01T10:00:00Z", Line: 1},
+		{Type: "assistant", Content: "Plain string answer", Timestamp: "2024-01-01T10:01:00Z", Line: 2},
+		{Type: "assistant", Content: "Block answer", Timestamp: "2024-01-01T10:02:00Z", Line: 3},
+	}
+	for i, want := range expected {
+		if messages[i] != want {
+			t.Errorf("Message %d = %+v, want %+v", i, messages[i], want)
+		}
+	}
+}
+
+// TestFilterJSONLFileStrict tests strict-mode line errors and non-strict skip counts
+func TestFilterJSONLFileStrict(t *testing.T) {
+	path := writeFixture(t, "corrupt.jsonl", `{"type":"user","message":{"content":"ok"}}
+{"type":"user","message":{"content":
+{"type":"user","message":{"content":"also ok"}}
+{broken
+`)
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("Non-strict filterJSONLFile failed: %v", err)
+	}
+	if result.SkippedLines != 2 {
+		t.Errorf("Expected 2 skipped lines, got %d", result.SkippedLines)
+	}
+	if len(result.Messages) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(result.Messages))
+	}
+
+	_, err = filterJSONLFile(context.Background(), path, 0, FilterOptions{Strict: true})
+	if err == nil {
+		t.Fatal("Expected strict mode to fail on malformed line")
+	}
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("Expected *LineError, got %T: %v", err, err)
+	}
+	if lineErr.Line != 2 {
+		t.Errorf("Expected error on line 2, got line %d", lineErr.Line)
+	}
+	if !strings.HasPrefix(lineErr.Snippet, `{"type":"user"`) {
+		t.Errorf("Expected snippet of offending line, got %q", lineErr.Snippet)
+	}
+}
+
+// TestFilterJSONLFileEmptyVsCorrupt tests that a corrupt file is distinguishable from an empty one
+func TestFilterJSONLFileEmptyVsCorrupt(t *testing.T) {
+	empty := writeFixture(t, "empty.jsonl", "")
+	corrupt := writeFixture(t, "corrupt.jsonl", "not json\nstill not json\n")
+
+	emptyResult, err := filterJSONLFile(context.Background(), empty, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+	corruptResult, err := filterJSONLFile(context.Background(), corrupt, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+
+	if len(emptyResult.Messages) != 0 || len(corruptResult.Messages) != 0 {
+		t.Fatal("Expected no messages from either file")
+	}
+	if emptyResult.SkippedLines != 0 {
+		t.Errorf("Expected empty file to skip 0 lines, got %d", emptyResult.SkippedLines)
+	}
+	if corruptResult.SkippedLines != 2 {
+		t.Errorf("Expected corrupt file to skip 2 lines, got %d", corruptResult.SkippedLines)
+	}
+
+	// Messages must encode as an empty array, not null
+	data, _ := json.Marshal(emptyResult)
+	if !strings.Contains(string(data), `"messages":[]`) {
+		t.Errorf("Expected empty messages array in JSON, got %s", data)
+	}
+}
+
+// TestFilterJSONLFileLineRange tests that only messages within the line range are kept
+func TestFilterJSONLFileLineRange(t *testing.T) {
+	path := writeFixture(t, "range.jsonl", buildUserJSONL(10))
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{StartLine: 3, EndLine: 5})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+
+	if len(result.Messages) != 3 {
+		t.Fatalf("Expected 3 messages in range, got %d", len(result.Messages))
+	}
+	for i, msg := range result.Messages {
+		expectedLine := i + 3
+		if msg.Line != expectedLine {
+			t.Errorf("Message %d: expected line %d, got %d", i, expectedLine, msg.Line)
+		}
+		expectedContent := fmt.Sprintf("Message %d", expectedLine-1)
+		if msg.Content != expectedContent {
+			t.Errorf("Message %d: expected %q, got %q", i, expectedContent, msg.Content)
+		}
+	}
+}
+
+// TestFilterJSONLFileTracksLines tests that blank and skipped lines still advance line numbers
+func TestFilterJSONLFileTracksLines(t *testing.T) {
+	path := writeFixture(t, "lines.jsonl", `{"type":"user","message":{"content":"first"}}
+
+garbage
+{"type":"user","message":{"content":"fourth"}}
+`)
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(result.Messages))
+	}
+	if result.Messages[0].Line != 1 || result.Messages[1].Line != 4 {
+		t.Errorf("Expected lines 1 and 4, got %d and %d", result.Messages[0].Line, result.Messages[1].Line)
+	}
+}
+
+// TestFilterJSONLFileAttachments tests placeholder messages for image and document blocks
+func TestFilterJSONLFileAttachments(t *testing.T) {
+	path := writeFixture(t, "attachments.jsonl", `{"type":"user","message":{"content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}}]},"timestamp":"2024-01-01T10:00:00Z"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"I see a screenshot"}]},"timestamp":"2024-01-01T10:01:00Z"}
+{"type":"user","message":{"content":[{"type":"document","source":{"type":"file","file_id":"file_123"}}]},"timestamp":"2024-01-01T10:02:00Z"}
+`)
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+
+	expected := []FilteredMessage{
+		{Type: "user", Content: "[image attachment]", Timestamp: "2024-01-01T10:00:00Z", Line: 1},
+		{Type: "assistant", Content: "I see a screenshot", Timestamp: "2024-01-01T10:01:00Z", Line: 2},
+		{Type: "user", Content: "[document attachment]", Timestamp: "2024-01-01T10:02:00Z", Line: 3},
+	}
+	if len(result.Messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d: %+v", len(expected), len(result.Messages), result.Messages)
+	}
+	for i, want := range expected {
+		if result.Messages[i] != want {
+			t.Errorf("Message %d = %+v, want %+v", i, result.Messages[i], want)
+		}
+	}
+
+	// Raw attachment data must never leak into the output
+	for _, msg := range result.Messages {
+		if strings.Contains(msg.Content, "iVBORw0KGgo") {
+			t.Errorf("Attachment data leaked into message: %q", msg.Content)
+		}
+	}
+}
+
+// TestFilterJSONLFileDocumentFormats tests sessions exported as one JSON document
+func TestFilterJSONLFileDocumentFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Messages wrapper", `{"messages":[{"type":"user","message":{"content":"first"}},{"type":"assistant","message":{"content":"second"}}]}`},
+		{"Pretty-printed wrapper", `{
+  "messages": [
+    {"type": "user", "message": {"content": "first"}},
+    {"type": "assistant", "message": {"content": "second"}}
+  ]
+}
+`},
+		{"Bare array", `[{"type":"user","message":{"content":"first"}},
+{"type":"assistant","message":{"content":"second"}}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFixture(t, "export.json", tt.content)
+			result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+			if err != nil {
+				t.Fatalf("filterJSONLFile failed: %v", err)
+			}
+			if len(result.Messages) != 2 || result.SkippedLines != 0 {
+				t.Fatalf("Expected 2 messages and no skipped entries, got %+v", result)
+			}
+			if result.Messages[0].Content != "first" || result.Messages[0].Line != 1 ||
+				result.Messages[1].Content != "second" || result.Messages[1].Line != 2 {
+				t.Errorf("Expected entries numbered in document order, got %+v", result.Messages)
+			}
+		})
+	}
+}
+
+// TestFilterJSONLFileDocumentStrict tests that a non-object entry fails strict mode
+func TestFilterJSONLFileDocumentStrict(t *testing.T) {
+	path := writeFixture(t, "export.json", `[{"type":"user","message":{"content":"first"}},"oops"]`)
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil || len(result.Messages) != 1 || result.SkippedLines != 1 {
+		t.Fatalf("Expected the non-object entry to be skipped, got %+v, %v", result, err)
+	}
+
+	_, err = filterJSONLFile(context.Background(), path, 0, FilterOptions{Strict: true})
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Errorf("Expected LineError for entry 2, got %v", err)
+	}
+}
+
+// TestFilterJSONLFileMalformedFirstLine tests that JSONL whose first line looks like
+// the start of a document is still read line by line
+func TestFilterJSONLFileMalformedFirstLine(t *testing.T) {
+	path := writeFixture(t, "broken.jsonl", `{"type":"user","message":
+{"type":"user","message":{"content":"second"}}
+{"type":"user","message":{"content":"third"}}
+`)
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+	if len(result.Messages) != 2 || result.SkippedLines != 1 || result.Messages[0].Line != 2 {
+		t.Errorf("Expected lines 2 and 3 with one skipped, got %+v", result)
+	}
+}
+
+// gzipFixture writes content gzipped to a temporary file
+func gzipFixture(t *testing.T, name, content string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(content))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeFixture(t, name, buf.String())
+}
+
+// TestFilterJSONLFileGzip tests that gzipped sessions are detected by extension or header
+func TestFilterJSONLFileGzip(t *testing.T) {
+	for _, name := range []string{"session.jsonl.gz", "session.jsonl"} {
+		t.Run(name, func(t *testing.T) {
+			path := gzipFixture(t, name, buildUserJSONL(5))
+			result, err := filterJSONLFile(context.Background(), path, 2, FilterOptions{})
+			if err != nil {
+				t.Fatalf("filterJSONLFile failed: %v", err)
+			}
+			if len(result.Messages) != 2 || result.Messages[1].Content != "Message 4" || result.Messages[1].Line != 5 {
+				t.Errorf("Unexpected messages: %+v", result.Messages)
+			}
+		})
+	}
+
+	corrupt := writeFixture(t, "corrupt.jsonl.gz", buildUserJSONL(1))
+	if _, err := filterJSONLFile(context.Background(), corrupt, 0, FilterOptions{}); err == nil {
+		t.Error("Expected an error for a .gz file that isn't gzipped")
+	}
+}
+
+// TestFilterJSONLFileIncludeSystem tests opt-in system entries in their different shapes
+func TestFilterJSONLFileIncludeSystem(t *testing.T) {
+	path := writeFixture(t, "system.jsonl", strings.Join([]string{
+		`{"type":"system","content":"You are a helpful assistant","timestamp":"2025-01-01T10:00:00Z"}`,
+		`{"type":"system","message":{"content":[{"type":"text","text":"Context"},{"type":"text","text":"window compacted"}]}}`,
+		`{"type":"system","content":{"cwd":"/repo","model":"haiku"}}`,
+		`{"type":"user","message":{"content":"Hello"}}`,
+	}, "\n"))
+
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Type != "user" {
+		t.Errorf("Expected system entries to be dropped by default, got %+v", result.Messages)
+	}
+
+	result, err = filterJSONLFile(context.Background(), path, 0, FilterOptions{IncludeSystem: true})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+	want := []FilteredMessage{
+		{Type: "system", Content: "You are a helpful assistant", Timestamp: "2025-01-01T10:00:00Z", Line: 1},
+		{Type: "system", Content: "Context\nwindow compacted", Line: 2},
+		{Type: "system", Content: `{"cwd":"/repo","model":"haiku"}`, Line: 3},
+		{Type: "user", Content: "Hello", Line: 4},
+	}
+	if len(result.Messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %+v", len(want), result.Messages)
+	}
+	for i := range want {
+		if result.Messages[i] != want[i] {
+			t.Errorf("Message %d: got %+v, want %+v", i, result.Messages[i], want[i])
+		}
+	}
+}
+
+// TestFilterJSONLFileRole tests that --role keeps one side of the conversation, with
+// tool messages only when tools are included too, before the limit is applied
+func TestFilterJSONLFileRole(t *testing.T) {
+	path := writeFixture(t, "roles.jsonl", strings.Join([]string{
+		`{"type":"system","content":"Session started"}`,
+		`{"type":"user","message":{"content":"Read the file"}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Reading it"},{"type":"tool_use","name":"Read","input":{"path":"a.go"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","content":"package a"}]}}`,
+		`{"type":"assistant","message":{"content":"It declares package a"}}`,
+		`{"type":"user","message":{"content":"Thanks"}}`,
+	}, "\n"))
+
+	types := func(messages []FilteredMessage) string {
+		var got []string
+		for _, msg := range messages {
+			got = append(got, msg.Type+"@"+fmt.Sprint(msg.Line))
+		}
+		return strings.Join(got, " ")
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		opts  FilterOptions
+		want  string
+	}{
+		{"User", 0, FilterOptions{Role: "user", IncludeSystem: true}, "user@2 user@6"},
+		{"Assistant", 0, FilterOptions{Role: "assistant"}, "assistant@3 assistant@5"},
+		{"User with tools", 0, FilterOptions{Role: "user", IncludeTools: true}, "user@2 tool_result@4 user@6"},
+		{"Assistant with tools", 0, FilterOptions{Role: "assistant", IncludeTools: true}, "assistant@3 tool_use@3 assistant@5"},
+		{"Limit after role", 1, FilterOptions{Role: "assistant"}, "assistant@5"},
+		{"All", 0, FilterOptions{}, "user@2 assistant@3 assistant@5 user@6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := filterJSONLFile(context.Background(), path, tt.limit, tt.opts)
+			if err != nil {
+				t.Fatalf("filterJSONLFile failed: %v", err)
+			}
+			if got := types(result.Messages); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFilterJSONLCanceled tests that a canceled context stops reading large input
+func TestFilterJSONLCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	input := buildUserJSONL(3 * contextCheckInterval)
+	if _, err := filterJSONL(ctx, strings.NewReader(input), 0, FilterOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("filterJSONL: expected context.Canceled, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if _, err := filterJSONLStream(ctx, strings.NewReader(input), &out, 0, FilterOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("filterJSONLStream: expected context.Canceled, got %v", err)
+	}
+	if strings.Count(out.String(), "\n") >= 3*contextCheckInterval {
+		t.Error("Expected streaming to stop before the end of the input")
+	}
+
+	document := "[" + strings.TrimSuffix(strings.ReplaceAll(input, "\n", ",\n"), ",\n") + "]"
+	if _, err := filterJSONL(ctx, strings.NewReader(document), 0, FilterOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("filterJSONL document: expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "os"
+
+// handleFilterAnalysis drops episodes below --min-confidence from a saved analysis, as
+// analyze --min-confidence does, and prints the trimmed analysis
+func handleFilterAnalysis() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer filter-analysis --input <analysis.json> --min-confidence <0-1>")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	inputPath := flags["input"]
+
+	if inputPath == "" {
+		respondError("Missing input path")
+		return
+	}
+	if flags["min-confidence"] == "" {
+		respondError("Missing min-confidence")
+		return
+	}
+	minConfidence, err := parseConfidenceFlag(flags, "min-confidence")
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	analysis, err := readAnalysisFile(inputPath)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	dropLowConfidence(analysis, minConfidence)
+	respondJSON(analysis)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestFilterAnalysisCommand tests trimming a saved analysis and argument validation
+func TestFilterAnalysisCommand(t *testing.T) {
+	input := writeFixture(t, "analysis.json", `{"episodes":[`+
+		`{"id":"ep1","phase":"planning","confidence":0.3,"start_line":1,"end_line":4},`+
+		`{"id":"ep2","phase":"implementation","confidence":0.8,"start_line":5,"end_line":9}`+
+		`],"patterns":{"workflow":"linear","efficiency":"high"},"metadata":{}}`)
+
+	output := runMain(t, "filter-analysis", "--input", input, "--min-confidence", "0.5")
+	var analysis llm.Analysis
+	if err := json.Unmarshal([]byte(output), &analysis); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if len(analysis.Episodes) != 1 || analysis.Episodes[0].ID != "ep2" {
+		t.Errorf("Expected only ep2, got %+v", analysis.Episodes)
+	}
+	// JSON numbers decode as float64
+	if dropped := analysis.Metadata.HierarchicalInfo[llm.InfoLowConfidenceDropped]; dropped != float64(1) {
+		t.Errorf("Expected 1 dropped episode in metadata, got %v", dropped)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Missing input", []string{"--min-confidence", "0.5"}, "Missing input path"},
+		{"Missing threshold", []string{"--input", input}, "Missing min-confidence"},
+		{"Out of range", []string{"--input", input, "--min-confidence", "1.5"}, "Invalid min-confidence: 1.5"},
+		{"Not a number", []string{"--input", input, "--min-confidence", "high"}, "Invalid min-confidence: high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runMain(t, append([]string{"filter-analysis"}, tt.args...)...)
+			if !strings.Contains(output, `"error"`) || !strings.Contains(output, tt.want) {
+				t.Errorf("Expected error %q, got: %s", tt.want, output)
+			}
+		})
+	}
+}
+
+// TestAnalyzeMinConfidence tests that analyze trims a valid analysis instead of rejecting it
+func TestAnalyzeMinConfidence(t *testing.T) {
+	promptFile := installRoutingFakeClaude(t,
+		fakeRoute{Marker: "Reply with JSON only", Response: validAnalysisJSON},
+		fakeRoute{Marker: "concise summary", Response: validSummary},
+	)
+
+	output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: add streaming", "--both", "--min-confidence", "0.95")
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, output)
+	}
+	if response.Analysis == nil || len(response.Analysis.Episodes) != 0 {
+		t.Fatalf("Expected an analysis with its episode trimmed, got %+v (error %q)", response.Analysis, response.AnalysisError)
+	}
+	if dropped := response.Analysis.Metadata.HierarchicalInfo[llm.InfoLowConfidenceDropped]; dropped != float64(1) {
+		t.Errorf("Expected 1 dropped episode in metadata, got %v", dropped)
+	}
+	if prompts := readPrompts(t, promptFile); len(prompts) != 2 {
+		t.Errorf("Expected no retry for low-confidence episodes, got %d prompts", len(prompts))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FingerprintResponse represents the fingerprint command output
+type FingerprintResponse struct {
+	File         string `json:"file"`
+	Fingerprint  string `json:"fingerprint"`
+	MessageCount int    `json:"message_count"`
+}
+
+// handleFingerprint computes a stable content hash for a JSONL session
+func handleFingerprint() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer fingerprint --file <path>")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	filePath := flags["file"]
+
+	if filePath == "" {
+		respondError("Missing file path")
+		return
+	}
+
+	// Fingerprint the whole conversation, not just the most recent messages
+	result, err := filterJSONLFile(context.Background(), filePath, 0, FilterOptions{})
+	if err != nil {
+		respondError(fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	respondJSON(FingerprintResponse{
+		File:         filePath,
+		Fingerprint:  computeFingerprint(result.Messages),
+		MessageCount: len(result.Messages),
+	})
+}
+
+// computeFingerprint hashes the normalized type and content of each message.
+// Timestamps are ignored so the same session recorded twice hashes identically.
+func computeFingerprint(messages []FilteredMessage) string {
+	hash := sha256.New()
+	for _, msg := range messages {
+		content := strings.ReplaceAll(msg.Content, "\r\n", "\n")
+		content = strings.TrimSpace(content)
+
+		// Length-prefix each field so message boundaries can't collide
+		fmt.Fprintf(hash, "%d:%s%d:%s", len(msg.Type), msg.Type, len(content), content)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
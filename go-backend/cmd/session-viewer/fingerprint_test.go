@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFixture writes JSONL content to a file in a temp directory
+func writeFixture(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	return path
+}
+
+// fingerprintFile filters a fixture and returns its fingerprint
+func fingerprintFile(t *testing.T, path string) string {
+	t.Helper()
+	result, err := filterJSONLFile(context.Background(), path, 0, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filterJSONLFile failed: %v", err)
+	}
+	return computeFingerprint(result.Messages)
+}
+
+// TestComputeFingerprint tests fingerprint stability and sensitivity
+func TestComputeFingerprint(t *testing.T) {
+	original := writeFixture(t, "original.jsonl", `{"type":"user","message":{"content":"Fix the bug"},"timestamp":"2024-01-01T10:00:00Z"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Fixed it"}]},"timestamp":"2024-01-01T10:01:00Z"}
+`)
+	retimed := writeFixture(t, "retimed.jsonl", `{"type":"user","message":{"content":"Fix the bug"},"timestamp":"2025-06-15T08:30:00Z"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Fixed it"}]},"timestamp":"2025-06-15T08:31:00Z"}
+`)
+	changed := writeFixture(t, "changed.jsonl", `{"type":"user","message":{"content":"Fix the bug"},"timestamp":"2024-01-01T10:00:00Z"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Could not fix it"}]},"timestamp":"2024-01-01T10:01:00Z"}
+`)
+
+	base := fingerprintFile(t, original)
+	if len(base) != 64 {
+		t.Errorf("Expected 64-character hex fingerprint, got %q", base)
+	}
+
+	if got := fingerprintFile(t, retimed); got != base {
+		t.Errorf("Expected files differing only in timestamps to match: %s vs %s", base, got)
+	}
+
+	if got := fingerprintFile(t, changed); got == base {
+		t.Error("Expected content change to produce a different fingerprint")
+	}
+}
+
+// TestComputeFingerprintMessageBoundaries tests that content can't shift across messages
+func TestComputeFingerprintMessageBoundaries(t *testing.T) {
+	a := computeFingerprint([]FilteredMessage{
+		{Type: "user", Content: "ab"},
+		{Type: "user", Content: "c"},
+	})
+	b := computeFingerprint([]FilteredMessage{
+		{Type: "user", Content: "a"},
+		{Type: "user", Content: "bc"},
+	})
+	if a == b {
+		t.Error("Expected different message splits to produce different fingerprints")
+	}
+}
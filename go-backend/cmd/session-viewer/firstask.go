@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// FirstAskResponse represents the first-ask command output
+type FirstAskResponse struct {
+	File             string `json:"file"`
+	Content          string `json:"content"`
+	Timestamp        string `json:"timestamp,omitempty"`
+	Line             int    `json:"line"`
+	Restatement      string `json:"restatement,omitempty"`
+	RestatementError string `json:"restatement_error,omitempty"`
+}
+
+// errFirstAskFound stops reading once the first user message has been seen
+var errFirstAskFound = errors.New("first user message found")
+
+// handleFirstAsk returns the session's original request: the first user message
+func handleFirstAsk(cfg *config.Config) {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer first-ask --file <path> [--restate]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:], "restate")
+	filePath := flags["file"]
+
+	if filePath == "" {
+		respondError("Missing file path")
+		return
+	}
+
+	first, err := findFirstUserMessage(filePath)
+	if err != nil {
+		respondError(fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+	if first == nil {
+		respondError("No user message found in session")
+		return
+	}
+
+	response := FirstAskResponse{
+		File:      filePath,
+		Content:   first.Content,
+		Timestamp: first.Timestamp,
+		Line:      first.Line,
+	}
+
+	if flags["restate"] != "" {
+		restatement, err := restateRequest(cfg, first.Content)
+		if err != nil {
+			response.RestatementError = err.Error()
+		} else {
+			response.Restatement = restatement
+		}
+	}
+
+	respondJSON(response)
+}
+
+// findFirstUserMessage returns the first user entry with text content, skipping
+// tool results and attachments. Returns nil if the session has no user message.
+func findFirstUserMessage(filePath string) (*FilteredMessage, error) {
+	file, err := openSession(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var first *FilteredMessage
+	_, err = readJSONLEntries(context.Background(), file, false, func(lineNum int, line map[string]interface{}) error {
+		if line["type"] != "user" {
+			return nil
+		}
+		message, ok := line["message"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		content, ok := message["content"].(string)
+		if !ok || strings.TrimSpace(content) == "" {
+			return nil
+		}
+		timestamp, _ := line["timestamp"].(string)
+		first = &FilteredMessage{Type: "user", Content: content, Timestamp: timestamp, Line: lineNum}
+		return errFirstAskFound
+	})
+	if err != nil && !errors.Is(err, errFirstAskFound) {
+		return nil, err
+	}
+	return first, nil
+}
+
+// restateRequest asks the model for a one-line restatement of the user's request
+func restateRequest(cfg *config.Config, request string) (string, error) {
+	prompt := `Restate the following request in a single line of at most 20 words, in third person (e.g. "User wants to..."). Reply with the restatement only.
+
+Request:
+` + request
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	analyzer, err := newAnalyzer(cfg, "", validator.Options{})
+	if err != nil {
+		return "", err
+	}
+	response, err := analyzer.SendConversationalPrompt(ctx, prompt, "")
+	if err != nil {
+		return "", err
+	}
+
+	// Keep only the first non-empty line in case the model elaborates
+	for _, line := range strings.Split(response, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+	return "", fmt.Errorf("empty restatement")
+}
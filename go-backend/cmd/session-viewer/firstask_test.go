@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestFirstAsk tests that the first user message is returned
+func TestFirstAsk(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", `{"type":"user","message":{"content":"Add a dark mode toggle"},"timestamp":"2024-01-01T10:00:00Z"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Sure"}]},"timestamp":"2024-01-01T10:01:00Z"}
+{"type":"user","message":{"content":"Also fix the header"},"timestamp":"2024-01-01T10:02:00Z"}
+`)
+
+	var response FirstAskResponse
+	if err := json.Unmarshal([]byte(runMain(t, "first-ask", "--file", path)), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+
+	if response.Content != "Add a dark mode toggle" {
+		t.Errorf("Expected first user message, got %q", response.Content)
+	}
+	if response.Line != 1 || response.Timestamp != "2024-01-01T10:00:00Z" {
+		t.Errorf("Unexpected line/timestamp: %d %q", response.Line, response.Timestamp)
+	}
+	if response.Restatement != "" {
+		t.Errorf("Expected no restatement without --restate, got %q", response.Restatement)
+	}
+}
+
+// TestFirstAskSkipsLeadingAssistant tests a session that starts with assistant and tool entries
+func TestFirstAskSkipsLeadingAssistant(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", `{"type":"summary","summary":"Earlier work"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Welcome back"}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"user","message":{"content":"Refactor the parser"}}
+`)
+
+	first, err := findFirstUserMessage(path)
+	if err != nil {
+		t.Fatalf("findFirstUserMessage failed: %v", err)
+	}
+	if first == nil || first.Content != "Refactor the parser" || first.Line != 4 {
+		t.Errorf("Expected first user message on line 4, got %+v", first)
+	}
+}
+
+// TestFirstAskNoUserMessage tests a session without any user message
+func TestFirstAskNoUserMessage(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", `{"type":"assistant","message":{"content":"Hello"}}
+`)
+
+	output := runMain(t, "first-ask", "--file", path)
+	if !strings.Contains(output, "No user message found") {
+		t.Errorf("Expected no-user-message error, got %s", output)
+	}
+}
+
+// TestFirstAskRestate tests the one-line model restatement
+func TestFirstAskRestate(t *testing.T) {
+	promptFile := installFakeClaude(t, "User wants a dark mode toggle.\nExtra detail")
+	path := writeFixture(t, "session.jsonl", `{"type":"user","message":{"content":"Add a dark mode toggle"}}
+`)
+
+	var response FirstAskResponse
+	if err := json.Unmarshal([]byte(runMain(t, "first-ask", "--file", path, "--restate")), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+
+	if response.Restatement != "User wants a dark mode toggle." {
+		t.Errorf("Expected first line of restatement, got %q", response.Restatement)
+	}
+	if prompts := readPrompts(t, promptFile); !strings.Contains(prompts[0], "Add a dark mode toggle") {
+		t.Errorf("Expected prompt to include the request, got %q", prompts[0])
+	}
+}
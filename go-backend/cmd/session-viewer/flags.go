@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseFlags parses "--name value" and "--name=value" pairs from command arguments.
+// Names listed in boolFlags are switches that take no value and are recorded as "true".
+// A value flag given as the last argument with no value is ignored.
+func parseFlags(args []string, boolFlags ...string) map[string]string {
+	isBool := make(map[string]bool, len(boolFlags))
+	for _, name := range boolFlags {
+		isBool[name] = true
+	}
+
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			continue
+		}
+		name := strings.TrimPrefix(args[i], "--")
+
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			flags[name[:eq]] = name[eq+1:]
+			continue
+		}
+
+		if isBool[name] {
+			flags[name] = "true"
+			continue
+		}
+
+		if i+1 < len(args) {
+			flags[name] = args[i+1]
+			i++
+		}
+	}
+	return flags
+}
+
+// parseIntFlag returns the non-negative integer value of a flag, or defaultValue when unset
+func parseIntFlag(flags map[string]string, name string, defaultValue int) (int, error) {
+	value, ok := flags[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("Invalid %s: %s", name, value)
+	}
+	return parsed, nil
+}
+
+// parseConfidenceFlag returns the value of a confidence threshold flag, a number from 0
+// to 1, or 0 when unset
+func parseConfidenceFlag(flags map[string]string, name string) (float64, error) {
+	value, ok := flags[name]
+	if !ok {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 || parsed > 1 {
+		return 0, fmt.Errorf("Invalid %s: %s (expected a number from 0 to 1)", name, value)
+	}
+	return parsed, nil
+}
+
+// parseRoleFlag returns the --role value, "user" or "assistant", or "" for all roles
+func parseRoleFlag(flags map[string]string) (string, error) {
+	switch role := flags["role"]; role {
+	case "", "all":
+		return "", nil
+	case "user", "assistant":
+		return role, nil
+	default:
+		return "", fmt.Errorf("Invalid role: %s (expected user, assistant, or all)", role)
+	}
+}
+
+// parseLineRange reads --start-line and --end-line, where 0 leaves that end of the range open
+func parseLineRange(flags map[string]string) (int, int, error) {
+	startLine, err := parseIntFlag(flags, "start-line", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	endLine, err := parseIntFlag(flags, "end-line", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if endLine > 0 && startLine > endLine {
+		return 0, 0, fmt.Errorf("Invalid line range: start-line %d is after end-line %d", startLine, endLine)
+	}
+	return startLine, endLine, nil
+}
+
+// parseTimeRange reads --since and --until, each a timestamp or a Go duration relative
+// to now such as -2h. An unset flag leaves that end of the range open (the zero time).
+func parseTimeRange(flags map[string]string, now time.Time) (time.Time, time.Time, error) {
+	since, err := parseTimeFlag(flags, "since", now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	until, err := parseTimeFlag(flags, "until", now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !since.IsZero() && !until.IsZero() && since.After(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid time range: since %s is after until %s", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+	return since, until, nil
+}
+
+// parseTimeFlag parses one --since or --until value. Timestamps are read like message
+// timestamps, in any of the common layouts.
+func parseTimeFlag(flags map[string]string, name string, now time.Time) (time.Time, error) {
+	value := flags[name]
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if offset, err := time.ParseDuration(value); err == nil {
+		return now.Add(offset), nil
+	}
+	if at, err := parseTimestamp(value, ""); err == nil {
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid %s: %s (expected an RFC 3339 time or a duration relative to now, like -2h)", name, value)
+}
+
+// sliceLines returns the 1-based, inclusive line range of content.
+// A zero start or end leaves that end of the range open.
+func sliceLines(content string, startLine, endLine int) string {
+	if startLine <= 1 && endLine == 0 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	from := 0
+	if startLine > 1 {
+		from = startLine - 1
+	}
+	to := len(lines)
+	if endLine > 0 && endLine < to {
+		to = endLine
+	}
+	if from >= to {
+		return ""
+	}
+	return strings.Join(lines[from:to], "\n")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseFlags tests command argument parsing
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		boolFlags []string
+		expected  map[string]string
+	}{
+		{
+			name:     "Value flags",
+			args:     []string{"--session-id", "abc", "--content", "hello"},
+			expected: map[string]string{"session-id": "abc", "content": "hello"},
+		},
+		{
+			name:      "Bool flag between value flags",
+			args:      []string{"--file", "a.jsonl", "--include-tools", "--limit", "5"},
+			boolFlags: []string{"include-tools"},
+			expected:  map[string]string{"file": "a.jsonl", "include-tools": "true", "limit": "5"},
+		},
+		{
+			name:     "Trailing flag without value",
+			args:     []string{"--file"},
+			expected: map[string]string{},
+		},
+		{
+			name:      "Equals form",
+			args:      []string{"--limit=5", "--file=a=b.jsonl", "--include-tools"},
+			boolFlags: []string{"include-tools"},
+			expected:  map[string]string{"limit": "5", "file": "a=b.jsonl", "include-tools": "true"},
+		},
+		{
+			name:     "Stray positional arguments are ignored",
+			args:     []string{"extra", "--file", "a.jsonl"},
+			expected: map[string]string{"file": "a.jsonl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseFlags(tt.args, tt.boolFlags...)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseFlags(%v) = %v, want %v", tt.args, result, tt.expected)
+			}
+			for key, value := range tt.expected {
+				if result[key] != value {
+					t.Errorf("parseFlags(%v)[%q] = %q, want %q", tt.args, key, result[key], value)
+				}
+			}
+		})
+	}
+}
+
+// TestParseLineRange tests --start-line/--end-line validation
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		name          string
+		flags         map[string]string
+		expectedStart int
+		expectedEnd   int
+		expectError   bool
+	}{
+		{name: "Unset", flags: map[string]string{}},
+		{name: "Both set", flags: map[string]string{"start-line": "5", "end-line": "10"}, expectedStart: 5, expectedEnd: 10},
+		{name: "Open end", flags: map[string]string{"start-line": "5"}, expectedStart: 5},
+		{name: "Start after end", flags: map[string]string{"start-line": "10", "end-line": "5"}, expectError: true},
+		{name: "Not a number", flags: map[string]string{"end-line": "ten"}, expectError: true},
+		{name: "Negative", flags: map[string]string{"start-line": "-1"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parseLineRange(tt.flags)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %v", tt.flags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if start != tt.expectedStart || end != tt.expectedEnd {
+				t.Errorf("parseLineRange(%v) = (%d, %d), want (%d, %d)", tt.flags, start, end, tt.expectedStart, tt.expectedEnd)
+			}
+		})
+	}
+}
+
+// TestParseRoleFlag tests accepted --role values
+func TestParseRoleFlag(t *testing.T) {
+	for value, want := range map[string]string{"": "", "all": "", "user": "user", "assistant": "assistant"} {
+		role, err := parseRoleFlag(map[string]string{"role": value})
+		if err != nil || role != want {
+			t.Errorf("parseRoleFlag(%q) = (%q, %v), want %q", value, role, err, want)
+		}
+	}
+	if _, err := parseRoleFlag(map[string]string{"role": "system"}); err == nil {
+		t.Error("Expected an error for an unsupported role")
+	}
+}
+
+// TestParseTimeRange tests absolute and relative --since and --until values
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	since, until, err := parseTimeRange(map[string]string{"since": "-2h", "until": "2025-06-01T11:30:00Z"}, now)
+	if err != nil {
+		t.Fatalf("parseTimeRange failed: %v", err)
+	}
+	if !since.Equal(now.Add(-2*time.Hour)) || !until.Equal(time.Date(2025, 6, 1, 11, 30, 0, 0, time.UTC)) {
+		t.Errorf("Got (%v, %v)", since, until)
+	}
+
+	if since, until, err := parseTimeRange(map[string]string{}, now); err != nil || !since.IsZero() || !until.IsZero() {
+		t.Errorf("Expected an open range, got (%v, %v, %v)", since, until, err)
+	}
+
+	for _, flags := range []map[string]string{
+		{"since": "last tuesday"},
+		{"since": "-1h", "until": "-2h"},
+	} {
+		if _, _, err := parseTimeRange(flags, now); err == nil {
+			t.Errorf("Expected an error for %v", flags)
+		}
+	}
+}
+
+// TestSliceLines tests restricting content to a line range
+func TestSliceLines(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\nfive"
+
+	tests := []struct {
+		name     string
+		start    int
+		end      int
+		expected string
+	}{
+		{name: "Whole content", start: 0, end: 0, expected: content},
+		{name: "Middle range", start: 2, end: 4, expected: "two\nthree\nfour"},
+		{name: "Open end", start: 4, end: 0, expected: "four\nfive"},
+		{name: "Open start", start: 0, end: 2, expected: "one\ntwo"},
+		{name: "End past content", start: 5, end: 99, expected: "five"},
+		{name: "Start past content", start: 10, end: 0, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sliceLines(content, tt.start, tt.end)
+			if result != tt.expected {
+				t.Errorf("sliceLines(%d, %d) = %q, want %q", tt.start, tt.end, result, tt.expected)
+			}
+		})
+	}
+}
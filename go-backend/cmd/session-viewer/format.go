@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// handleFormat renders a saved analysis as a Markdown or HTML report, or its episodes
+// as CSV. The output is written to stdout as-is rather than wrapped in JSON so it can
+// be piped into a file.
+func handleFormat() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer format --input <analysis.json> [--as markdown|html|csv]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	inputPath := flags["input"]
+
+	if inputPath == "" {
+		respondError("Missing input path")
+		return
+	}
+
+	as := flags["as"]
+	if as == "" {
+		as = "markdown"
+	}
+	if as != "markdown" && as != "html" && as != "csv" {
+		respondError(fmt.Sprintf("Invalid format: %s (expected markdown, html, or csv)", as))
+		return
+	}
+
+	analysis, err := readAnalysisFile(inputPath)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	switch as {
+	case "html":
+		report, err := renderHTML(analysis)
+		if err != nil {
+			respondError(fmt.Sprintf("Error rendering HTML: %v", err))
+			return
+		}
+		fmt.Print(report)
+	case "csv":
+		report, err := renderCSV(analysis)
+		if err != nil {
+			respondError(fmt.Sprintf("Error rendering CSV: %v", err))
+			return
+		}
+		fmt.Print(report)
+	default:
+		fmt.Print(renderMarkdown(analysis))
+	}
+}
+
+// readAnalysisFile reads a saved analysis, bare or wrapped in an analyze response
+func readAnalysisFile(path string) (*llm.Analysis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading file: %v", err)
+	}
+	analysis, err := decodeAnalysis(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid analysis JSON in %s: %v", path, err)
+	}
+	return analysis, nil
+}
+
+// decodeAnalysis accepts either a bare llm.Analysis or a saved analyze response whose
+// "analysis" field holds one (as produced by analyze --both)
+func decodeAnalysis(data []byte) (*llm.Analysis, error) {
+	var wrapped struct {
+		Analysis *llm.Analysis `json:"analysis"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+	if wrapped.Analysis != nil {
+		return wrapped.Analysis, nil
+	}
+
+	var analysis llm.Analysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
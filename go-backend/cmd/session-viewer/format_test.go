@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestFormatCommand tests rendering a saved analysis in each report format
+func TestFormatCommand(t *testing.T) {
+	bare := writeFixture(t, "analysis.json", validAnalysisJSON)
+	wrapped := writeFixture(t, "response.json", `{"session_id":"s1","summary":"x","analysis":`+validAnalysisJSON+`}`)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Markdown by default", []string{"--input", bare}, "### implementation (ep1)"},
+		{"Markdown from analyze response", []string{"--input", wrapped, "--as", "markdown"}, "- Add tests"},
+		{"HTML", []string{"--input", bare, "--as", "html"}, `<section id="episode-implementation-ep1">`},
+		{"CSV", []string{"--input", wrapped, "--as", "csv"}, "id,phase,sub_phase,confidence,start_line,end_line,duration,description\nep1,implementation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runMain(t, append([]string{"format"}, tt.args...)...)
+			if !strings.Contains(output, tt.want) {
+				t.Errorf("Expected %q in output:\n%s", tt.want, output)
+			}
+		})
+	}
+}
+
+// TestFormatCommandErrors tests argument and input validation
+func TestFormatCommandErrors(t *testing.T) {
+	bad := writeFixture(t, "bad.json", "not json")
+	good := writeFixture(t, "analysis.json", validAnalysisJSON)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Missing input", []string{"--as", "html"}, "Missing input path"},
+		{"Unknown format", []string{"--input", good, "--as", "pdf"}, "Invalid format: pdf"},
+		{"Invalid JSON", []string{"--input", bad}, "Invalid analysis JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runMain(t, append([]string{"format"}, tt.args...)...)
+			if !strings.Contains(output, `"error"`) || !strings.Contains(output, tt.want) {
+				t.Errorf("Expected error %q, got: %s", tt.want, output)
+			}
+		})
+	}
+}
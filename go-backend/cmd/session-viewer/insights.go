@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// InsightsResponse represents the insights command output
+type InsightsResponse struct {
+	TotalTime string         `json:"total_time"` // Summed time of every timed episode
+	Phases    []PhaseInsight `json:"phases"`     // Longest first
+}
+
+// PhaseInsight is the time spent in one phase of a session
+type PhaseInsight struct {
+	Phase             string  `json:"phase"`
+	TotalTime         string  `json:"total_time"`
+	TotalSeconds      float64 `json:"total_seconds"`
+	TimeShare         float64 `json:"time_share"` // Fraction of the session's total time
+	Episodes          int     `json:"episodes"`
+	UntimedEpisodes   int     `json:"untimed_episodes,omitempty"` // Episodes without timestamps or a parseable duration
+	AverageConfidence float64 `json:"average_confidence"`
+}
+
+// handleInsights shows where the time in a saved analysis went, phase by phase
+func handleInsights() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer insights --input <analysis.json>")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	inputPath := flags["input"]
+
+	if inputPath == "" {
+		respondError("Missing input path")
+		return
+	}
+
+	analysis, err := readAnalysisFile(inputPath)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	respondJSON(buildInsights(llm.AggregateByPhase(analysis)))
+}
+
+// buildInsights orders phase stats by time spent, then episode count, then name
+func buildInsights(stats map[string]llm.PhaseStats) InsightsResponse {
+	var total time.Duration
+	for _, s := range stats {
+		total += s.TotalTime
+	}
+
+	phases := make([]PhaseInsight, 0, len(stats))
+	for phase, s := range stats {
+		insight := PhaseInsight{
+			Phase:             phase,
+			TotalTime:         s.TotalTime.String(),
+			TotalSeconds:      s.TotalTime.Seconds(),
+			Episodes:          s.Episodes,
+			UntimedEpisodes:   s.Episodes - s.TimedEpisodes,
+			AverageConfidence: s.AverageConfidence,
+		}
+		if total > 0 {
+			insight.TimeShare = float64(s.TotalTime) / float64(total)
+		}
+		phases = append(phases, insight)
+	}
+	sort.Slice(phases, func(i, j int) bool {
+		a, b := phases[i], phases[j]
+		if a.TotalSeconds != b.TotalSeconds {
+			return a.TotalSeconds > b.TotalSeconds
+		}
+		if a.Episodes != b.Episodes {
+			return a.Episodes > b.Episodes
+		}
+		return a.Phase < b.Phase
+	})
+
+	return InsightsResponse{TotalTime: total.String(), Phases: phases}
+}
+
+// writeInsightsText renders one line per phase, longest first
+func writeInsightsText(b *strings.Builder, r InsightsResponse) {
+	fmt.Fprintf(b, "Total time: %s\n", r.TotalTime)
+	for _, p := range r.Phases {
+		fmt.Fprintf(b, "  %-16s %10s %4.0f%%  %d episode(s), avg confidence %.2f", p.Phase, p.TotalTime, p.TimeShare*100, p.Episodes, p.AverageConfidence)
+		if p.UntimedEpisodes > 0 {
+			fmt.Fprintf(b, ", %d untimed", p.UntimedEpisodes)
+		}
+		b.WriteByte('\n')
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// insightsAnalysisJSON has two timed debugging episodes, one implementation episode,
+// and one untimed review episode
+const insightsAnalysisJSON = `{"episodes":[
+	{"id":"ep1","phase":"debugging","confidence":0.9,"description":"a","start_line":1,"end_line":5,"start_time":"2025-01-01T10:00:00Z","end_time":"2025-01-01T10:30:00Z"},
+	{"id":"ep2","phase":"implementation","confidence":0.8,"description":"b","start_line":6,"end_line":9,"duration":"15m"},
+	{"id":"ep3","phase":"debugging","confidence":0.5,"description":"c","start_line":10,"end_line":12,"duration":"15m"},
+	{"id":"ep4","phase":"review","confidence":0.6,"description":"d","start_line":13,"end_line":14}
+],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":[],"metadata":{}}`
+
+// TestInsightsCommand tests the per-phase rollup of a saved analysis
+func TestInsightsCommand(t *testing.T) {
+	path := writeFixture(t, "analysis.json", insightsAnalysisJSON)
+
+	var response InsightsResponse
+	output := runMain(t, "insights", "--input", path)
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+
+	if response.TotalTime != "1h0m0s" {
+		t.Errorf("Expected total time 1h0m0s, got %s", response.TotalTime)
+	}
+	want := []PhaseInsight{
+		{Phase: "debugging", TotalTime: "45m0s", TotalSeconds: 2700, TimeShare: 0.75, Episodes: 2, AverageConfidence: 0.7},
+		{Phase: "implementation", TotalTime: "15m0s", TotalSeconds: 900, TimeShare: 0.25, Episodes: 1, AverageConfidence: 0.8},
+		{Phase: "review", TotalTime: "0s", Episodes: 1, UntimedEpisodes: 1, AverageConfidence: 0.6},
+	}
+	if len(response.Phases) != len(want) {
+		t.Fatalf("Expected %d phases, got %+v", len(want), response.Phases)
+	}
+	for i := range want {
+		got := response.Phases[i]
+		got.AverageConfidence = float64(int(got.AverageConfidence*100+0.5)) / 100
+		if got != want[i] {
+			t.Errorf("Phase %d: got %+v, want %+v", i, response.Phases[i], want[i])
+		}
+	}
+
+	text := runMain(t, "--output", "text", "insights", "--input", path)
+	if !strings.Contains(text, "Total time: 1h0m0s") || !strings.Contains(text, "1 untimed") {
+		t.Errorf("Unexpected text output:\n%s", text)
+	}
+}
+
+// TestInsightsCommandErrors tests argument and input validation
+func TestInsightsCommandErrors(t *testing.T) {
+	bad := writeFixture(t, "bad.json", "not json")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Missing input", []string{"--limit", "5"}, "Missing input path"},
+		{"Invalid JSON", []string{"--input", bad}, "Invalid analysis JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runMain(t, append([]string{"insights"}, tt.args...)...)
+			if !strings.Contains(output, `"error"`) || !strings.Contains(output, tt.want) {
+				t.Errorf("Expected error %q, got: %s", tt.want, output)
+			}
+		})
+	}
+}
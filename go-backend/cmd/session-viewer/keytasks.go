@@ -0,0 +1,111 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// keyTasksLabel matches the start of a "Key Tasks" section after markdown emphasis and
+// list markers are removed, capturing any tasks that follow on the same line
+var keyTasksLabel = regexp.MustCompile(`(?i)^key tasks(?: accomplished)?\s*:?\s*(.*)$`)
+
+// listItem matches a bulleted or numbered list line, capturing its text
+var listItem = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)])\s+(.*)$`)
+
+// extractKeyTasks pulls the individual tasks out of a summary's "Key Tasks" section.
+// Tasks may follow the label inline, separated by commas or semicolons, or be listed
+// on the following lines with any common bullet or numbering style. When the label
+// is itself a list item, only items nested under it are tasks; its siblings are other
+// sections. Returns nil if the summary has no such section.
+func extractKeyTasks(summary string) []string {
+	lines := strings.Split(summary, "\n")
+	for i, line := range lines {
+		match := keyTasksLabel.FindStringSubmatch(normalizeLabelLine(line))
+		if match == nil {
+			continue
+		}
+
+		labelIndent := -1
+		if listItem.MatchString(line) {
+			labelIndent = indentOf(line)
+		}
+
+		tasks := splitInlineTasks(match[1])
+		for _, next := range lines[i+1:] {
+			if strings.TrimSpace(next) == "" {
+				if len(tasks) > 0 {
+					break
+				}
+				continue
+			}
+			item := listItem.FindStringSubmatch(next)
+			if item == nil || indentOf(next) <= labelIndent {
+				break
+			}
+			if task := cleanTask(stripEmphasis(item[1])); task != "" {
+				tasks = append(tasks, task)
+			}
+		}
+		return tasks
+	}
+	return nil
+}
+
+// indentOf counts the leading whitespace of a line, treating a tab as 4 spaces
+func indentOf(line string) int {
+	indent := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4
+		default:
+			return indent
+		}
+	}
+	return indent
+}
+
+// normalizeLabelLine removes heading, list, and emphasis markup around a label line
+func normalizeLabelLine(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimLeft(line, "#")
+	if item := listItem.FindStringSubmatch(line); item != nil {
+		line = item[1]
+	}
+	return strings.TrimSpace(stripEmphasis(line))
+}
+
+// stripEmphasis removes markdown bold and italic markers
+func stripEmphasis(text string) string {
+	return strings.NewReplacer("**", "", "__", "").Replace(text)
+}
+
+// splitInlineTasks splits a same-line task list on semicolons, or commas if there are none
+func splitInlineTasks(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	sep := ","
+	if strings.Contains(text, ";") {
+		sep = ";"
+	}
+
+	var tasks []string
+	for _, part := range strings.Split(text, sep) {
+		part = strings.TrimSpace(part)
+		part = strings.TrimPrefix(part, "and ")
+		if task := cleanTask(part); task != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// cleanTask trims whitespace and trailing punctuation from a task
+func cleanTask(task string) string {
+	return strings.TrimRight(strings.TrimSpace(task), ".;, ")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestExtractKeyTasks tests parsing task lists in the formats the model produces
+func TestExtractKeyTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  string
+		expected []string
+	}{
+		{
+			name:     "Comma separated",
+			summary:  "**Domain**: Go\n**Key Tasks**: Added a streaming filter, fixed line numbers, and updated tests.\n**Complexity**: Moderate",
+			expected: []string{"Added a streaming filter", "fixed line numbers", "updated tests"},
+		},
+		{
+			name:     "Semicolon separated",
+			summary:  "Key tasks accomplished: Parsed config, with defaults; wrote docs",
+			expected: []string{"Parsed config, with defaults", "wrote docs"},
+		},
+		{
+			name:     "Dash bullets",
+			summary:  "Main topic: React\n\nKey Tasks:\n- Built the sidebar\n- Added dark mode\n\nComplexity: Simple",
+			expected: []string{"Built the sidebar", "Added dark mode"},
+		},
+		{
+			name:     "Mixed bullet styles and bold label",
+			summary:  "## Key Tasks Accomplished\n* Refactored parser\n• Removed dead code\n+ **Benchmarked** the hot path.\nOutcome: faster parsing",
+			expected: []string{"Refactored parser", "Removed dead code", "Benchmarked the hot path"},
+		},
+		{
+			name:     "Numbered list",
+			summary:  "2. Key tasks accomplished:\n   1) Migrated schema\n   2) Backfilled data\n3. Important outcomes: done",
+			expected: []string{"Migrated schema", "Backfilled data"},
+		},
+		{
+			name:     "Label as sibling bullet",
+			summary:  "- **Domain**: Python\n- **Key Tasks**: Fixed retry wrapper\n- **Complexity**: Moderate",
+			expected: []string{"Fixed retry wrapper"},
+		},
+		{
+			name:     "Absent",
+			summary:  "Domain: Go backend development. Complexity: Simple.",
+			expected: nil,
+		},
+		{
+			name:     "Empty section",
+			summary:  "Key Tasks:\n\nComplexity: Simple",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractKeyTasks(tt.summary); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("extractKeyTasks() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAnalyzeKeyTasks tests that key tasks are surfaced in the analyze response
+func TestAnalyzeKeyTasks(t *testing.T) {
+	installFakeClaude(t, "Domain: Go backend development.\nKey Tasks:\n- Added a streaming filter\n- Wrote tests\nComplexity: Moderate.")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", "user: add streaming")), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	expected := []string{"Added a streaming filter", "Wrote tests"}
+	if !reflect.DeepEqual(response.KeyTasks, expected) {
+		t.Errorf("Expected key tasks %q, got %q", expected, response.KeyTasks)
+	}
+}
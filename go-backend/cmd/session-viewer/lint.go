@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxLintExamples is how many offending line numbers a LintIssue lists
+const maxLintExamples = 10
+
+// knownEntryTypes are the JSONL entry types written by Claude sessions
+var knownEntryTypes = map[string]bool{
+	"user":                  true,
+	"assistant":             true,
+	"system":                true,
+	"summary":               true,
+	"file-history-snapshot": true,
+	"queue-operation":       true,
+}
+
+// LintReport represents lint output: problems found in a session file, by kind
+type LintReport struct {
+	File    string `json:"file"`
+	Entries int    `json:"entries"` // Non-blank lines, or entries of a JSON document
+	Valid   bool   `json:"valid"`   // No problems of any kind
+
+	InvalidJSON          LintIssue      `json:"invalid_json"`
+	MissingType          LintIssue      `json:"missing_type"`
+	UnknownType          LintIssue      `json:"unknown_type"`
+	UnknownTypes         map[string]int `json:"unknown_types,omitempty"` // Entry count per unrecognized type
+	AssistantWithoutText LintIssue      `json:"assistant_without_text"`  // Assistant entries with no text content
+}
+
+// LintIssue counts the lines with one kind of problem
+type LintIssue struct {
+	Count int   `json:"count"`
+	Lines []int `json:"lines"` // The first maxLintExamples offending line numbers
+}
+
+// add records lineNum as offending
+func (i *LintIssue) add(lineNum int) {
+	i.Count++
+	if len(i.Lines) < maxLintExamples {
+		i.Lines = append(i.Lines, lineNum)
+	}
+}
+
+// handleLint checks that a JSONL session is well-formed before it is analyzed
+func handleLint() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer lint --file <path>")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:])
+	filePath := flags["file"]
+
+	if filePath == "" {
+		respondError("Missing file path")
+		return
+	}
+
+	report, err := lintSession(filePath)
+	if err != nil {
+		respondError(fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	respondJSON(report)
+}
+
+// lintSession reads a session with the filter command's decode loop, reporting every
+// malformed line instead of skipping it, along with entries the filter would silently
+// drop: ones without a type, of an unknown type, or assistant replies with no text
+func lintSession(filePath string) (*LintReport, error) {
+	file, err := openSession(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	report := &LintReport{
+		File:                 filePath,
+		InvalidJSON:          LintIssue{Lines: []int{}},
+		MissingType:          LintIssue{Lines: []int{}},
+		UnknownType:          LintIssue{Lines: []int{}},
+		AssistantWithoutText: LintIssue{Lines: []int{}},
+	}
+	err = scanJSONLEntries(context.Background(), file, func(lineNum int, line map[string]interface{}) error {
+		report.Entries++
+		entryType, ok := line["type"].(string)
+		switch {
+		case !ok || entryType == "":
+			report.MissingType.add(lineNum)
+		case !knownEntryTypes[entryType]:
+			report.UnknownType.add(lineNum)
+			if report.UnknownTypes == nil {
+				report.UnknownTypes = map[string]int{}
+			}
+			report.UnknownTypes[entryType]++
+		case entryType == "assistant" && !hasAssistantText(line):
+			report.AssistantWithoutText.add(lineNum)
+		}
+		return nil
+	}, func(lineErr *LineError) error {
+		report.Entries++
+		report.InvalidJSON.add(lineErr.Line)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Valid = report.InvalidJSON.Count == 0 && report.MissingType.Count == 0 &&
+		report.UnknownType.Count == 0 && report.AssistantWithoutText.Count == 0
+	return report, nil
+}
+
+// hasAssistantText reports whether an assistant entry carries non-blank text, either as
+// string content or in a text block
+func hasAssistantText(line map[string]interface{}) bool {
+	message, ok := line["message"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	switch content := message["content"].(type) {
+	case string:
+		return strings.TrimSpace(content) != ""
+	case []interface{}:
+		for _, block := range content {
+			blockMap, ok := block.(map[string]interface{})
+			if !ok || blockMap["type"] != "text" {
+				continue
+			}
+			if text, ok := blockMap["text"].(string); ok && strings.TrimSpace(text) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
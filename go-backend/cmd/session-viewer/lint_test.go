@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLintSession tests that each kind of problem is reported with its line numbers
+func TestLintSession(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", `{"type":"user","message":{"content":"Fix the parser"}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Done"}]}}
+not json
+
+{"message":{"content":"no type"}}
+{"type":"progress","data":{}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read","input":{}}]}}
+{"type":"assistant","message":{"content":"  "}}
+{"type":"summary","summary":"Earlier work"}
+`)
+
+	report, err := lintSession(path)
+	if err != nil {
+		t.Fatalf("lintSession failed: %v", err)
+	}
+	if report.Valid || report.Entries != 8 {
+		t.Errorf("Expected an invalid report of 8 entries, got %+v", report)
+	}
+
+	tests := []struct {
+		name  string
+		issue LintIssue
+		want  []int
+	}{
+		{"Invalid JSON", report.InvalidJSON, []int{3}},
+		{"Missing type", report.MissingType, []int{5}},
+		{"Unknown type", report.UnknownType, []int{6}},
+		{"Assistant without text", report.AssistantWithoutText, []int{7, 8}},
+	}
+	for _, tt := range tests {
+		if tt.issue.Count != len(tt.want) || !reflect.DeepEqual(tt.issue.Lines, tt.want) {
+			t.Errorf("%s: expected lines %v, got %+v", tt.name, tt.want, tt.issue)
+		}
+	}
+	if report.UnknownTypes["progress"] != 1 {
+		t.Errorf("Expected the unknown type to be named, got %v", report.UnknownTypes)
+	}
+}
+
+// TestLintSessionExampleLimit tests that counts stay exact past the listed line numbers
+func TestLintSessionExampleLimit(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", strings.Repeat("not json\n", maxLintExamples+5))
+
+	report, err := lintSession(path)
+	if err != nil {
+		t.Fatalf("lintSession failed: %v", err)
+	}
+	if report.InvalidJSON.Count != maxLintExamples+5 || len(report.InvalidJSON.Lines) != maxLintExamples {
+		t.Errorf("Expected %d invalid lines with %d listed, got %+v", maxLintExamples+5, maxLintExamples, report.InvalidJSON)
+	}
+}
+
+// TestLintCommand tests the lint command's output for a clean session
+func TestLintCommand(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", buildUserJSONL(3))
+
+	var report LintReport
+	output := runMain(t, "lint", "--file", path)
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	if !report.Valid || report.Entries != 3 || report.InvalidJSON.Lines == nil {
+		t.Errorf("Expected a valid report of 3 entries, got %+v", report)
+	}
+
+	if output := runMain(t, "lint", "--file", path+".missing"); !strings.Contains(output, `"error"`) {
+		t.Errorf("Expected an error for a missing file, got %s", output)
+	}
+	if output := runMain(t, "lint"); !strings.Contains(output, "Usage: session-viewer lint") {
+		t.Errorf("Expected usage, got %s", output)
+	}
+}
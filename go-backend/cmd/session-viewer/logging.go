@@ -0,0 +1,17 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+)
+
+// logWriter receives diagnostic log records; stderr keeps stdout clean JSON
+var logWriter io.Writer = os.Stderr
+
+// newLogger returns a logger that writes records at cfg.Log.Level and above as text
+func newLogger(cfg *config.Config) *slog.Logger {
+	return slog.New(slog.NewTextHandler(logWriter, &slog.HandlerOptions{Level: cfg.Log.Level}))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+)
+
+// TestNewLogger tests that records below the configured level are dropped
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	original := logWriter
+	logWriter = &buf
+	defer func() { logWriter = original }()
+
+	logger := newLogger(&config.Config{Log: config.LogConfig{Level: slog.LevelWarn}})
+	logger.Debug("Created temporary analysis directory", "dir", "/tmp/x")
+	logger.Warn("Could not clean up temporary analysis directory", "dir", "/tmp/y")
+
+	output := buf.String()
+	if strings.Contains(output, "/tmp/x") {
+		t.Errorf("Expected debug records to be dropped, got %q", output)
+	}
+	if !strings.Contains(output, "level=WARN") || !strings.Contains(output, "dir=/tmp/y") {
+		t.Errorf("Expected the warning, got %q", output)
+	}
+
+	buf.Reset()
+	newLogger(&config.Config{Log: config.LogConfig{Level: config.LogLevelOff}}).Error("failure")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output with logging off, got %q", buf.String())
+	}
+}
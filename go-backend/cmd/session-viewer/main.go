@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
 	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
-	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/claude"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/version"
 )
 
 // SessionAnalysisRequest represents a request to analyze a session
@@ -22,19 +24,44 @@ type SessionAnalysisRequest struct {
 
 // SessionAnalysisResponse represents the analysis result
 type SessionAnalysisResponse struct {
-	SessionID string `json:"session_id"`
-	Summary   string `json:"summary"`
-	Error     string `json:"error,omitempty"`
+	SessionID       string        `json:"session_id"`
+	Language        string        `json:"language,omitempty"` // Detected ISO 639-1 code of the session content
+	Summary         string        `json:"summary"`
+	KeyTasks        []string      `json:"key_tasks,omitempty"` // Tasks listed in the summary's "Key Tasks" section
+	Error           string        `json:"error,omitempty"`
+	RejectedReasons []string      `json:"rejected_reasons,omitempty"` // Why each retried attempt was rejected
+	Analysis        *llm.Analysis `json:"analysis,omitempty"`         // Structured analysis, with --both
+	AnalysisError   string        `json:"analysis_error,omitempty"`   // Why the structured analysis failed, with --both
+	Warnings        []string      `json:"warnings,omitempty"`         // Non-fatal issues found before sending, e.g. a very large prompt
+	File            string        `json:"file,omitempty"`             // Session file name, with --dir
+
+	Metadata *llm.AnalysisMetadata `json:"metadata,omitempty"` // Timing and size of the summary request
+	Loops    []llm.LoopInfo        `json:"loops,omitempty"`    // Runs where the same exchange repeats, found locally
+
+	Redactions *RedactionCounts `json:"redactions,omitempty"` // Secrets masked before sending, with --redact
+
+	// Summary prompts sent, 0 when served from the cache, and whether the strict retry
+	// prompt was among them
+	Attempts           int  `json:"attempts"`
+	UsedFallbackPrompt bool `json:"used_fallback_prompt"`
+
+	ErrorKind string `json:"error_kind,omitempty"` // Why Error happened, e.g. timeout or conversational; see classifyError
 }
 
 // FilteredMessage represents a simplified message for analysis
-type FilteredMessage struct {
-	Type      string `json:"type"`
-	Content   string `json:"content"`
-	Timestamp string `json:"timestamp"`
-}
+type FilteredMessage = llm.FilteredMessage
 
 func main() {
+	args, format, err := extractOutputFlag(os.Args)
+	outputFormat = format
+	// A profile selected by analyze --profile may still set the format when this is false
+	outputFlagGiven = len(args) != len(os.Args)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	os.Args = args
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		respondError(fmt.Sprintf("Failed to load configuration: %v", err))
@@ -47,12 +74,49 @@ func main() {
 	}
 
 	command := os.Args[1]
+	if command != "cleanup" {
+		cleanupOrphansAtStartup(cfg)
+	}
 
 	switch command {
 	case "analyze":
 		handleAnalyze(cfg)
+	case "ask":
+		handleAsk(cfg)
+	case "cache":
+		handleCache(cfg)
+	case "cleanup":
+		handleCleanup(cfg)
+	case "config":
+		handleConfig(cfg)
+	case "diff":
+		handleDiff()
+	case "doctor":
+		handleDoctor(cfg)
 	case "filter":
 		handleFilter()
+	case "filter-analysis":
+		handleFilterAnalysis()
+	case "fingerprint":
+		handleFingerprint()
+	case "format":
+		handleFormat()
+	case "first-ask":
+		handleFirstAsk(cfg)
+	case "insights":
+		handleInsights()
+	case "lint":
+		handleLint()
+	case "search":
+		handleSearch()
+	case "serve":
+		handleServe(cfg)
+	case "stats":
+		handleStats()
+	case "validate":
+		handleValidate()
+	case "version":
+		respondJSON(version.Get())
 	case "help":
 		printUsage()
 	default:
@@ -62,235 +126,136 @@ func main() {
 
 func printUsage() {
 	usage := map[string]interface{}{
-		"usage": "session-viewer <command> [options]",
+		"usage": "session-viewer <command> [options] [--output json|pretty|text|compact]",
 		"commands": map[string]string{
-			"analyze": "analyze --session-id <id> --content <content>  - Analyze session content",
-			"filter":  "filter --file <path>                           - Filter JSONL file",
-			"help":    "help                                          - Show this help",
+			"analyze":         "analyze --session-id <id> --content <content> | --file <path> | --dir <path> [--concurrency <n>] [--limit <n>] [--start-line <n>] [--end-line <n>] [--redact-paths] [--redact] [--profile <name>] [--model <name>] [--max-retries <n>] [--reject-on-exhaust] [--both] [--tier 1|2|3] [--output-language auto|<code>] [--sort-episodes line|confidence|time] [--max-chars <n>] [--truncate head|tail|middle] [--min-episodes <n>] [--min-confidence <0-1>] [--cache] [--progress] [--dry-run] - Analyze session content",
+			"ask":             "ask --prompt <text> [--session-id <id>] [--model <name>] - Send a follow-up prompt into a Claude session; without an ID a new session is started and its ID returned",
+			"cache":           "cache list | cache clear [--older-than <duration>] - List or remove cached model responses",
+			"cleanup":         "cleanup [--older-than <duration>] - Remove temp analysis directories left behind by interrupted runs",
+			"config":          "config - Show the effective configuration and where each value came from",
+			"diff":            "diff --old <analysis.json> --new <analysis.json> - Compare two analyses of the same session",
+			"doctor":          "doctor [--timeout <duration>] - Check that the backend is configured, the analysis directory is writable, and a test prompt gets a reply",
+			"filter":          "filter --file <path> [--limit <n>] [--start-line <n>] [--end-line <n>] [--role user|assistant|all] [--include-tools] [--include-system] [--stream] [--strict] [--sort-by-time] [--timestamp-layout <layout>] [--since <time|-duration>] [--until <time|-duration>] [--require-timestamp] [--anonymize] - Filter JSONL file (optionally gzipped)",
+			"filter-analysis": "filter-analysis --input <analysis.json> --min-confidence <0-1> - Drop low-confidence episodes from a saved analysis",
+			"fingerprint":     "fingerprint --file <path> - Compute a stable content hash of a session",
+			"format":          "format --input <analysis.json> [--as markdown|html|csv] - Render a saved analysis as a report or episode CSV",
+			"first-ask":       "first-ask --file <path> [--restate] - Show the session's first user message",
+			"insights":        "insights --input <analysis.json> - Total time, episode count, and average confidence per phase of a saved analysis",
+			"lint":            "lint --file <path> - Report malformed JSON lines, entries without a known type, and assistant entries without text",
+			"search":          "search --file <path> --pattern <regex> [--ignore-case] [--context <n>] [--include-tools] [--include-system] - Find messages matching a regular expression, with highlighted snippets",
+			"serve":           "serve [--addr <host:port>] [--max-concurrent <n>] - Serve POST /analyze, POST /filter, /healthz, and /metrics over HTTP until interrupted; listens on 127.0.0.1:8080 by default, use --addr :8080 to accept connections from other machines",
+			"stats":           "stats --file <path> [--timestamp-layout <layout>] [--anonymize] - Count messages, characters, and estimated tokens without calling Claude",
+			"validate":        "validate --input <path> [--only-invalid] [--min-episodes <n>] [--strict] - Check a model reply or analysis JSON against the analysis schema",
+			"version":         "version - Show build version, commit, and date",
+			"help":            "help - Show this help",
 		},
 	}
 	respondJSON(usage)
 }
 
-// handleAnalyze processes session analysis using Claude Haiku
-func handleAnalyze(cfg *config.Config) {
-	if len(os.Args) < 4 {
-		respondError("Usage: session-viewer analyze --session-id <id> --content <content>")
+// handleFilter filters a JSONL file to extract user/assistant content, and optionally
+// tool and system entries
+func handleFilter() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer filter --file <path> [--limit <n>] [--start-line <n>] [--end-line <n>] [--role user|assistant|all] [--include-tools] [--include-system] [--stream] [--strict] [--sort-by-time] [--timestamp-layout <layout>] [--since <time|-duration>] [--until <time|-duration>] [--require-timestamp] [--anonymize]")
 		return
 	}
 
-	// Parse arguments (simplified - in real implementation would use proper flag parsing)
-	var sessionID, content string
-	for i := 2; i < len(os.Args); i += 2 {
-		if i+1 >= len(os.Args) {
-			break
-		}
-		switch os.Args[i] {
-		case "--session-id":
-			sessionID = os.Args[i+1]
-		case "--content":
-			content = os.Args[i+1]
-		}
-	}
+	flags := parseFlags(os.Args[2:], "include-tools", "include-system", "stream", "strict", "sort-by-time", "require-timestamp", "anonymize")
+	filePath := flags["file"]
 
-	if sessionID == "" || content == "" {
-		respondError("Missing required arguments")
+	if filePath == "" {
+		respondError("Missing file path")
 		return
 	}
 
-	claudeWrapper := claude.NewWrapper(cfg)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	// Retry mechanism: try up to 3 times with increasingly explicit prompts
-	const maxRetries = 3
-	var summary string
-	var err error
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		// Build analysis prompt with increasing explicitness on retries
-		var prompt string
-		if attempt == 1 {
-			// Initial attempt: standard prompt
-			prompt = `Analyze this Claude conversation and provide a concise summary:
-
-1. Main topic/domain (e.g., "React development", "Python scripting")
-2. Key tasks accomplished
-3. Important outcomes or decisions
-4. Session complexity (Simple/Moderate/Complex)
-
-Keep it under 150 words. Focus only on the actual conversation content between user and assistant.
-
-Conversation data:
-` + content
-		} else {
-			// Retry attempts: strict prompt with system/role/few-shot techniques
-			prompt = `SYSTEM: You are a professional conversation analyst. Your role is to provide objective, third-person analysis of completed conversations.
-
-CRITICAL RULES:
-1. Write ONLY in third person (never use "I", "we", "you")
-2. Provide ANALYTICAL SUMMARY (not conversational responses)
-3. Do NOT engage, validate, question, or provide advice
-4. Do NOT start with exclamations, agreements, or disagreements (no "!", "No!", "Yes!", "You're right")
-
-EXAMPLE - WRONG (Conversational):
-"No! We're not removing that functionality. Let me explain the fix..."
-"You're absolutely right! I made an error. Here's what we should do..."
-
-EXAMPLE - CORRECT (Analytical):
-"**Domain**: Python backend development
-**Main Topic**: Debugging structured output retry wrapper implementation
-**Key Tasks**: Resolved schema initialization issue in criterion analysis wrapper
-**Complexity**: Moderate"
-
-YOUR TASK: Analyze the conversation below and provide a structured summary with:
-- Main topic/domain
-- Key tasks accomplished
-- Important outcomes
-- Complexity level (Simple/Moderate/Complex)
-
-Write objectively in third person. Maximum 150 words.
-
-Conversation:
-` + content
-		}
-
-		summary, err = claudeWrapper.SendConversationalPrompt(ctx, prompt, "")
-
-		if err != nil {
-			// Network/API error - no point retrying
-			break
-		}
-
-		// Check if response is an error message instead of a summary
-		isError := isErrorResponse(summary)
-
-		if !isError {
-			// Valid summary received
-			break
-		}
-
-		// Invalid response detected, retry unless this was the last attempt
-		if attempt < maxRetries {
-			continue
-		}
-	}
-
+	limit, err := parseIntFlag(flags, "limit", defaultMessageLimit)
 	if err != nil {
-		response := SessionAnalysisResponse{
-			SessionID: sessionID,
-			Summary:   "Analysis failed - " + err.Error(),
-			Error:     err.Error(),
-		}
-		respondJSON(response)
+		respondError(err.Error())
 		return
 	}
 
-	response := SessionAnalysisResponse{
-		SessionID: sessionID,
-		Summary:   summary,
-	}
-
-	respondJSON(response)
-}
-
-// handleFilter filters a JSONL file to extract only user/assistant content
-func handleFilter() {
-	if len(os.Args) < 3 {
-		respondError("Usage: session-viewer filter --file <path>")
+	startLine, endLine, err := parseLineRange(flags)
+	if err != nil {
+		respondError(err.Error())
 		return
 	}
 
-	var filePath string
-	for i := 2; i < len(os.Args); i += 2 {
-		if i+1 >= len(os.Args) {
-			break
-		}
-		if os.Args[i] == "--file" {
-			filePath = os.Args[i+1]
-		}
-	}
-
-	if filePath == "" {
-		respondError("Missing file path")
+	role, err := parseRoleFlag(flags)
+	if err != nil {
+		respondError(err.Error())
 		return
 	}
 
-	messages, err := filterJSONLFile(filePath)
+	since, until, err := parseTimeRange(flags, time.Now())
 	if err != nil {
-		respondError(fmt.Sprintf("Error filtering file: %v", err))
+		respondError(err.Error())
 		return
 	}
 
-	respondJSON(messages)
-}
+	opts := FilterOptions{
+		IncludeTools:  flags["include-tools"] != "",
+		IncludeSystem: flags["include-system"] != "",
+		Strict:        flags["strict"] != "",
+		StartLine:     startLine,
+		EndLine:       endLine,
+		SortByTime:    flags["sort-by-time"] != "",
+		Role:          role,
 
-// filterJSONLFile reads a JSONL file and extracts only user/assistant messages
-func filterJSONLFile(filePath string) ([]FilteredMessage, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
+		TimestampLayout: flags["timestamp-layout"],
+
+		Since:            since,
+		Until:            until,
+		RequireTimestamp: flags["require-timestamp"] != "",
+	}
+	if flags["anonymize"] != "" {
+		opts.Anonymizer = NewAnonymizer()
 	}
-	defer file.Close()
 
-	var messages []FilteredMessage
-	decoder := json.NewDecoder(file)
+	// Ctrl-C stops reading a large session promptly instead of waiting for the whole file
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	for decoder.More() {
-		var line map[string]interface{}
-		if err := decoder.Decode(&line); err != nil {
-			continue // Skip invalid JSON lines
+	if flags["stream"] != "" {
+		// Streaming writes messages as they are read, so they can't be reordered
+		if opts.SortByTime {
+			respondError("--sort-by-time can't be combined with --stream")
+			return
 		}
 
-		msgType, ok := line["type"].(string)
-		if !ok {
-			continue
+		file, err := openSession(filePath)
+		if err != nil {
+			respondError(fmt.Sprintf("Error filtering file: %v", err))
+			return
 		}
+		defer file.Close()
 
-		timestamp, _ := line["timestamp"].(string)
-
-		if msgType == "user" {
-			if message, ok := line["message"].(map[string]interface{}); ok {
-				if content, ok := message["content"].(string); ok {
-					messages = append(messages, FilteredMessage{
-						Type:      "user",
-						Content:   content,
-						Timestamp: timestamp,
-					})
-				}
-			}
-		} else if msgType == "assistant" {
-			if message, ok := line["message"].(map[string]interface{}); ok {
-				if contentArray, ok := message["content"].([]interface{}); ok {
-					var textBlocks []string
-					for _, block := range contentArray {
-						if blockMap, ok := block.(map[string]interface{}); ok {
-							if blockType, ok := blockMap["type"].(string); ok && blockType == "text" {
-								if text, ok := blockMap["text"].(string); ok {
-									textBlocks = append(textBlocks, text)
-								}
-							}
-						}
-					}
-					if len(textBlocks) > 0 {
-						messages = append(messages, FilteredMessage{
-							Type:      "assistant",
-							Content:   joinStrings(textBlocks, "\n"),
-							Timestamp: timestamp,
-						})
-					}
-				}
-			}
+		skipped, err := filterJSONLStream(ctx, file, os.Stdout, limit, opts)
+		if err != nil {
+			respondError(fmt.Sprintf("Error filtering file: %v", err))
+			return
+		}
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %d malformed line(s)\n", skipped)
 		}
+		if opts.Anonymizer != nil {
+			summary := opts.Anonymizer.Summary()
+			fmt.Fprintf(os.Stderr, "Anonymized %d occurrence(s): %d user(s), %d author(s), %d host(s)\n", summary.Replacements,
+				summary.Pseudonyms[pseudonymUser], summary.Pseudonyms[pseudonymAuthor], summary.Pseudonyms[pseudonymHost])
+		}
+		return
 	}
 
-	// Return only the last 20 messages (most recent)
-	if len(messages) > 20 {
-		messages = messages[len(messages)-20:]
+	result, err := filterJSONLFile(ctx, filePath, limit, opts)
+	if err != nil {
+		respondError(fmt.Sprintf("Error filtering file: %v", err))
+		return
+	}
+	if result.UntimedMessages > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d message(s) without a parseable timestamp sorted last\n", result.UntimedMessages)
 	}
 
-	return messages, nil
+	respondJSON(result)
 }
 
 // simulateAnalysis provides a mock analysis for demonstration
@@ -339,9 +304,23 @@ func joinStrings(strs []string, sep string) string {
 	return result
 }
 
-// respondJSON outputs JSON response
+// respondJSON outputs a response in the format selected with --output
 func respondJSON(data interface{}) {
-	jsonData, err := json.Marshal(data)
+	if outputFormat == outputText {
+		fmt.Print(renderText(data))
+		return
+	}
+
+	var jsonData []byte
+	var err error
+	if outputFormat == outputPretty {
+		jsonData, err = json.MarshalIndent(data, "", "  ")
+	} else {
+		jsonData, err = json.Marshal(data)
+	}
+	if err == nil && outputFormat == outputCompact {
+		jsonData, err = compactJSON(jsonData)
+	}
 	if err != nil {
 		respondError(fmt.Sprintf("JSON encoding error: %v", err))
 		return
@@ -356,102 +335,3 @@ func respondError(message string) {
 	}
 	respondJSON(response)
 }
-
-// isErrorResponse checks if Claude's response is an out-of-scope error message
-// instead of a proper analysis summary
-func isErrorResponse(response string) bool {
-	responseLower := strings.ToLower(response)
-
-	// Very short responses are likely errors
-	if len(strings.TrimSpace(response)) < 50 {
-		return true
-	}
-
-	// Check for limitation/error phrases
-	errorPhrases := []string{
-		"i've hit a technical limitation",
-		"i can't access",
-		"i cannot access",
-		"i don't have access",
-		"i'm unable to access",
-		"technical limitation",
-		"i need you to",
-		"please run",
-		"please share",
-		"let me ",              // AI offering to do something (e.g., "Let me revert my changes")
-		"i'll ",                // AI committing to action
-		"i will ",              // AI committing to action
-		"the fix should",       // AI providing implementation advice instead of analysis
-		"you should",           // AI giving instructions instead of analyzing
-		"you need to",          // AI giving instructions
-		"you're right",         // AI validating user in conversation (e.g., "You're absolutely right!")
-		"you're absolutely",    // AI giving strong validation
-		"you're correct",       // AI agreeing with user
-		"i made a",             // AI admitting errors in active conversation
-		"i apologize for",      // AI apologizing for mistakes
-		"should i ",            // AI asking for permission/direction
-		"shall i ",             // AI asking for direction
-	}
-
-	for _, phrase := range errorPhrases {
-		if strings.Contains(responseLower, phrase) {
-			return true
-		}
-	}
-
-	// Check if response starts with action-oriented or conversational phrases (first 100 chars)
-	responseStart := responseLower
-	if len(responseStart) > 100 {
-		responseStart = responseLower[:100]
-	}
-	actionStarts := []string{
-		"here's the",
-		"here is the",
-		"i've created",
-		"i've updated",
-		"i've implemented",
-		"no!",            // Conversational disagreement (e.g., "No! We're **not** removing...")
-		"yes!",           // Conversational agreement
-		"we're not",      // Conversational discussion about code
-		"we're ",         // General conversational "we"
-	}
-	for _, phrase := range actionStarts {
-		if strings.HasPrefix(responseStart, phrase) {
-			return true
-		}
-	}
-
-	// Check for exclamation marks in first sentence (very conversational)
-	firstSentence := responseStart
-	if dotPos := strings.Index(responseStart, "."); dotPos > 0 && dotPos < 100 {
-		firstSentence = responseStart[:dotPos]
-	}
-	if strings.Contains(firstSentence, "!") {
-		return true
-	}
-
-	// Check for questions directed at user
-	questionPhrases := []string{
-		"can you either:",
-		"can you ",
-		"could you ",
-		"would you ",
-		"can you please",
-	}
-
-	for _, phrase := range questionPhrases {
-		if strings.Contains(responseLower, phrase) {
-			return true
-		}
-	}
-
-	// Check for code blocks suggesting commands to run
-	if strings.Contains(response, "```bash") ||
-	   strings.Contains(response, "```sh") ||
-	   (strings.Contains(response, "```") && strings.Contains(responseLower, "cd /")) {
-		return true
-	}
-
-	// Valid summary received
-	return false
-}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Output formats selectable with the global --output flag
+const (
+	outputJSON    = "json"    // Single-line JSON (default, consumed by the frontend)
+	outputPretty  = "pretty"  // Indented JSON
+	outputText    = "text"    // Human-readable layout
+	outputCompact = "compact" // Single-line JSON without zero-valued fields
+)
+
+// outputFormat is the format respondJSON writes in
+var outputFormat = outputJSON
+
+// outputFlagGiven records whether --output was passed, so it wins over a profile's format
+var outputFlagGiven bool
+
+// extractOutputFlag removes a global "--output <format>" or "--output=<format>" from
+// args so subcommands never see it, and returns the remaining args and the format
+func extractOutputFlag(args []string) ([]string, string, error) {
+	format := outputJSON
+	remaining := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--output" && i+1 < len(args):
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--output="):
+			format = strings.TrimPrefix(args[i], "--output=")
+		default:
+			remaining = append(remaining, args[i])
+		}
+	}
+
+	if err := checkOutputFormat(format); err != nil {
+		return remaining, outputJSON, err
+	}
+	return remaining, format, nil
+}
+
+// checkOutputFormat reports an output format that isn't one of the known ones
+func checkOutputFormat(format string) error {
+	switch format {
+	case outputJSON, outputPretty, outputText, outputCompact:
+		return nil
+	}
+	return fmt.Errorf("Invalid output: %s (expected json, pretty, text, or compact)", format)
+}
+
+// applyProfileOutput switches respondJSON to a profile's output format, unless --output
+// chose one. An empty format keeps the current one.
+func applyProfileOutput(format string) error {
+	if format == "" || outputFlagGiven {
+		return nil
+	}
+	if err := checkOutputFormat(format); err != nil {
+		return fmt.Errorf("Invalid profile output: %s (expected json, pretty, text, or compact)", format)
+	}
+	outputFormat = format
+	return nil
+}
+
+// renderText lays out command results for reading in a terminal. Analysis responses,
+// filtered messages, phase insights, and generic maps get dedicated layouts; anything
+// else falls back to indented JSON.
+func renderText(data interface{}) string {
+	var b strings.Builder
+	switch v := data.(type) {
+	case SessionAnalysisResponse:
+		writeAnalysisText(&b, v)
+	case []SessionAnalysisResponse:
+		for i, response := range v {
+			if i > 0 {
+				b.WriteString("\n---\n\n")
+			}
+			writeAnalysisText(&b, response)
+		}
+	case *FilterResult:
+		writeFilterText(&b, v)
+	case InsightsResponse:
+		writeInsightsText(&b, v)
+	case map[string]interface{}:
+		writeMapText(&b, v, "")
+	default:
+		jsonData, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return fmt.Sprintf("Error: JSON encoding error: %v\n", err)
+		}
+		b.Write(jsonData)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeAnalysisText renders an analyze response
+func writeAnalysisText(b *strings.Builder, r SessionAnalysisResponse) {
+	fmt.Fprintf(b, "Session: %s\n", r.SessionID)
+	if r.File != "" {
+		fmt.Fprintf(b, "File: %s\n", r.File)
+	}
+	if r.Language != "" {
+		fmt.Fprintf(b, "Language: %s\n", r.Language)
+	}
+	if r.Redactions != nil {
+		fmt.Fprintf(b, "Redactions: %d\n", r.Redactions.Total)
+	}
+	if r.UsedFallbackPrompt {
+		fmt.Fprintf(b, "Attempts: %d (strict retry prompt used)\n", r.Attempts)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(b, "Error: %s\n", r.Error)
+	}
+	if r.ErrorKind != "" {
+		fmt.Fprintf(b, "Error kind: %s\n", r.ErrorKind)
+	}
+	fmt.Fprintf(b, "\n%s\n", strings.TrimSpace(r.Summary))
+
+	writeTextList(b, "Key tasks", r.KeyTasks)
+	writeTextList(b, "Warnings", r.Warnings)
+	writeTextList(b, "Rejected attempts", r.RejectedReasons)
+
+	if len(r.Loops) > 0 {
+		fmt.Fprintf(b, "\nLoops:\n")
+		for _, loop := range r.Loops {
+			fmt.Fprintf(b, "  [messages %d-%d] %d x %d message(s): %s\n", loop.StartIndex, loop.EndIndex, loop.Repetitions, loop.Period, loop.Excerpt)
+		}
+	}
+
+	if r.Analysis != nil {
+		fmt.Fprintf(b, "\nEpisodes:\n")
+		for _, ep := range r.Analysis.Episodes {
+			fmt.Fprintf(b, "  [%d-%d] %s (%.2f): %s\n", ep.StartLine, ep.EndLine, ep.Phase, ep.Confidence, ep.Description)
+		}
+	}
+	if r.AnalysisError != "" {
+		fmt.Fprintf(b, "\nStructured analysis failed: %s\n", r.AnalysisError)
+	}
+}
+
+// writeFilterText renders filtered messages one block per message
+func writeFilterText(b *strings.Builder, r *FilterResult) {
+	for i, msg := range r.Messages {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		header := msg.Type
+		if msg.Line > 0 {
+			header = fmt.Sprintf("[line %d] %s", msg.Line, header)
+		}
+		if msg.Timestamp != "" {
+			header += " (" + msg.Timestamp + ")"
+		}
+		fmt.Fprintf(b, "%s:\n%s\n", header, indentText(strings.TrimSpace(msg.Content), "  "))
+	}
+	if r.SkippedLines > 0 {
+		fmt.Fprintf(b, "\n(skipped %d malformed line(s))\n", r.SkippedLines)
+	}
+}
+
+// writeMapText renders a map as sorted "key: value" lines, nesting sub-maps
+func writeMapText(b *strings.Builder, m map[string]interface{}, indent string) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		label := key
+		if indent == "" && key == "error" {
+			label = "Error"
+		}
+		switch value := m[key].(type) {
+		case map[string]interface{}:
+			fmt.Fprintf(b, "%s%s:\n", indent, label)
+			writeMapText(b, value, indent+"  ")
+		case map[string]string:
+			nested := make(map[string]interface{}, len(value))
+			for k, v := range value {
+				nested[k] = v
+			}
+			fmt.Fprintf(b, "%s%s:\n", indent, label)
+			writeMapText(b, nested, indent+"  ")
+		default:
+			fmt.Fprintf(b, "%s%s: %v\n", indent, label, value)
+		}
+	}
+}
+
+// writeTextList renders a titled bullet list, or nothing if items is empty
+func writeTextList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "  - %s\n", item)
+	}
+}
+
+// indentText prefixes every line of text with indent
+func indentText(text, indent string) string {
+	return indent + strings.ReplaceAll(text, "\n", "\n"+indent)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runMainWithOutput runs main and restores the default output format afterwards
+func runMainWithOutput(t *testing.T, args ...string) string {
+	t.Helper()
+	t.Cleanup(func() { outputFormat = outputJSON })
+	return runMain(t, args...)
+}
+
+// TestExtractOutputFlag tests removal of the global --output flag
+func TestExtractOutputFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantArgs []string
+		want     string
+		wantErr  bool
+	}{
+		{"Default", []string{"sv", "version"}, []string{"sv", "version"}, outputJSON, false},
+		{"Separate value", []string{"sv", "--output", "pretty", "version"}, []string{"sv", "version"}, outputPretty, false},
+		{"Equals form", []string{"sv", "filter", "--file", "a", "--output=text"}, []string{"sv", "filter", "--file", "a"}, outputText, false},
+		{"Unknown format", []string{"sv", "version", "--output", "yaml"}, []string{"sv", "version"}, outputJSON, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, format, err := extractOutputFlag(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractOutputFlag(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if format != tt.want {
+				t.Errorf("format = %q, want %q", format, tt.want)
+			}
+			if strings.Join(args, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// TestOutputPretty tests that --output pretty indents the JSON response
+func TestOutputPretty(t *testing.T) {
+	output := runMainWithOutput(t, "version", "--output", "pretty")
+
+	if !strings.Contains(output, "\n  \"") {
+		t.Errorf("Expected indented JSON, got: %s", output)
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Pretty output is not valid JSON: %v", err)
+	}
+}
+
+// TestOutputDefaultIsCompact tests that responses stay on one line without --output
+func TestOutputDefaultIsCompact(t *testing.T) {
+	output := runMainWithOutput(t, "version")
+
+	if strings.Count(strings.TrimSpace(output), "\n") != 0 {
+		t.Errorf("Expected single-line JSON, got: %s", output)
+	}
+}
+
+// TestOutputInvalid tests that an unknown format is reported as a JSON error
+func TestOutputInvalid(t *testing.T) {
+	output := runMainWithOutput(t, "version", "--output=yaml")
+
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Expected JSON error, got: %s", output)
+	}
+	if parsed["error"] != "Invalid output: yaml (expected json, pretty, text, or compact)" {
+		t.Errorf("Unexpected error: %q", parsed["error"])
+	}
+}
+
+// TestOutputTextFilter tests the human-readable layout of filtered messages
+func TestOutputTextFilter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.jsonl")
+	content := `{"type":"user","message":{"content":"Fix the bug"},"timestamp":"2025-01-01T00:00:00Z"}
+not json
+{"type":"assistant","message":{"content":[{"type":"text","text":"Done.\nAll tests pass."}]}}
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := runMainWithOutput(t, "filter", "--file", file, "--output", "text")
+
+	expected := "[line 1] user (2025-01-01T00:00:00Z):\n  Fix the bug\n\n" +
+		"[line 3] assistant:\n  Done.\n  All tests pass.\n\n" +
+		"(skipped 1 malformed line(s))\n"
+	if output != expected {
+		t.Errorf("Text output mismatch\ngot:\n%s\nwant:\n%s", output, expected)
+	}
+}
+
+// TestOutputTextError tests that errors render as a plain message in text mode
+func TestOutputTextError(t *testing.T) {
+	output := runMainWithOutput(t, "--output", "text", "unknown")
+
+	if output != "Error: Unknown command: unknown\n" {
+		t.Errorf("Unexpected text error output: %q", output)
+	}
+}
+
+// TestRenderTextAnalysis tests the human-readable layout of an analyze response
+func TestRenderTextAnalysis(t *testing.T) {
+	output := renderText(SessionAnalysisResponse{
+		SessionID: "abc",
+		Language:  "en",
+		Summary:   "**Main Topic**: Refactoring\n",
+		KeyTasks:  []string{"Split the parser", "Add tests"},
+		Warnings:  []string{"large prompt"},
+	})
+
+	for _, want := range []string{
+		"Session: abc\n",
+		"Language: en\n",
+		"\n**Main Topic**: Refactoring\n",
+		"\nKey tasks:\n  - Split the parser\n  - Add tests\n",
+		"\nWarnings:\n  - large prompt\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Rejected attempts") {
+		t.Errorf("Empty sections should be omitted:\n%s", output)
+	}
+}
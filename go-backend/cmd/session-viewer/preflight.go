@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// elidedMarker replaces the content removed by --truncate
+const elidedMarker = "[...elided...]"
+
+// truncateStrategies are the --truncate values: the part of oversized content kept
+var truncateStrategies = map[string]string{
+	"head":   "beginning",
+	"tail":   "end",
+	"middle": "beginning and end",
+}
+
+// largePromptTokens is the estimated prompt size above which analyze warns that the
+// model may truncate or lose detail
+const largePromptTokens = 20000
+
+// preflightWarnings returns warnings about content before it is sent to the model
+func preflightWarnings(content string) []string {
+	var warnings []string
+	if tokens := llm.EstimateTokens(content); tokens > largePromptTokens {
+		warnings = append(warnings, fmt.Sprintf(
+			"Prompt is large (~%d estimated tokens, warning threshold %d); consider trimming with --max-chars or analyzing part of the session with --start-line/--end-line",
+			tokens, largePromptTokens))
+	}
+	return warnings
+}
+
+// checkInputBudget returns an error naming both counts when content of the estimated
+// tokens would exceed maxTokens (CLAUDE_MAX_INPUT_TOKENS), since the model would
+// silently truncate it or fail. A maxTokens of 0 disables the check.
+func checkInputBudget(tokens, maxTokens int) error {
+	if maxTokens <= 0 || tokens <= maxTokens {
+		return nil
+	}
+	return fmt.Errorf(
+		"Content is too large (~%d estimated tokens, limit %d set by CLAUDE_MAX_INPUT_TOKENS); analyze part of the session with --start-line/--end-line or --limit, trim it with --max-chars or --truncate, or raise the limit",
+		tokens, maxTokens)
+}
+
+// trimToMaxChars keeps at most maxChars characters (runes) from the end of content, the
+// most recent part of the conversation. The cut is moved forward to the next line start
+// so no line is split. A maxChars of 0 disables trimming.
+func trimToMaxChars(content string, maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(content) <= maxChars {
+		return content
+	}
+	// Step back maxChars runes from the end, so the cut is always on a rune boundary
+	cut := len(content)
+	for i := 0; i < maxChars; i++ {
+		_, size := utf8.DecodeLastRuneInString(content[:cut])
+		cut -= size
+	}
+	if content[cut-1] == '\n' {
+		return content[cut:]
+	}
+	trimmed := content[cut:]
+	if i := strings.IndexByte(trimmed, '\n'); i >= 0 && i < len(trimmed)-1 {
+		trimmed = trimmed[i+1:]
+	}
+	return trimmed
+}
+
+// validateTruncate checks a --truncate value; empty means oversized content is refused
+func validateTruncate(strategy string) error {
+	if _, ok := truncateStrategies[strategy]; strategy == "" || ok {
+		return nil
+	}
+	return fmt.Errorf("Invalid truncate: %s (expected head, tail, or middle)", strategy)
+}
+
+// truncateToTokens cuts content whose estimate exceeds maxTokens down to fit, keeping
+// its head, its tail, or half the budget from each end (middle), with elidedMarker in
+// place of the removed part. Cuts fall on line starts where the kept part has a line
+// break. Content within the budget, or a maxTokens of 0, is returned unchanged with a
+// nil TruncationInfo.
+func truncateToTokens(content string, maxTokens int, strategy string) (string, *llm.TruncationInfo) {
+	original := llm.EstimateTokens(content)
+	if maxTokens <= 0 || original <= maxTokens {
+		return content, nil
+	}
+
+	// Estimates aren't exactly additive, so the budget shrinks until the result fits
+	budget := maxTokens - llm.EstimateTokens("\n"+elidedMarker+"\n")
+	for {
+		truncated, elided := elide(content, max(0, budget), strategy)
+		excess := llm.EstimateTokens(truncated) - maxTokens
+		if excess <= 0 || budget <= 0 {
+			return truncated, &llm.TruncationInfo{Strategy: strategy, OriginalTokens: original, ElidedChars: elided}
+		}
+		budget -= excess
+	}
+}
+
+// truncationWarning describes a cut made by truncateToTokens
+func truncationWarning(info *llm.TruncationInfo, maxTokens int) string {
+	return fmt.Sprintf(
+		"Content truncated to fit CLAUDE_MAX_INPUT_TOKENS (%d): kept the %s of ~%d estimated tokens, %d characters elided; the summary covers only that part",
+		maxTokens, truncateStrategies[info.Strategy], info.OriginalTokens, info.ElidedChars)
+}
+
+// elide keeps about budget tokens of content as selected by strategy and returns the
+// result with elidedMarker between the kept parts, and how many bytes were removed
+func elide(content string, budget int, strategy string) (string, int) {
+	var head, tail string
+	switch strategy {
+	case "head":
+		head = content[:tokenPrefix(content, budget)]
+	case "tail":
+		tail = content[tokenSuffix(content, budget):]
+	case "middle":
+		head = content[:tokenPrefix(content, budget/2)]
+		tail = content[max(len(head), tokenSuffix(content, budget-llm.EstimateTokens(head))):]
+	}
+
+	parts := []string{elidedMarker}
+	if head != "" {
+		parts = append([]string{strings.TrimRight(head, "\n")}, parts...)
+	}
+	if tail != "" {
+		parts = append(parts, tail)
+	}
+	return strings.Join(parts, "\n"), len(content) - len(head) - len(tail)
+}
+
+// tokenPrefix returns the length of the longest prefix of content estimated at no more
+// than budget tokens, shortened to end at a line break when it contains one
+func tokenPrefix(content string, budget int) int {
+	n := sort.Search(len(content)+1, func(i int) bool {
+		return llm.EstimateTokens(content[:i]) > budget
+	}) - 1
+	for n > 0 && n < len(content) && !utf8.RuneStart(content[n]) {
+		n--
+	}
+	if i := strings.LastIndexByte(content[:n], '\n'); i > 0 {
+		n = i + 1
+	}
+	return n
+}
+
+// tokenSuffix returns the start of the longest suffix of content estimated at no more
+// than budget tokens, moved forward to a line start when the suffix has a later one
+func tokenSuffix(content string, budget int) int {
+	s := sort.Search(len(content)+1, func(i int) bool {
+		return llm.EstimateTokens(content[i:]) <= budget
+	})
+	for s < len(content) && !utf8.RuneStart(content[s]) {
+		s++
+	}
+	if s > 0 && content[s-1] != '\n' {
+		if i := strings.IndexByte(content[s:], '\n'); i >= 0 && s+i < len(content)-1 {
+			s += i + 1
+		}
+	}
+	return s
+}
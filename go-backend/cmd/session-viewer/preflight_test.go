@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestAnalyzePreflightWarning tests the large prompt warning
+func TestAnalyzePreflightWarning(t *testing.T) {
+	t.Run("Large content", func(t *testing.T) {
+		installFakeClaude(t, validSummary)
+		content := strings.Repeat("user: please refactor the parser module\n", 2500)
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", content)), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if len(response.Warnings) != 1 {
+			t.Fatalf("Expected one warning, got %v", response.Warnings)
+		}
+		warning := response.Warnings[0]
+		if !strings.Contains(warning, "~23125 estimated tokens") || !strings.Contains(warning, "--max-chars") {
+			t.Errorf("Expected token estimate and guidance, got %q", warning)
+		}
+		if response.Summary != validSummary {
+			t.Errorf("Expected analysis to proceed, got %q", response.Summary)
+		}
+	})
+
+	t.Run("Small content", func(t *testing.T) {
+		installFakeClaude(t, validSummary)
+		output := runMain(t, "analyze", "--session-id", "s1", "--content", "user: fix the bug")
+		if strings.Contains(output, "warnings") {
+			t.Errorf("Expected no warnings, got %s", output)
+		}
+	})
+
+	t.Run("Trimmed below threshold", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+		content := strings.Repeat("user: please refactor the parser module\n", 2500)
+		output := runMain(t, "analyze", "--session-id", "s1", "--content", content, "--max-chars", "1000")
+		if strings.Contains(output, "warnings") {
+			t.Errorf("Expected no warnings after trimming, got %s", output)
+		}
+		if prompt := readPrompts(t, promptFile)[0]; len(prompt) > 2000 {
+			t.Errorf("Expected trimmed prompt, got %d characters", len(prompt))
+		}
+	})
+}
+
+// TestTrimToMaxChars tests keeping the tail of content on a line boundary
+func TestTrimToMaxChars(t *testing.T) {
+	content := "line one\nline two\nline three"
+
+	tests := []struct {
+		maxChars int
+		expected string
+	}{
+		{0, content},
+		{100, content},
+		{12, "line three"},
+		{19, "line two\nline three"},
+		{5, "three"},
+	}
+	for _, tt := range tests {
+		if got := trimToMaxChars(content, tt.maxChars); got != tt.expected {
+			t.Errorf("trimToMaxChars(%d) = %q, want %q", tt.maxChars, got, tt.expected)
+		}
+	}
+
+	// Multibyte content without line breaks is cut on a character, not byte, boundary
+	multibyte := strings.Repeat("日本語", 10)
+	if got := trimToMaxChars(multibyte, 4); got != "語日本語" || !utf8.ValidString(got) {
+		t.Errorf("trimToMaxChars(multibyte, 4) = %q, want %q", got, "語日本語")
+	}
+	if got := trimToMaxChars(multibyte, 30); got != multibyte {
+		t.Errorf("Expected 30 characters kept whole, got %q", got)
+	}
+}
+
+// TestAnalyzeInputBudget tests that content over CLAUDE_MAX_INPUT_TOKENS fails with
+// both counts before the model is called, and that 0 disables the check
+func TestAnalyzeInputBudget(t *testing.T) {
+	content := strings.Repeat("user: please refactor the parser module\n", 100)
+
+	t.Run("Over budget", func(t *testing.T) {
+		promptFile := installFakeClaude(t, validSummary)
+		t.Setenv("CLAUDE_MAX_INPUT_TOKENS", "500")
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", content)), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if !strings.Contains(response.Error, "~925 estimated tokens, limit 500") || !strings.Contains(response.Error, "--max-chars") {
+			t.Errorf("Expected both token counts and guidance, got %q", response.Error)
+		}
+		if _, err := os.Stat(promptFile); err == nil {
+			t.Error("Expected no prompt to be sent")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		installFakeClaude(t, validSummary)
+		t.Setenv("CLAUDE_MAX_INPUT_TOKENS", "0")
+
+		var response SessionAnalysisResponse
+		if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", content)), &response); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		if response.Error != "" || response.Summary != validSummary {
+			t.Errorf("Expected analysis to proceed, got %+v", response)
+		}
+	})
+}
+
+// TestTruncateToTokens tests each strategy's kept part and that the result fits
+func TestTruncateToTokens(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("user: message number %d about the parser", i))
+	}
+	content := strings.Join(lines, "\n")
+
+	tests := []struct {
+		strategy string
+		prefix   string
+		suffix   string
+	}{
+		{"head", "user: message number 1 about", "\n" + elidedMarker},
+		{"tail", elidedMarker + "\n", "number 100 about the parser"},
+		{"middle", "user: message number 1 about", "number 100 about the parser"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			truncated, info := truncateToTokens(content, 200, tt.strategy)
+			if tokens := llm.EstimateTokens(truncated); tokens > 200 {
+				t.Errorf("Expected at most 200 tokens, got %d", tokens)
+			}
+			if !strings.HasPrefix(truncated, tt.prefix) || !strings.HasSuffix(truncated, tt.suffix) || strings.Count(truncated, elidedMarker) != 1 {
+				t.Errorf("Unexpected truncation: %q", truncated)
+			}
+			// Kept lines are whole
+			for _, line := range strings.Split(truncated, "\n") {
+				if line != elidedMarker && !strings.HasSuffix(line, "about the parser") {
+					t.Errorf("Expected whole lines, got %q", line)
+				}
+			}
+			if info == nil || info.Strategy != tt.strategy || info.OriginalTokens != llm.EstimateTokens(content) || info.ElidedChars <= 0 {
+				t.Errorf("Unexpected truncation info: %+v", info)
+			}
+		})
+	}
+
+	if truncated, info := truncateToTokens(content, 0, "head"); truncated != content || info != nil {
+		t.Errorf("Expected no truncation with the check disabled, got %+v", info)
+	}
+	if truncated, info := truncateToTokens("short", 200, "middle"); truncated != "short" || info != nil {
+		t.Errorf("Expected content within budget unchanged, got %q", truncated)
+	}
+
+	single := strings.Repeat("word ", 2000)
+	if truncated, _ := truncateToTokens(single, 100, "tail"); llm.EstimateTokens(truncated) > 100 || !strings.HasPrefix(truncated, elidedMarker+"\n") || !strings.HasSuffix(truncated, "word ") {
+		t.Errorf("Expected a single long line cut within it, got %q", truncated)
+	}
+}
+
+// TestAnalyzeTruncate tests that --truncate analyzes oversized content instead of
+// refusing it, and records the cut
+func TestAnalyzeTruncate(t *testing.T) {
+	content := strings.Repeat("user: please refactor the parser module\n", 100)
+	promptFile := installFakeClaude(t, validSummary)
+	t.Setenv("CLAUDE_MAX_INPUT_TOKENS", "500")
+
+	var response SessionAnalysisResponse
+	if err := json.Unmarshal([]byte(runMain(t, "analyze", "--session-id", "s1", "--content", content, "--truncate", "middle")), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if response.Error != "" || response.Summary != validSummary {
+		t.Fatalf("Expected analysis to proceed, got %+v", response)
+	}
+	truncation := response.Metadata.Truncation
+	if truncation == nil || truncation.Strategy != "middle" || truncation.OriginalTokens != 925 || response.Metadata.TokenCount > 500 {
+		t.Errorf("Unexpected truncation metadata: %+v, %+v", truncation, response.Metadata)
+	}
+	if len(response.Warnings) != 1 || !strings.Contains(response.Warnings[0], "beginning and end") {
+		t.Errorf("Expected a truncation warning, got %q", response.Warnings)
+	}
+	if prompts := readPrompts(t, promptFile); len(prompts) != 1 || !strings.Contains(prompts[0], elidedMarker) {
+		t.Errorf("Expected the elided content in the prompt, got %q", prompts)
+	}
+
+	if output := runMain(t, "analyze", "--session-id", "s1", "--content", content, "--truncate", "sides"); !strings.Contains(output, "Invalid truncate") {
+		t.Errorf("Expected invalid truncate error, got %s", output)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressWriter receives --progress lines; stderr keeps stdout clean JSON
+var progressWriter io.Writer = os.Stderr
+
+// progress reports each started item of a fan-out as "[n/total] analyzing <item>..."
+// with the time elapsed since the fan-out began. A nil *progress reports nothing.
+type progress struct {
+	total int
+	start time.Time
+
+	mu      sync.Mutex
+	started int
+}
+
+// newProgress returns a reporter for total items, or nil when enabled is false
+func newProgress(enabled bool, total int) *progress {
+	if !enabled {
+		return nil
+	}
+	return &progress{total: total, start: time.Now()}
+}
+
+// step reports that work on item has started
+func (p *progress) step(item string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started++
+	fmt.Fprintf(progressWriter, "[%d/%d] analyzing %s... (%.1fs elapsed)\n", p.started, p.total, item, time.Since(p.start).Seconds())
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// captureProgress redirects progress lines into a buffer for the rest of the test
+func captureProgress(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	original := progressWriter
+	progressWriter = &buf
+	t.Cleanup(func() { progressWriter = original })
+	return &buf
+}
+
+// TestProgress tests the progress line format and that a disabled reporter is silent
+func TestProgress(t *testing.T) {
+	buf := captureProgress(t)
+
+	newProgress(false, 2).step("ignored.jsonl")
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no output when disabled, got %q", buf.String())
+	}
+
+	p := newProgress(true, 2)
+	p.step("a.jsonl")
+	p.step("b.jsonl")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []*regexp.Regexp{
+		regexp.MustCompile(`^\[1/2\] analyzing a\.jsonl\.\.\. \(\d+\.\ds elapsed\)$`),
+		regexp.MustCompile(`^\[2/2\] analyzing b\.jsonl\.\.\. \(\d+\.\ds elapsed\)$`),
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %q", len(want), buf.String())
+	}
+	for i, re := range want {
+		if !re.MatchString(lines[i]) {
+			t.Errorf("Line %d = %q, want match for %s", i, lines[i], re)
+		}
+	}
+}
+
+// TestAnalyzeDirProgress tests that --progress reports every file without touching stdout
+func TestAnalyzeDirProgress(t *testing.T) {
+	buf := captureProgress(t)
+	installFakeClaude(t, validSummary)
+	dir := writeSessionDir(t, map[string]string{
+		"one.jsonl": `{"type":"user","message":{"content":"First session"}}` + "\n",
+		"two.jsonl": `{"type":"user","message":{"content":"Second session"}}` + "\n",
+	})
+
+	output := runMain(t, "analyze", "--dir", dir, "--progress")
+	if strings.Contains(output, "analyzing") {
+		t.Errorf("Expected progress to stay off stdout, got %s", output)
+	}
+
+	progress := buf.String()
+	for _, want := range []string{"analyzing one.jsonl...", "analyzing two.jsonl...", "[1/2]", "[2/2]"} {
+		if !strings.Contains(progress, want) {
+			t.Errorf("Expected %q in progress output, got %q", want, progress)
+		}
+	}
+}
+
+// TestAnalyzeWindowProgress tests that --progress reports each window of a large session
+func TestAnalyzeWindowProgress(t *testing.T) {
+	buf := captureProgress(t)
+	installRoutingFakeClaude(t,
+		fakeRoute{Marker: "Analyze window", Response: validAnalysisJSON},
+		fakeRoute{Marker: "concise summary", Response: validSummary},
+	)
+
+	runMain(t, "analyze", "--session-id", "s1", "--content", windowedContent(t), "--both", "--progress")
+
+	progress := buf.String()
+	if !strings.Contains(progress, "analyzing window 1 of s1...") {
+		t.Errorf("Expected window progress, got %q", progress)
+	}
+	if strings.Count(progress, "analyzing window") < 2 {
+		t.Errorf("Expected a line per window, got %q", progress)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// homePathPatterns match the username segment of absolute home-directory paths.
+// The leading group anchors on a path boundary so relative paths such as
+// "src/home/alice" are left untouched.
+var homePathPatterns = []*regexp.Regexp{
+	regexp.MustCompile("(^|[\\s\"'`(=:])(/(?:Users|home)/)[^/\\s\"'`)]+"),
+	regexp.MustCompile("(^|[\\s\"'`(=])([A-Za-z]:\\\\Users\\\\)[^\\\\\\s\"'`)]+"),
+}
+
+// redactPaths masks usernames in absolute home-directory paths
+// (e.g. /Users/alice/project -> /Users/<user>/project)
+func redactPaths(text string) string {
+	for _, pattern := range homePathPatterns {
+		text = pattern.ReplaceAllString(text, "${1}${2}<user>")
+	}
+	return text
+}
+
+// redactAnalysisPaths masks home-directory paths in episode descriptions
+func redactAnalysisPaths(analysis *llm.Analysis) {
+	if analysis == nil {
+		return
+	}
+	for _, episode := range analysis.Episodes {
+		if episode != nil {
+			episode.Description = redactPaths(episode.Description)
+		}
+	}
+}
+
+// SecretPattern is a named regular expression whose matches Redact masks. When the
+// expression has a group named "secret", only that group is masked, keeping context
+// such as the "Bearer " prefix.
+type SecretPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// defaultSecretPatterns are masked unless a redact rules file replaces them
+var defaultSecretPatterns = []SecretPattern{
+	{Name: "aws_access_key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "bearer_token", Pattern: `(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]{8,}=*)`},
+	{Name: "api_key", Pattern: `\bsk-[A-Za-z0-9_\-]{16,}`},
+	{Name: "email", Pattern: `\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}\b`},
+	{Name: "ip_address", Pattern: `\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b`},
+}
+
+// RedactionCounts reports how many matches of each pattern --redact masked
+type RedactionCounts struct {
+	Total     int            `json:"total"`
+	ByPattern map[string]int `json:"by_pattern"`
+}
+
+// compiledSecretPattern is a SecretPattern ready to match
+type compiledSecretPattern struct {
+	name        string
+	re          *regexp.Regexp
+	secret      int // Index of the "secret" group, or 0 to mask the whole match
+	placeholder string
+}
+
+// Redactor masks secrets in content before it is sent to the model
+type Redactor struct {
+	patterns []compiledSecretPattern
+}
+
+// defaultRedactor masks the built-in patterns
+var defaultRedactor = mustNewRedactor(defaultSecretPatterns)
+
+// Redact masks the built-in secret patterns in content, replacing each match with a
+// placeholder naming the pattern, e.g. "[REDACTED_EMAIL]"
+func Redact(content string) string {
+	redacted, _ := defaultRedactor.Redact(content)
+	return redacted
+}
+
+// NewRedactor compiles patterns, applied in order
+func NewRedactor(patterns []SecretPattern) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("secret pattern %q has no name", p.Pattern)
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %s: %w", p.Name, err)
+		}
+		r.patterns = append(r.patterns, compiledSecretPattern{
+			name:        p.Name,
+			re:          re,
+			secret:      re.SubexpIndex("secret"),
+			placeholder: "[REDACTED_" + strings.ToUpper(p.Name) + "]",
+		})
+	}
+	return r, nil
+}
+
+func mustNewRedactor(patterns []SecretPattern) *Redactor {
+	r, err := NewRedactor(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// loadRedactor returns a redactor for the patterns in the given JSON file, an object
+// with a "patterns" list replacing the built-in ones, or the built-in redactor when
+// path is empty
+func loadRedactor(path string) (*Redactor, error) {
+	if path == "" {
+		return defaultRedactor, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redact rules %s: %w", path, err)
+	}
+	var rules struct {
+		Patterns []SecretPattern `json:"patterns"`
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse redact rules %s: %w", path, err)
+	}
+	if rules.Patterns == nil {
+		return defaultRedactor, nil
+	}
+	return NewRedactor(rules.Patterns)
+}
+
+// Redact masks every pattern's matches in content and counts them per pattern
+func (r *Redactor) Redact(content string) (string, *RedactionCounts) {
+	counts := &RedactionCounts{ByPattern: map[string]int{}}
+	for _, p := range r.patterns {
+		matches := p.re.FindAllStringSubmatchIndex(content, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if p.secret > 0 {
+				start, end = m[2*p.secret], m[2*p.secret+1]
+				if start < 0 {
+					continue
+				}
+			}
+			b.WriteString(content[last:start])
+			b.WriteString(p.placeholder)
+			last = end
+			counts.ByPattern[p.name]++
+			counts.Total++
+		}
+		b.WriteString(content[last:])
+		content = b.String()
+	}
+	return content, counts
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// htmlEpisode is an episode paired with its unique anchor ID
+type htmlEpisode struct {
+	Anchor string
+	*llm.Episode
+}
+
+// htmlReport is the data passed to htmlReportTemplate
+type htmlReport struct {
+	Episodes        []htmlEpisode
+	Patterns        *llm.WorkflowPatterns
+	Recommendations []string
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Session Analysis</title>
+</head>
+<body>
+<h1>Session Analysis</h1>
+{{- if .Episodes}}
+<nav id="toc">
+<h2>Contents</h2>
+<ol>
+{{- range .Episodes}}
+<li><a href="#{{.Anchor}}">{{.Phase}}{{if .ID}} ({{.ID}}){{end}}</a></li>
+{{- end}}
+</ol>
+</nav>
+{{- end}}
+<h2>Episodes</h2>
+{{- range .Episodes}}
+<section id="{{.Anchor}}">
+<h3>{{.Phase}}{{if .SubPhase}} / {{.SubPhase}}{{end}}{{if .ID}} ({{.ID}}){{end}}</h3>
+<p>Lines {{.StartLine}}-{{.EndLine}}, confidence {{printf "%.2f" .Confidence}}</p>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- if .KeyInsights}}
+<ul>
+{{- range .KeyInsights}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- else}}
+<p>No episodes.</p>
+{{- end}}
+{{- with .Patterns}}
+<h2>Patterns</h2>
+<table>
+<tr><th>Workflow</th><td>{{.Workflow}}</td></tr>
+<tr><th>Efficiency</th><td>{{.Efficiency}}</td></tr>
+{{- if .FrustrationLevel}}
+<tr><th>Frustration level</th><td>{{.FrustrationLevel}}</td></tr>
+{{- end}}
+{{- if .LearningPattern}}
+<tr><th>Learning pattern</th><td>{{.LearningPattern}}</td></tr>
+{{- end}}
+{{- if .Collaboration}}
+<tr><th>Collaboration</th><td>{{.Collaboration}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Recommendations}}
+<h2>Recommendations</h2>
+<ul>
+{{- range .Recommendations}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML renders an analysis as a standalone HTML page with a table of contents
+// linking to each episode. All model-provided text is escaped by html/template.
+func renderHTML(analysis *llm.Analysis) (string, error) {
+	report := htmlReport{
+		Patterns:        analysis.Patterns,
+		Recommendations: analysis.Recommendations,
+	}
+
+	anchors := episodeAnchors(analysis.Episodes)
+	for i, ep := range analysis.Episodes {
+		if ep == nil {
+			continue
+		}
+		report.Episodes = append(report.Episodes, htmlEpisode{Anchor: anchors[i], Episode: ep})
+	}
+
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// episodeAnchors builds an anchor ID for each episode from its phase and ID, e.g.
+// "episode-debugging-ep1". IDs are restricted to [a-z0-9-] and made unique with a
+// numeric suffix when two episodes would otherwise collide.
+func episodeAnchors(episodes []*llm.Episode) []string {
+	anchors := make([]string, len(episodes))
+	used := make(map[string]bool, len(episodes))
+	for i, ep := range episodes {
+		if ep == nil {
+			continue
+		}
+		base := "episode"
+		for _, part := range []string{ep.Phase, ep.ID} {
+			if slug := anchorSlug(part); slug != "" {
+				base += "-" + slug
+			}
+		}
+		if base == "episode" {
+			base = fmt.Sprintf("episode-%d", i+1)
+		}
+
+		anchor := base
+		for n := 2; used[anchor]; n++ {
+			anchor = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[anchor] = true
+		anchors[i] = anchor
+	}
+	return anchors
+}
+
+// anchorSlug lowercases s and replaces runs of other characters with a single hyphen
+func anchorSlug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// renderMarkdown renders an analysis as a Markdown report: a section per episode,
+// a patterns table, and a recommendations list
+func renderMarkdown(analysis *llm.Analysis) string {
+	var b strings.Builder
+	b.WriteString("# Session Analysis\n\n## Episodes\n")
+
+	episodes := 0
+	for _, ep := range analysis.Episodes {
+		if ep == nil {
+			continue
+		}
+		episodes++
+
+		heading := ep.Phase
+		if ep.SubPhase != "" {
+			heading += " / " + ep.SubPhase
+		}
+		if ep.ID != "" {
+			heading += " (" + ep.ID + ")"
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", markdownInline(heading))
+		fmt.Fprintf(&b, "- **Phase:** %s\n", markdownInline(ep.Phase))
+		fmt.Fprintf(&b, "- **Confidence:** %.2f\n", ep.Confidence)
+		fmt.Fprintf(&b, "- **Lines:** %d-%d\n", ep.StartLine, ep.EndLine)
+		if ep.Description != "" {
+			fmt.Fprintf(&b, "\n%s\n", ep.Description)
+		}
+		if len(ep.KeyInsights) > 0 {
+			b.WriteString("\n**Key insights:**\n\n")
+			for _, insight := range ep.KeyInsights {
+				fmt.Fprintf(&b, "- %s\n", markdownInline(insight))
+			}
+		}
+	}
+	if episodes == 0 {
+		b.WriteString("\nNo episodes.\n")
+	}
+
+	if p := analysis.Patterns; p != nil {
+		b.WriteString("\n## Patterns\n\n| Pattern | Value |\n| --- | --- |\n")
+		// Workflow and efficiency are always shown, like in the HTML report
+		rows := [][2]string{{"Workflow", p.Workflow}, {"Efficiency", p.Efficiency}}
+		for _, row := range [][2]string{
+			{"Frustration level", p.FrustrationLevel},
+			{"Learning pattern", p.LearningPattern},
+			{"Collaboration", p.Collaboration},
+		} {
+			if row[1] != "" {
+				rows = append(rows, row)
+			}
+		}
+		for _, row := range rows {
+			fmt.Fprintf(&b, "| %s | %s |\n", row[0], markdownCell(row[1]))
+		}
+	}
+
+	if len(analysis.Recommendations) > 0 {
+		b.WriteString("\n## Recommendations\n\n")
+		for _, rec := range analysis.Recommendations {
+			fmt.Fprintf(&b, "- %s\n", markdownInline(rec))
+		}
+	}
+	return b.String()
+}
+
+// markdownInline collapses line breaks so model text can't start a new block
+func markdownInline(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// markdownCell makes s safe inside a table cell by also escaping pipes
+func markdownCell(s string) string {
+	return strings.ReplaceAll(markdownInline(s), "|", `\|`)
+}
+
+// csvHeader names the columns written by renderCSV
+var csvHeader = []string{"id", "phase", "sub_phase", "confidence", "start_line", "end_line", "duration", "description"}
+
+// renderCSV renders an analysis's episodes as CSV with a header row and one row per
+// episode. Fields containing commas, quotes, or newlines are quoted by encoding/csv.
+func renderCSV(analysis *llm.Analysis) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return "", err
+	}
+	for _, ep := range analysis.Episodes {
+		if ep == nil {
+			continue
+		}
+		row := []string{
+			ep.ID,
+			ep.Phase,
+			ep.SubPhase,
+			strconv.FormatFloat(ep.Confidence, 'f', -1, 64),
+			strconv.Itoa(ep.StartLine),
+			strconv.Itoa(ep.EndLine),
+			ep.Duration,
+			ep.Description,
+		}
+		if err := w.Write(row); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/csv"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestRenderHTMLTableOfContents tests one TOC entry per episode with matching anchors
+func TestRenderHTMLTableOfContents(t *testing.T) {
+	analysis := &llm.Analysis{
+		Episodes: []*llm.Episode{
+			{ID: "ep1", Phase: "exploration", StartLine: 1, EndLine: 10},
+			{ID: "ep2", Phase: "debugging", StartLine: 11, EndLine: 20, Description: "<script>alert(1)</script>"},
+			{ID: "ep2", Phase: "debugging", StartLine: 21, EndLine: 30},
+			{ID: `"><b>x`, Phase: "Testing & QA", StartLine: 31, EndLine: 40},
+		},
+		Patterns:        &llm.WorkflowPatterns{Workflow: "iterative", Efficiency: "high"},
+		Recommendations: []string{"Write tests first"},
+	}
+
+	output, err := renderHTML(analysis)
+	if err != nil {
+		t.Fatalf("renderHTML failed: %v", err)
+	}
+
+	tocStart := strings.Index(output, `<nav id="toc">`)
+	tocEnd := strings.Index(output, "</nav>")
+	if tocStart < 0 || tocEnd < 0 {
+		t.Fatalf("Expected a table of contents, got:\n%s", output)
+	}
+	toc := output[tocStart:tocEnd]
+	body := output[tocEnd:]
+
+	links := regexp.MustCompile(`href="#([^"]+)"`).FindAllStringSubmatch(toc, -1)
+	if len(links) != len(analysis.Episodes) {
+		t.Fatalf("Expected %d TOC entries, got %d", len(analysis.Episodes), len(links))
+	}
+
+	seen := make(map[string]bool)
+	for _, link := range links {
+		anchor := link[1]
+		if seen[anchor] {
+			t.Errorf("Duplicate anchor %q", anchor)
+		}
+		seen[anchor] = true
+		if !strings.Contains(body, `<section id="`+anchor+`">`) {
+			t.Errorf("Anchor %q has no matching section in the body", anchor)
+		}
+	}
+
+	for _, expected := range []string{"episode-exploration-ep1", "episode-debugging-ep2", "episode-debugging-ep2-2", "episode-testing-qa-b-x"} {
+		if !seen[expected] {
+			t.Errorf("Expected anchor %q, got %v", expected, links)
+		}
+	}
+
+	if strings.Contains(output, "<script>") || strings.Contains(output, `"><b>`) {
+		t.Error("Expected model text to be escaped")
+	}
+}
+
+// TestRenderHTMLNoEpisodes tests that the TOC is omitted when there is nothing to link
+func TestRenderHTMLNoEpisodes(t *testing.T) {
+	output, err := renderHTML(&llm.Analysis{})
+	if err != nil {
+		t.Fatalf("renderHTML failed: %v", err)
+	}
+	if strings.Contains(output, `id="toc"`) {
+		t.Error("Expected no table of contents without episodes")
+	}
+	if !strings.Contains(output, "No episodes.") {
+		t.Error("Expected empty episode notice")
+	}
+}
+
+// TestRenderMarkdown tests episode sections, the patterns table, and recommendations
+func TestRenderMarkdown(t *testing.T) {
+	analysis := &llm.Analysis{
+		Episodes: []*llm.Episode{
+			{ID: "ep1", Phase: "debugging", SubPhase: "root cause", Confidence: 0.875, StartLine: 3, EndLine: 12,
+				Description: "Tracked down the nil map.", KeyInsights: []string{"Map was never\ninitialized"}},
+		},
+		Patterns:        &llm.WorkflowPatterns{Workflow: "trial | error", Efficiency: "medium", Collaboration: "close"},
+		Recommendations: []string{"Initialize maps in constructors"},
+	}
+
+	output := renderMarkdown(analysis)
+
+	for _, want := range []string{
+		"### debugging / root cause (ep1)\n",
+		"- **Phase:** debugging\n- **Confidence:** 0.88\n- **Lines:** 3-12\n",
+		"\nTracked down the nil map.\n",
+		"**Key insights:**\n\n- Map was never initialized\n",
+		"| Workflow | trial \\| error |\n| Efficiency | medium |\n| Collaboration | close |\n",
+		"## Recommendations\n\n- Initialize maps in constructors\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Frustration level") {
+		t.Errorf("Empty optional patterns should be omitted:\n%s", output)
+	}
+}
+
+// TestRenderMarkdownNoEpisodes tests the placeholder for an empty analysis
+func TestRenderMarkdownNoEpisodes(t *testing.T) {
+	output := renderMarkdown(&llm.Analysis{})
+
+	if !strings.Contains(output, "No episodes.") || strings.Contains(output, "## Patterns") {
+		t.Errorf("Unexpected output for empty analysis:\n%s", output)
+	}
+}
+
+// TestRenderCSV tests the episode rows and quoting of multi-line descriptions
+func TestRenderCSV(t *testing.T) {
+	analysis := &llm.Analysis{
+		Episodes: []*llm.Episode{
+			{ID: "ep1", Phase: "debugging", SubPhase: "root cause", Confidence: 0.875, StartLine: 3, EndLine: 12,
+				Duration: "5m", Description: "Found it, \"finally\"\nafter a while"},
+			nil,
+			{ID: "ep2", Phase: "testing", Confidence: 1, StartLine: 13, EndLine: 20},
+		},
+	}
+
+	output, err := renderCSV(analysis)
+	if err != nil {
+		t.Fatalf("renderCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v\n%s", err, output)
+	}
+	want := [][]string{
+		csvHeader,
+		{"ep1", "debugging", "root cause", "0.875", "3", "12", "5m", "Found it, \"finally\"\nafter a while"},
+		{"ep2", "testing", "", "1", "13", "20", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Unexpected rows:\n got %q\nwant %q", records, want)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetRadius is how many bytes of content a search snippet keeps on either side of
+// the first match
+const snippetRadius = 60
+
+// Markers placed around each match in a search snippet
+const (
+	highlightStart = "**"
+	highlightEnd   = "**"
+)
+
+// SearchResponse represents search output: the filtered messages matching a pattern
+type SearchResponse struct {
+	File          string        `json:"file"`
+	Pattern       string        `json:"pattern"`
+	TotalMessages int           `json:"total_messages"` // Messages searched
+	Matches       []SearchMatch `json:"matches"`
+}
+
+// SearchMatch is one matching message
+type SearchMatch struct {
+	Index     int               `json:"index"` // 0-based position among the filtered messages
+	Line      int               `json:"line"`
+	Role      string            `json:"role"`
+	Timestamp string            `json:"timestamp,omitempty"`
+	Snippet   string            `json:"snippet"` // Content around the first match, matches wrapped in **
+	Count     int               `json:"count"`   // Matches in the whole message
+	Before    []FilteredMessage `json:"before,omitempty"`
+	After     []FilteredMessage `json:"after,omitempty"`
+}
+
+// handleSearch finds the messages of a session whose content matches a regular expression
+func handleSearch() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer search --file <path> --pattern <regex> [--ignore-case] [--context <n>] [--include-tools] [--include-system]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:], "ignore-case", "include-tools", "include-system")
+	filePath := flags["file"]
+
+	if filePath == "" {
+		respondError("Missing file path")
+		return
+	}
+	if flags["pattern"] == "" {
+		respondError("Missing pattern")
+		return
+	}
+
+	expr := flags["pattern"]
+	if flags["ignore-case"] != "" {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		respondError(fmt.Sprintf("Invalid pattern: %v", err))
+		return
+	}
+
+	contextSize, err := parseIntFlag(flags, "context", 0)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	result, err := filterJSONLFile(context.Background(), filePath, 0, FilterOptions{
+		IncludeTools:  flags["include-tools"] != "",
+		IncludeSystem: flags["include-system"] != "",
+	})
+	if err != nil {
+		respondError(fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	respondJSON(SearchResponse{
+		File:          filePath,
+		Pattern:       flags["pattern"],
+		TotalMessages: len(result.Messages),
+		Matches:       searchMessages(result.Messages, re, contextSize),
+	})
+}
+
+// searchMessages returns the messages whose content matches re, each with up to
+// contextSize messages on either side
+func searchMessages(messages []FilteredMessage, re *regexp.Regexp, contextSize int) []SearchMatch {
+	matches := []SearchMatch{}
+	for i, msg := range messages {
+		locs := re.FindAllStringIndex(msg.Content, -1)
+		if locs == nil {
+			continue
+		}
+		match := SearchMatch{
+			Index:     i,
+			Line:      msg.Line,
+			Role:      msg.Type,
+			Timestamp: msg.Timestamp,
+			Snippet:   highlightSnippet(msg.Content, locs),
+			Count:     len(locs),
+		}
+		if contextSize > 0 {
+			match.Before = messages[max(0, i-contextSize):i]
+			match.After = messages[i+1 : min(len(messages), i+1+contextSize)]
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// highlightSnippet cuts content to snippetRadius bytes around the first match, moved to
+// rune boundaries, and wraps every non-empty match inside the cut in highlight markers
+func highlightSnippet(content string, locs [][]int) string {
+	start := max(0, locs[0][0]-snippetRadius)
+	end := min(len(content), locs[0][1]+snippetRadius)
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	pos := start
+	for _, loc := range locs {
+		if loc[0] < pos || loc[1] > end || loc[0] == loc[1] {
+			continue
+		}
+		b.WriteString(content[pos:loc[0]])
+		b.WriteString(highlightStart + content[loc[0]:loc[1]] + highlightEnd)
+		pos = loc[1]
+	}
+	b.WriteString(content[pos:end])
+	if end < len(content) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// runSearch runs the search command with args and decodes its output
+func runSearch(t *testing.T, args ...string) SearchResponse {
+	t.Helper()
+	var response SearchResponse
+	output := runMain(t, append([]string{"search"}, args...)...)
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+	return response
+}
+
+// TestSearch tests matching, case folding, and surrounding context
+func TestSearch(t *testing.T) {
+	path := writeFixture(t, "session.jsonl", strings.Join([]string{
+		`{"type":"user","message":{"content":"Why does parseConfig panic?"},"timestamp":"2025-01-01T10:00:00Z"}`,
+		`{"type":"assistant","message":{"content":"It dereferences a nil map"}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","content":"panic: assignment to entry in nil map"}]}}`,
+		`{"type":"assistant","message":{"content":"Fixed: PANIC no longer occurs in parseConfig"}}`,
+	}, "\n"))
+
+	response := runSearch(t, "--file", path, "--pattern", `parse\w+`)
+	if response.TotalMessages != 3 || len(response.Matches) != 2 {
+		t.Fatalf("Expected 2 matches in 3 messages, got %+v", response)
+	}
+	first := response.Matches[0]
+	if first.Index != 0 || first.Line != 1 || first.Role != "user" || first.Timestamp != "2025-01-01T10:00:00Z" || first.Snippet != "Why does **parseConfig** panic?" {
+		t.Errorf("Unexpected first match: %+v", first)
+	}
+	if first.Before != nil || first.After != nil {
+		t.Errorf("Expected no context by default, got %+v", first)
+	}
+
+	response = runSearch(t, "--file", path, "--pattern", "panic", "--ignore-case", "--include-tools", "--context", "1")
+	if len(response.Matches) != 3 {
+		t.Fatalf("Expected 3 case-insensitive matches with tools, got %+v", response.Matches)
+	}
+	last := response.Matches[2]
+	if last.Index != 3 || last.Snippet != "Fixed: **PANIC** no longer occurs in parseConfig" || len(last.Before) != 1 || last.Before[0].Type != "tool_result" || len(last.After) != 0 {
+		t.Errorf("Unexpected last match: %+v", last)
+	}
+
+	if output := runMain(t, "search", "--file", path, "--pattern", "("); !strings.Contains(output, "Invalid pattern") {
+		t.Errorf("Expected an invalid pattern error, got %s", output)
+	}
+	if output := runMain(t, "search", "--file", path); !strings.Contains(output, "Missing pattern") {
+		t.Errorf("Expected a missing pattern error, got %s", output)
+	}
+}
+
+// TestHighlightSnippet tests trimming long content around the first match
+func TestHighlightSnippet(t *testing.T) {
+	content := strings.Repeat("é", 100) + " error here, error again " + strings.Repeat("x", 100)
+	re := regexp.MustCompile("error")
+
+	snippet := highlightSnippet(content, re.FindAllStringIndex(content, -1))
+	if !strings.HasPrefix(snippet, "...") || !strings.HasSuffix(snippet, "...") {
+		t.Errorf("Expected ellipses on both sides, got %q", snippet)
+	}
+	if strings.Count(snippet, "**error**") != 2 {
+		t.Errorf("Expected both matches highlighted, got %q", snippet)
+	}
+	if !strings.Contains(snippet, "é") || !utf8.ValidString(snippet) {
+		t.Errorf("Expected the cut on a rune boundary, got %q", snippet)
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/metrics"
+)
+
+const (
+	// defaultServeAddr is where serve listens without --addr. The server has no
+	// authentication and spends the configured model credentials, so it is only reachable
+	// from this machine unless --addr asks for more, e.g. --addr :8080.
+	defaultServeAddr = "127.0.0.1:8080"
+
+	// maxRequestBytes bounds request bodies, including uploaded sessions
+	maxRequestBytes = 64 << 20
+
+	// shutdownTimeout is how long in-flight requests get to finish after SIGINT
+	shutdownTimeout = 30 * time.Second
+
+	// defaultMaxConcurrentAnalyses is how many /analyze requests run at once without
+	// --max-concurrent
+	defaultMaxConcurrentAnalyses = 4
+)
+
+// Metrics recorded by the server for the /analyze and /filter endpoints
+const (
+	MetricRequestsInFlight  = "session_viewer_requests_in_flight"
+	MetricRequestsCompleted = "session_viewer_requests_completed_total"
+	MetricRequestsFailed    = "session_viewer_requests_failed_total"
+	MetricRequestsRejected  = "session_viewer_requests_rejected_total"
+)
+
+// server serves analyze and filter over HTTP. Config, filter rules, and the backend
+// are loaded once and shared by every request.
+type server struct {
+	run            *analyzeRun
+	slots          chan struct{}     // One token per analysis allowed to run at once
+	requestTimeout time.Duration     // Deadline of each /analyze and /filter request
+	registry       *metrics.Registry // Request counts, exposed at /metrics
+}
+
+// newServer prepares the shared analysis state with default analyze options. At most
+// maxConcurrent analyses run at once. Each request gets the same deadline as a session
+// analyzed by the analyze command, which leaves room for every transport and
+// conversational-response retry.
+func newServer(cfg *config.Config, maxConcurrent int) (*server, error) {
+	run, err := newAnalyzeRun(cfg, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	return &server{
+		run:            run,
+		slots:          make(chan struct{}, maxConcurrent),
+		requestTimeout: run.timeout(),
+		registry:       metrics.Default,
+	}, nil
+}
+
+// routes returns the server's handler
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.Handle("/analyze", s.instrument(http.HandlerFunc(s.handleAnalyze)))
+	mux.Handle("/filter", s.instrument(http.HandlerFunc(s.handleFilter)))
+	return mux
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrument counts requests in flight and, once answered, as completed or failed
+// (any 4xx or 5xx status, including rejections)
+func (s *server) instrument(next http.Handler) http.Handler {
+	inFlight := s.registry.Gauge(MetricRequestsInFlight, "Requests currently being served")
+	completed := s.registry.Counter(MetricRequestsCompleted, "Requests answered successfully")
+	failed := s.registry.Counter(MetricRequestsFailed, "Requests answered with an error status")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if recorder.status >= 400 {
+			failed.Inc()
+		} else {
+			completed.Inc()
+		}
+	})
+}
+
+// handleServe runs the HTTP server until SIGINT or SIGTERM, then waits up to
+// shutdownTimeout for in-flight requests before exiting
+func handleServe(cfg *config.Config) {
+	flags := parseFlags(os.Args[2:])
+	addr := flags["addr"]
+	if addr == "" {
+		addr = defaultServeAddr
+	}
+	maxConcurrent, err := parseIntFlag(flags, "max-concurrent", defaultMaxConcurrentAnalyses)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+	if maxConcurrent < 1 {
+		respondError("Invalid max-concurrent: must be at least 1")
+		return
+	}
+
+	srv, err := newServer(cfg, maxConcurrent)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		respondError(fmt.Sprintf("Failed to listen on %s: %v", addr, err))
+		return
+	}
+
+	httpServer := &http.Server{Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.Serve(listener) }()
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", listener.Addr())
+
+	select {
+	case err := <-serveErr:
+		respondError(fmt.Sprintf("Server failed: %v", err))
+		return
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintf(os.Stderr, "Shutting down\n")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		respondError(fmt.Sprintf("Shutdown failed: %v", err))
+	}
+}
+
+// handleHealth reports that the server is up
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleMetrics writes the server's metrics in the Prometheus text format
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = s.registry.WritePrometheus(w)
+}
+
+// handleAnalyze analyzes the content of a SessionAnalysisRequest like the analyze
+// command. It answers 429 when every analysis slot is taken, 504 when the analysis
+// outlives the request deadline, and 502 for any other failed model call.
+func (s *server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	default:
+		s.registry.Counter(MetricRequestsRejected, "Analyze requests rejected at the concurrency limit").Inc()
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "Too many concurrent analyses")
+		return
+	}
+
+	var req SessionAnalysisRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.SessionID == "" || strings.TrimSpace(req.Content) == "" {
+		writeError(w, http.StatusBadRequest, "Missing required arguments")
+		return
+	}
+	if err := llm.ValidateSessionID(req.SessionID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkInputBudget(llm.EstimateTokens(req.Content), s.run.cfg.Claude.MaxInputTokens); err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(metrics.WithRegistry(r.Context(), s.registry), s.requestTimeout)
+	defer cancel()
+
+	response := s.run.analyze(ctx, req.SessionID, req.Content)
+	status := http.StatusOK
+	if response.Error != "" {
+		status = http.StatusBadGateway
+		if ctx.Err() == context.DeadlineExceeded {
+			status = http.StatusGatewayTimeout
+		}
+	}
+	writeJSON(w, status, response)
+}
+
+// handleFilter filters an uploaded JSONL session (multipart field "file"), optionally
+// gzipped, like the filter command. The limit, start-line, end-line, role, include-tools,
+// include-system, strict, sort-by-time, since, until, and require-timestamp form fields
+// match the command's flags.
+func (s *server) handleFilter(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Missing file upload: %v", err))
+		return
+	}
+	defer file.Close()
+
+	flags := make(map[string]string)
+	for _, name := range []string{"limit", "start-line", "end-line", "role", "include-tools", "include-system", "strict", "sort-by-time", "since", "until", "require-timestamp"} {
+		if values, ok := r.MultipartForm.Value[name]; ok && len(values) > 0 {
+			flags[name] = values[0]
+		}
+	}
+
+	limit, err := parseIntFlag(flags, "limit", defaultMessageLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	startLine, endLine, err := parseLineRange(flags)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	role, err := parseRoleFlag(flags)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	since, until, err := parseTimeRange(flags, time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	session, err := decompressSession(file, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Error filtering file: %v", err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.requestTimeout)
+	defer cancel()
+
+	result, err := filterJSONL(ctx, session, limit, FilterOptions{
+		IncludeTools:  isTrueValue(flags["include-tools"]),
+		IncludeSystem: isTrueValue(flags["include-system"]),
+		Strict:        isTrueValue(flags["strict"]),
+		StartLine:     startLine,
+		EndLine:       endLine,
+		SortByTime:    isTrueValue(flags["sort-by-time"]),
+		Role:          role,
+
+		Since:            since,
+		Until:            until,
+		RequireTimestamp: isTrueValue(flags["require-timestamp"]),
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			writeError(w, http.StatusGatewayTimeout, fmt.Sprintf("Error filtering file: %v", err))
+			return
+		}
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Error filtering file: %v", err))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Error filtering file: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// requireMethod answers 405 unless r uses method
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method %s not allowed", r.Method))
+	return false
+}
+
+// isTrueValue reports whether a form value switches a boolean option on
+func isTrueValue(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// writeJSON writes data as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status line is already sent; a failed write only affects this client
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// writeError writes an error in the same shape respondError prints
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"error": message})
+}
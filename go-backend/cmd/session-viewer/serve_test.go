@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/metrics"
+)
+
+// newTestServer starts the serve routes against the fake claude installed by the
+// caller, counting metrics in a registry of its own
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	_, ts := startTestServer(t, defaultMaxConcurrentAnalyses)
+	return ts
+}
+
+// startTestServer is newTestServer with a concurrency limit, also returning the server
+func startTestServer(t *testing.T, maxConcurrent int) (*server, *httptest.Server) {
+	t.Helper()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	srv, err := newServer(cfg, maxConcurrent)
+	if err != nil {
+		t.Fatalf("newServer failed: %v", err)
+	}
+	srv.registry = metrics.NewRegistry()
+	ts := httptest.NewServer(srv.routes())
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+// postJSON posts body to path and decodes the JSON reply into out
+func postJSON(t *testing.T, url string, body string, out interface{}) int {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("Invalid JSON reply: %v", err)
+	}
+	return resp.StatusCode
+}
+
+// uploadSession posts content as the multipart "file" field along with form fields
+func uploadSession(t *testing.T, url, content string, fields map[string]string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "session.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	form.Close()
+
+	resp, err := http.Post(url, form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	return resp
+}
+
+// TestServeHealth tests the health endpoint
+func TestServeHealth(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
+
+// TestServeDefaultAddr tests that serve only listens on loopback unless --addr says otherwise
+func TestServeDefaultAddr(t *testing.T) {
+	host, _, err := net.SplitHostPort(defaultServeAddr)
+	if err != nil {
+		t.Fatalf("Invalid default address %q: %v", defaultServeAddr, err)
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		t.Errorf("Expected a loopback default address, got %q", defaultServeAddr)
+	}
+}
+
+// TestServeRequestTimeout tests that an /analyze request may last as long as analyze
+// allows one session, retries included, not just one CLI call
+func TestServeRequestTimeout(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	srv, _ := startTestServer(t, 1)
+	if srv.requestTimeout != srv.run.timeout() || srv.requestTimeout <= srv.run.cfg.Claude.Timeout {
+		t.Errorf("Expected the analyze deadline %v, got %v", srv.run.timeout(), srv.requestTimeout)
+	}
+}
+
+// TestServeAnalyze tests POST /analyze and its request errors
+func TestServeAnalyze(t *testing.T) {
+	promptFile := installFakeClaude(t, validSummary)
+	ts := newTestServer(t)
+
+	var response SessionAnalysisResponse
+	status := postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"user: add a server mode"}`, &response)
+	if status != http.StatusOK || response.SessionID != "s1" || response.Summary != validSummary {
+		t.Errorf("Unexpected reply %d: %+v", status, response)
+	}
+	if prompt := readPrompts(t, promptFile)[0]; !strings.Contains(prompt, "add a server mode") {
+		t.Errorf("Expected content in prompt, got %q", prompt)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"Missing content", `{"session_id":"s1"}`, http.StatusBadRequest},
+		{"Unsafe session ID", `{"session_id":"../../etc/something","content":"hello"}`, http.StatusBadRequest},
+		{"Invalid JSON", `{"session_id":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reply map[string]interface{}
+			if status := postJSON(t, ts.URL+"/analyze", tt.body, &reply); status != tt.status || reply["error"] == nil {
+				t.Errorf("Expected %d with an error, got %d: %v", tt.status, status, reply)
+			}
+		})
+	}
+
+	resp, err := http.Get(ts.URL + "/analyze")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+// TestServeAnalyzeFailure tests that a failed model call is reported as 502
+func TestServeAnalyzeFailure(t *testing.T) {
+	installFakeClaude(t, "")
+	t.Setenv("CLAUDE_RETRY_BASE_DELAY", "1ms")
+	ts := newTestServer(t)
+
+	var response SessionAnalysisResponse
+	status := postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"hello"}`, &response)
+	if status != http.StatusBadGateway || response.Error == "" {
+		t.Errorf("Expected 502 with an error, got %d: %+v", status, response)
+	}
+}
+
+// TestServeAnalyzeOverBudget tests that content over CLAUDE_MAX_INPUT_TOKENS is
+// rejected as 413 without calling the model
+func TestServeAnalyzeOverBudget(t *testing.T) {
+	promptFile := installFakeClaude(t, validSummary)
+	t.Setenv("CLAUDE_MAX_INPUT_TOKENS", "10")
+	ts := newTestServer(t)
+
+	var reply map[string]interface{}
+	status := postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"user: please refactor the parser module and add tests for it"}`, &reply)
+	if status != http.StatusRequestEntityTooLarge || !strings.Contains(fmt.Sprint(reply["error"]), "limit 10") {
+		t.Errorf("Expected 413 naming the limit, got %d: %v", status, reply)
+	}
+	if _, err := os.Stat(promptFile); err == nil {
+		t.Error("Expected no prompt to be sent")
+	}
+}
+
+// TestServeFilter tests POST /filter with an uploaded session
+func TestServeFilter(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	ts := newTestServer(t)
+	session := `{"type":"user","message":{"content":"First"}}
+not json
+{"type":"assistant","message":{"content":"Second"}}
+{"type":"user","message":{"content":"Third"}}
+`
+
+	resp := uploadSession(t, ts.URL+"/filter", session, map[string]string{"limit": "2"})
+	defer resp.Body.Close()
+	var result FilterResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Invalid JSON reply: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(result.Messages) != 2 || result.Messages[0].Content != "Second" || result.SkippedLines != 1 {
+		t.Errorf("Unexpected reply %d: %+v", resp.StatusCode, result)
+	}
+
+	strict := uploadSession(t, ts.URL+"/filter", session, map[string]string{"strict": "true"})
+	strict.Body.Close()
+	if strict.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a malformed line in strict mode, got %d", strict.StatusCode)
+	}
+
+	badLimit := uploadSession(t, ts.URL+"/filter", session, map[string]string{"limit": "-1"})
+	badLimit.Body.Close()
+	if badLimit.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", badLimit.StatusCode)
+	}
+}
+
+// TestServeConcurrencyLimit tests that analyses beyond the limit are rejected with 429
+func TestServeConcurrencyLimit(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	srv, ts := startTestServer(t, 1)
+
+	// Occupy the only slot as a running analysis would
+	srv.slots <- struct{}{}
+	var reply map[string]interface{}
+	if status := postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"hello"}`, &reply); status != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d: %v", status, reply)
+	}
+	<-srv.slots
+
+	var response SessionAnalysisResponse
+	if status := postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"hello"}`, &response); status != http.StatusOK {
+		t.Errorf("Expected 200 once the slot is free, got %d: %+v", status, response)
+	}
+	if rejected := srv.registry.Snapshot()[MetricRequestsRejected]; rejected != 1 {
+		t.Errorf("Expected 1 rejected request, got %d", rejected)
+	}
+}
+
+// TestServeTimeout tests that an analysis outliving the request deadline gets 504
+func TestServeTimeout(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "claude")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLAUDE_BINARY_PATH", script)
+	t.Setenv("ANALYSIS_DIR", t.TempDir())
+
+	srv, ts := startTestServer(t, 1)
+	srv.requestTimeout = 100 * time.Millisecond
+
+	var response SessionAnalysisResponse
+	if status := postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"hello"}`, &response); status != http.StatusGatewayTimeout || response.Error == "" {
+		t.Errorf("Expected 504 with an error, got %d: %+v", status, response)
+	}
+}
+
+// TestServeMetrics tests the request counters exposed at /metrics
+func TestServeMetrics(t *testing.T) {
+	installFakeClaude(t, validSummary)
+	ts := newTestServer(t)
+
+	var response SessionAnalysisResponse
+	postJSON(t, ts.URL+"/analyze", `{"session_id":"s1","content":"hello"}`, &response)
+	var reply map[string]interface{}
+	postJSON(t, ts.URL+"/analyze", `{"session_id":"s1"}`, &reply)
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+
+	for _, want := range []string{
+		MetricRequestsCompleted + " 1\n",
+		MetricRequestsFailed + " 1\n",
+		MetricRequestsInFlight + " 0\n",
+		"# TYPE " + MetricRequestsInFlight + " gauge\n",
+	} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, body.String())
+		}
+	}
+}
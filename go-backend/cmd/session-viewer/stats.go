@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// SessionStats summarizes a JSONL session locally, without calling Claude
+type SessionStats struct {
+	File            string         `json:"file"`
+	TotalMessages   int            `json:"total_messages"`
+	MessageCounts   map[string]int `json:"message_counts"` // By type: user, assistant, tool_use, tool_result, system
+	TotalChars      int            `json:"total_chars"`
+	CharsByType     map[string]int `json:"chars_by_type"`
+	FirstTimestamp  string         `json:"first_timestamp,omitempty"`
+	LastTimestamp   string         `json:"last_timestamp,omitempty"`
+	EstimatedTokens int            `json:"estimated_tokens"`
+	SkippedLines    int            `json:"skipped_lines"`
+
+	Anonymized *AnonymizationSummary `json:"anonymized,omitempty"` // With --anonymize
+}
+
+// handleStats reports message counts, sizes, and the time span of a session
+func handleStats() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer stats --file <path> [--timestamp-layout <layout>] [--anonymize]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:], "anonymize")
+	filePath := flags["file"]
+
+	if filePath == "" {
+		respondError("Missing file path")
+		return
+	}
+
+	var anonymizer *Anonymizer
+	if flags["anonymize"] != "" {
+		anonymizer = NewAnonymizer()
+	}
+
+	stats, err := computeSessionStats(filePath, flags["timestamp-layout"], anonymizer)
+	if err != nil {
+		respondError(fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	respondJSON(stats)
+}
+
+// computeSessionStats reads a JSONL session with the same extraction rules as the
+// filter command (tool entries included) and also counts system entries, which the
+// filter drops. Timestamps are parsed with timestampLayout, or common layouts if empty;
+// ones that don't parse are left out of the time span. With an anonymizer, sizes are
+// those of the anonymized content, as filter --anonymize would share it, and the
+// reported file path is anonymized too.
+func computeSessionStats(filePath, timestampLayout string, anonymizer *Anonymizer) (*SessionStats, error) {
+	file, err := openSession(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stats := &SessionStats{
+		File:          filePath,
+		MessageCounts: map[string]int{},
+		CharsByType:   map[string]int{},
+	}
+	var first, last time.Time
+	opts := FilterOptions{IncludeTools: true, IncludeSystem: true, Anonymizer: anonymizer}
+
+	stats.SkippedLines, err = readJSONLEntries(context.Background(), file, false, func(lineNum int, line map[string]interface{}) error {
+		for _, msg := range extractMessages(lineNum, line, opts) {
+			chars := utf8.RuneCountInString(msg.Content)
+			stats.TotalMessages++
+			stats.MessageCounts[msg.Type]++
+			stats.TotalChars += chars
+			stats.CharsByType[msg.Type] += chars
+			stats.EstimatedTokens += llm.EstimateTokens(msg.Content)
+
+			ts, err := parseTimestamp(msg.Timestamp, timestampLayout)
+			if err != nil {
+				continue
+			}
+			if first.IsZero() || ts.Before(first) {
+				first, stats.FirstTimestamp = ts, msg.Timestamp
+			}
+			if last.IsZero() || ts.After(last) {
+				last, stats.LastTimestamp = ts, msg.Timestamp
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if anonymizer != nil {
+		stats.File = anonymizer.Anonymize(stats.File)
+		stats.Anonymized = anonymizer.Summary()
+	}
+	return stats, nil
+}
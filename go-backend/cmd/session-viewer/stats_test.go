@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestStatsCommand tests counts, character totals, and the timestamp span of a session
+func TestStatsCommand(t *testing.T) {
+	file := writeFixture(t, "session.jsonl", strings.Join([]string{
+		`{"type":"system","content":"Session started","timestamp":"2025-01-01T10:00:00Z"}`,
+		`{"type":"user","message":{"content":"Fix the bug"},"timestamp":"2025-01-01T10:01:00.500Z"}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Looking"},{"type":"tool_use","name":"Read","input":{}}]},"timestamp":"2025-01-01T10:02:00Z"}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","content":"file body"}]},"timestamp":"2025-01-01T10:03:00Z"}`,
+		`not json`,
+		`{"type":"assistant","message":{"content":"Fixé"},"timestamp":"2025-01-01T09:59:00Z"}`,
+	}, "\n"))
+
+	output := runMain(t, "stats", "--file", file)
+
+	var stats SessionStats
+	if err := json.Unmarshal([]byte(output), &stats); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+
+	wantCounts := map[string]int{"system": 1, "user": 1, "assistant": 2, "tool_use": 1, "tool_result": 1}
+	for msgType, want := range wantCounts {
+		if stats.MessageCounts[msgType] != want {
+			t.Errorf("MessageCounts[%s] = %d, want %d", msgType, stats.MessageCounts[msgType], want)
+		}
+	}
+	if stats.TotalMessages != 6 {
+		t.Errorf("TotalMessages = %d, want 6", stats.TotalMessages)
+	}
+	// "Looking" (7) + "Fixé" (4 characters, 5 bytes)
+	if stats.CharsByType["assistant"] != 11 {
+		t.Errorf("CharsByType[assistant] = %d, want 11", stats.CharsByType["assistant"])
+	}
+	if stats.CharsByType["user"] != len("Fix the bug") {
+		t.Errorf("CharsByType[user] = %d, want %d", stats.CharsByType["user"], len("Fix the bug"))
+	}
+	sum := 0
+	for _, chars := range stats.CharsByType {
+		sum += chars
+	}
+	if stats.TotalChars != sum {
+		t.Errorf("TotalChars = %d, want sum of per-type counts %d", stats.TotalChars, sum)
+	}
+	if stats.FirstTimestamp != "2025-01-01T09:59:00Z" || stats.LastTimestamp != "2025-01-01T10:03:00Z" {
+		t.Errorf("Timestamp span = %s..%s", stats.FirstTimestamp, stats.LastTimestamp)
+	}
+	if stats.EstimatedTokens <= 0 {
+		t.Errorf("Expected a positive token estimate, got %d", stats.EstimatedTokens)
+	}
+	if stats.SkippedLines != 1 {
+		t.Errorf("SkippedLines = %d, want 1", stats.SkippedLines)
+	}
+}
+
+// TestStatsCommandGzip tests stats on a gzipped session
+func TestStatsCommandGzip(t *testing.T) {
+	file := gzipFixture(t, "session.jsonl.gz", buildUserJSONL(3))
+
+	var stats SessionStats
+	if err := json.Unmarshal([]byte(runMain(t, "stats", "--file", file)), &stats); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if stats.MessageCounts["user"] != 3 || stats.SkippedLines != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestStatsCommandErrors tests missing and unreadable files
+func TestStatsCommandErrors(t *testing.T) {
+	if output := runMain(t, "stats", "--limit", "5"); !strings.Contains(output, "Missing file path") {
+		t.Errorf("Expected missing file error, got: %s", output)
+	}
+	if output := runMain(t, "stats", "--file", "/nonexistent/session.jsonl"); !strings.Contains(output, "Error reading file") {
+		t.Errorf("Expected read error, got: %s", output)
+	}
+}
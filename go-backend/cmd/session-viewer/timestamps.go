@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// commonTimestampLayouts are tried in order when no --timestamp-layout is given.
+// Layouts without a zone are read as UTC.
+var commonTimestampLayouts = []string{
+	time.RFC3339Nano, // Claude Code recordings; also matches RFC 3339 without fractions
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+}
+
+// parseTimestamp parses a message timestamp with layout (a Go reference layout), or
+// with each of commonTimestampLayouts when layout is empty
+func parseTimestamp(value, layout string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, value)
+	}
+	for _, candidate := range commonTimestampLayouts {
+		if t, err := time.Parse(candidate, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// sortMessagesByTime orders messages by timestamp, parsed as parseTimestamp does with
+// layout. The sort is stable, and messages whose timestamp is missing or doesn't parse
+// keep their relative order after all the others. Returns how many such messages there
+// were.
+func sortMessagesByTime(messages []FilteredMessage, layout string) int {
+	type timedMessage struct {
+		msg   FilteredMessage
+		at    time.Time
+		timed bool
+	}
+
+	timed := make([]timedMessage, len(messages))
+	untimed := 0
+	for i, msg := range messages {
+		timed[i].msg = msg
+		if msg.Timestamp != "" {
+			if at, err := parseTimestamp(msg.Timestamp, layout); err == nil {
+				timed[i].at, timed[i].timed = at, true
+				continue
+			}
+		}
+		untimed++
+	}
+
+	sort.SliceStable(timed, func(i, j int) bool {
+		if timed[i].timed != timed[j].timed {
+			return timed[i].timed
+		}
+		return timed[i].timed && timed[i].at.Before(timed[j].at)
+	})
+	for i := range timed {
+		messages[i] = timed[i].msg
+	}
+	return untimed
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseTimestamp tests explicit layouts and the common-layout fallback
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2025, 3, 4, 15, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		layout  string
+		wantErr bool
+	}{
+		{"RFC 3339", "2025-03-04T15:06:07Z", "", false},
+		{"No zone", "2025-03-04 15:06:07", "", false},
+		{"Custom layout", "04/03/2025 15h06m07s", "02/01/2006 15h04m05s", false},
+		{"Custom layout mismatch", "2025-03-04T15:06:07Z", "02/01/2006 15h04m05s", true},
+		{"Unrecognized without layout", "yesterday", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimestamp(tt.value, tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimestamp(%q, %q) error = %v, wantErr %v", tt.value, tt.layout, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(want) {
+				t.Errorf("parseTimestamp(%q, %q) = %v, want %v", tt.value, tt.layout, got, want)
+			}
+		})
+	}
+}
+
+// customLayoutSession uses day-first timestamps, with one entry in another format
+const customLayoutSession = `{"type":"user","message":{"content":"first"},"timestamp":"05/01/2025 09h00m00s"}
+{"type":"assistant","message":{"content":"second"},"timestamp":"2025-01-04T08:00:00Z"}
+{"type":"user","message":{"content":"third"},"timestamp":"05/01/2025 10h30m00s"}
+`
+
+// TestFilterTimestampLayout tests that mismatched timestamps are counted but kept
+func TestFilterTimestampLayout(t *testing.T) {
+	file := writeFixture(t, "session.jsonl", customLayoutSession)
+
+	output := runMain(t, "filter", "--file", file, "--timestamp-layout", "02/01/2006 15h04m05s")
+
+	var result FilterResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if len(result.Messages) != 3 || result.TimestampErrors != 1 {
+		t.Errorf("Expected 3 messages with 1 timestamp error, got %d and %d", len(result.Messages), result.TimestampErrors)
+	}
+	if result.Messages[1].Timestamp != "2025-01-04T08:00:00Z" {
+		t.Errorf("Expected the original timestamp to be kept, got %q", result.Messages[1].Timestamp)
+	}
+
+	// Without a layout nothing is checked
+	if output := runMain(t, "filter", "--file", file); strings.Contains(output, "timestamp_errors") {
+		t.Errorf("Expected no timestamp check without a layout, got %s", output)
+	}
+}
+
+// TestStatsTimestampLayout tests the time span with a custom layout
+func TestStatsTimestampLayout(t *testing.T) {
+	file := writeFixture(t, "session.jsonl", customLayoutSession)
+
+	stats, err := computeSessionStats(file, "02/01/2006 15h04m05s", nil)
+	if err != nil {
+		t.Fatalf("computeSessionStats failed: %v", err)
+	}
+	// The RFC 3339 entry doesn't match the layout and is left out of the span
+	if stats.FirstTimestamp != "05/01/2025 09h00m00s" || stats.LastTimestamp != "05/01/2025 10h30m00s" {
+		t.Errorf("Unexpected span %q..%q", stats.FirstTimestamp, stats.LastTimestamp)
+	}
+}
+
+// TestSortMessagesByTime tests ordering by timestamp with untimed messages last
+func TestSortMessagesByTime(t *testing.T) {
+	messages := []FilteredMessage{
+		{Content: "third", Timestamp: "2025-01-01T10:02:00Z"},
+		{Content: "untimed", Timestamp: ""},
+		{Content: "first", Timestamp: "2025-01-01T10:00:00Z"},
+		{Content: "garbled", Timestamp: "yesterday"},
+		{Content: "second", Timestamp: "2025-01-01T11:01:00+01:00"},
+		{Content: "also first", Timestamp: "2025-01-01T10:00:00Z"},
+	}
+
+	untimed := sortMessagesByTime(messages, "")
+
+	var order []string
+	for _, msg := range messages {
+		order = append(order, msg.Content)
+	}
+	want := "first,also first,second,third,untimed,garbled"
+	if strings.Join(order, ",") != want || untimed != 2 {
+		t.Errorf("Got order %v with %d untimed, want %s with 2", order, untimed, want)
+	}
+}
+
+// TestFilterSortByTime tests that sorting happens before the last-N limit
+func TestFilterSortByTime(t *testing.T) {
+	file := writeFixture(t, "merged.jsonl", strings.Join([]string{
+		`{"type":"user","message":{"content":"late"},"timestamp":"2025-01-01T12:00:00Z"}`,
+		`{"type":"user","message":{"content":"early"},"timestamp":"2025-01-01T09:00:00Z"}`,
+		`{"type":"user","message":{"content":"middle"},"timestamp":"2025-01-01T10:00:00Z"}`,
+		`{"type":"user","message":{"content":"no time"}}`,
+	}, "\n"))
+
+	var result FilterResult
+	output := runMain(t, "filter", "--file", file, "--sort-by-time", "--limit", "3")
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if len(result.Messages) != 3 || result.Messages[0].Content != "middle" || result.Messages[1].Content != "late" ||
+		result.Messages[2].Content != "no time" || result.UntimedMessages != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if output := runMain(t, "filter", "--file", file, "--sort-by-time", "--stream"); !strings.Contains(output, `"error"`) {
+		t.Errorf("Expected --sort-by-time with --stream to be rejected, got %s", output)
+	}
+}
+
+// TestFilterTimeRange tests --since and --until, and keeping or dropping untimed messages
+func TestFilterTimeRange(t *testing.T) {
+	file := writeFixture(t, "incident.jsonl", strings.Join([]string{
+		`{"type":"user","message":{"content":"before"},"timestamp":"2025-01-01T09:00:00Z"}`,
+		`{"type":"user","message":{"content":"start"},"timestamp":"2025-01-01T10:00:00Z"}`,
+		`{"type":"user","message":{"content":"no time"}}`,
+		`{"type":"assistant","message":{"content":"during"},"timestamp":"2025-01-01 10:30:00"}`,
+		`{"type":"user","message":{"content":"garbled"},"timestamp":"yesterday"}`,
+		`{"type":"user","message":{"content":"after"},"timestamp":"2025-01-01T12:00:00Z"}`,
+	}, "\n"))
+
+	contents := func(args ...string) string {
+		t.Helper()
+		var result FilterResult
+		output := runMain(t, append([]string{"filter", "--file", file, "--limit", "0"}, args...)...)
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("Failed to parse output: %v\n%s", err, output)
+		}
+		var got []string
+		for _, msg := range result.Messages {
+			got = append(got, msg.Content)
+		}
+		return strings.Join(got, ",")
+	}
+
+	since, until := []string{"--since", "2025-01-01T10:00:00Z"}, []string{"--until", "2025-01-01T11:00:00Z"}
+	if got, want := contents(append(since, until...)...), "start,no time,during,garbled"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+	if got, want := contents(append(append(since, until...), "--require-timestamp")...), "start,during"; got != want {
+		t.Errorf("With --require-timestamp got %s, want %s", got, want)
+	}
+	if got, want := contents(until...), "before,start,no time,during,garbled"; got != want {
+		t.Errorf("With only --until got %s, want %s", got, want)
+	}
+	if got := contents("--since", "-2h", "--require-timestamp"); got != "" {
+		t.Errorf("Expected nothing in the last 2 hours, got %s", got)
+	}
+
+	if output := runMain(t, "filter", "--file", file, "--since", "2025-01-02T00:00:00Z", "--until", "2025-01-01T00:00:00Z"); !strings.Contains(output, `"error"`) {
+		t.Errorf("Expected an inverted range to be rejected, got %s", output)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// OnlyInvalidResponse represents validate output when only failing episodes are requested
+type OnlyInvalidResponse struct {
+	Valid           bool                       `json:"valid"`
+	Errors          []string                   `json:"errors,omitempty"` // Errors not tied to an episode
+	InvalidEpisodes []validator.InvalidEpisode `json:"invalid_episodes"`
+}
+
+// handleValidate checks a model reply or analysis JSON file against the analysis schema
+func handleValidate() {
+	if len(os.Args) < 3 {
+		respondError("Usage: session-viewer validate --input <path> [--only-invalid] [--min-episodes <n>] [--strict]")
+		return
+	}
+
+	flags := parseFlags(os.Args[2:], "only-invalid", "strict")
+	inputPath := flags["input"]
+
+	if inputPath == "" {
+		respondError("Missing input path")
+		return
+	}
+
+	minEpisodes, err := parseIntFlag(flags, "min-episodes", 0)
+	if err != nil {
+		respondError(err.Error())
+		return
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		respondError(fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	result := validator.ValidateAnalysisJSONWithOptions(string(data), validator.Options{
+		MinEpisodes: minEpisodes,
+		Strict:      flags["strict"] != "",
+	})
+	if flags["only-invalid"] != "true" {
+		respondJSON(result)
+		return
+	}
+
+	respondJSON(onlyInvalid(result))
+}
+
+// onlyInvalid reduces a validation result to the episodes that failed and any errors
+// that don't belong to a single episode
+func onlyInvalid(result *validator.ValidationResult) OnlyInvalidResponse {
+	response := OnlyInvalidResponse{
+		Valid:           result.Valid,
+		InvalidEpisodes: []validator.InvalidEpisode{},
+	}
+
+	episodeErrors := make(map[string]bool)
+	for _, invalid := range result.InvalidEpisodes {
+		response.InvalidEpisodes = append(response.InvalidEpisodes, invalid)
+		for _, fieldErr := range invalid.Errors {
+			episodeErrors[fieldErr.Message] = true
+		}
+	}
+	for _, msg := range result.Errors {
+		if !episodeErrors[msg] {
+			response.Errors = append(response.Errors, msg)
+		}
+	}
+	return response
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// mixedEpisodesAnalysis has one valid episode between two invalid ones
+const mixedEpisodesAnalysis = `{
+	"episodes": [
+		{"id": "ep1", "phase": "", "confidence": 0.9, "description": "No phase", "start_line": 1, "end_line": 5},
+		{"id": "ep2", "phase": "implementation", "confidence": 0.8, "description": "Fine", "start_line": 6, "end_line": 10},
+		{"id": "", "phase": "testing", "confidence": 1.4, "description": "Two problems", "start_line": 11, "end_line": 20}
+	],
+	"patterns": {"workflow": "iterative", "efficiency": "high"},
+	"recommendations": [],
+	"metadata": {"timestamp": "2025-01-01T00:00:00Z"}
+}`
+
+// TestValidateOnlyInvalid tests that --only-invalid emits just the failing episodes
+func TestValidateOnlyInvalid(t *testing.T) {
+	input := writeFixture(t, "analysis.json", mixedEpisodesAnalysis)
+
+	output := runMain(t, "validate", "--input", input, "--only-invalid")
+
+	var response OnlyInvalidResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if response.Valid {
+		t.Error("Expected valid=false")
+	}
+	if len(response.Errors) != 0 {
+		t.Errorf("Expected no errors outside episodes, got %v", response.Errors)
+	}
+	if len(response.InvalidEpisodes) != 2 {
+		t.Fatalf("Expected 2 invalid episodes, got %d: %s", len(response.InvalidEpisodes), output)
+	}
+
+	first := response.InvalidEpisodes[0]
+	if first.Index != 0 || first.Episode.ID != "ep1" {
+		t.Errorf("First invalid episode = %d/%s, want 0/ep1", first.Index, first.Episode.ID)
+	}
+	if len(first.Errors) != 1 || first.Errors[0].Path != "episodes[0].phase" {
+		t.Errorf("Unexpected errors for ep1: %+v", first.Errors)
+	}
+
+	second := response.InvalidEpisodes[1]
+	if second.Index != 2 || len(second.Errors) != 2 {
+		t.Fatalf("Unexpected second invalid episode: %+v", second)
+	}
+	if second.Errors[0].Path != "episodes[2].id" || second.Errors[1].Path != "episodes[2].confidence" {
+		t.Errorf("Unexpected paths: %+v", second.Errors)
+	}
+	if strings.Contains(output, `"ep2"`) {
+		t.Errorf("Valid episode should not be emitted: %s", output)
+	}
+}
+
+// TestValidateOnlyInvalidKeepsTopLevelErrors tests that errors outside episodes are still reported
+func TestValidateOnlyInvalidKeepsTopLevelErrors(t *testing.T) {
+	input := writeFixture(t, "analysis.json", `{"episodes": [], "metadata": {"timestamp": "2025-01-01T00:00:00Z"}}`)
+
+	output := runMain(t, "validate", "--input", input, "--only-invalid")
+
+	var response OnlyInvalidResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if len(response.InvalidEpisodes) != 0 {
+		t.Errorf("Expected no invalid episodes, got %+v", response.InvalidEpisodes)
+	}
+	if len(response.Errors) != 1 || response.Errors[0] != "Missing required field: patterns" {
+		t.Errorf("Unexpected errors: %v", response.Errors)
+	}
+}
+
+// TestValidateFullResult tests that without --only-invalid the whole result is emitted
+func TestValidateFullResult(t *testing.T) {
+	input := writeFixture(t, "analysis.json", mixedEpisodesAnalysis)
+
+	output := runMain(t, "validate", "--input", input)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Failed to parse output: %v\n%s", err, output)
+	}
+	if errs, _ := response["errors"].([]interface{}); len(errs) != 3 {
+		t.Errorf("Expected 3 errors, got %v", response["errors"])
+	}
+}
+
+// TestValidateMinEpisodes tests that --min-episodes fails an analysis with too few episodes
+func TestValidateMinEpisodes(t *testing.T) {
+	input := writeFixture(t, "analysis.json", `{"episodes": [{"id": "ep1", "phase": "testing", "confidence": 0.7, "start_line": 1, "end_line": 3}], "patterns": {}, "metadata": {"timestamp": "2025-01-01T00:00:00Z"}}`)
+
+	for _, tt := range []struct {
+		minEpisodes string
+		expectValid bool
+	}{
+		{"1", true},
+		{"2", false},
+	} {
+		output := runMain(t, "validate", "--input", input, "--min-episodes", tt.minEpisodes)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &response); err != nil {
+			t.Fatalf("Failed to parse output: %v\n%s", err, output)
+		}
+		if response["valid"] != tt.expectValid {
+			t.Errorf("--min-episodes %s: valid = %v, want %v (%s)", tt.minEpisodes, response["valid"], tt.expectValid, output)
+		}
+	}
+}
+
+// TestValidateMissingInput tests the missing --input error
+func TestValidateMissingInput(t *testing.T) {
+	output := runMain(t, "validate", "--only-invalid")
+
+	if !strings.Contains(output, "Missing input path") {
+		t.Errorf("Expected missing input error, got: %s", output)
+	}
+}
+
+// TestValidateStrict tests that --strict rejects JSON the default mode repairs
+func TestValidateStrict(t *testing.T) {
+	input := writeFixture(t, "analysis.json", `{"episodes": [{"id": "ep1", "phase": "testing", "confidence": 0.7, "description": "ok", "start_line": 1, "end_line": 3},], "patterns": {"workflow": "linear", "efficiency": "high"}, "recommendations": ["a"], "metadata": {"timestamp": "2025-01-01T00:00:00Z"}}`)
+
+	for _, tt := range []struct {
+		args      []string
+		wantValid bool
+	}{
+		{[]string{"validate", "--input", input}, true},
+		{[]string{"validate", "--input", input, "--strict"}, false},
+	} {
+		var result validator.ValidationResult
+		if err := json.Unmarshal([]byte(runMain(t, tt.args...)), &result); err != nil {
+			t.Fatalf("Failed to parse output: %v", err)
+		}
+		if result.Valid != tt.wantValid {
+			t.Errorf("%v: valid = %v, want %v (errors: %v)", tt.args, result.Valid, tt.wantValid, result.Errors)
+		}
+	}
+}
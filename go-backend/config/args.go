@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// reservedClaudeFlags are set by the wrapper on every call and can't be overridden
+// through extra args
+var reservedClaudeFlags = []string{"--model", "--session-id", "--resume", "-r", "-p", "--print", "--output-format"}
+
+// extraArgs resolves a setting as additional Claude CLI arguments, split like a
+// POSIX shell would split them. Flags the wrapper sets itself are rejected.
+func (r *settingResolver) extraArgs(name string) ([]string, error) {
+	value, label := r.lookup(name, "")
+	args, err := splitArgs(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", label, value, err)
+	}
+	for _, arg := range args {
+		flag, _, _ := strings.Cut(arg, "=")
+		for _, reserved := range reservedClaudeFlags {
+			if flag == reserved {
+				return nil, fmt.Errorf("invalid %s: %s is set by the session viewer and can't be passed as an extra argument", label, flag)
+			}
+		}
+	}
+	return args, nil
+}
+
+// splitArgs splits s into words on unquoted whitespace. Single quotes preserve their
+// contents literally; inside double quotes and unquoted text a backslash escapes the
+// next character. An unterminated quote or trailing backslash is an error.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
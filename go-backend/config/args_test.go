@@ -0,0 +1,68 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSplitArgs tests shell-style splitting with quotes and escapes
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Empty", input: "", want: nil},
+		{name: "Whitespace only", input: "  \t ", want: nil},
+		{name: "Simple", input: "--max-turns 3", want: []string{"--max-turns", "3"}},
+		{name: "Double quotes", input: `--allowedTools "Read Write"`, want: []string{"--allowedTools", "Read Write"}},
+		{name: "Single quotes are literal", input: `--append-system-prompt 'say "hi" \n'`, want: []string{"--append-system-prompt", `say "hi" \n`}},
+		{name: "Escaped space", input: `--add-dir /tmp/my\ dir`, want: []string{"--add-dir", "/tmp/my dir"}},
+		{name: "Escaped quote in double quotes", input: `"a \"b\""`, want: []string{`a "b"`}},
+		{name: "Adjacent quoted parts", input: `--x=a'b c'"d"`, want: []string{"--x=ab cd"}},
+		{name: "Empty quoted argument", input: `--flag ""`, want: []string{"--flag", ""}},
+		{name: "Unterminated quote", input: `--flag "abc`, wantErr: true},
+		{name: "Trailing backslash", input: `--flag \`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitArgs(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %q", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitArgs(%q) failed: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgs(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadConfigExtraArgs tests CLAUDE_EXTRA_ARGS and the guard against flags the
+// wrapper sets itself
+func TestLoadConfigExtraArgs(t *testing.T) {
+	t.Setenv("CLAUDE_EXTRA_ARGS", `--max-turns 3 --allowedTools "Read Grep"`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := []string{"--max-turns", "3", "--allowedTools", "Read Grep"}
+	if !reflect.DeepEqual(cfg.Claude.ExtraArgs, want) {
+		t.Errorf("Expected extra args %q, got %q", want, cfg.Claude.ExtraArgs)
+	}
+
+	for _, value := range []string{"--model opus", "--model=opus", "-p hi", "--print", "--session-id x", "--resume other", "--resume=other", "-r other", "--output-format json", `"unterminated`} {
+		t.Setenv("CLAUDE_EXTRA_ARGS", value)
+		if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "CLAUDE_EXTRA_ARGS") {
+			t.Errorf("Expected CLAUDE_EXTRA_ARGS error for %q, got %v", value, err)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -11,6 +16,8 @@ import (
 type Config struct {
 	Claude ClaudeConfig
 	Paths  PathsConfig
+	Log    LogConfig
+	Source ConfigSource // Where each setting came from (env var, config file, or default)
 }
 
 // ClaudeConfig contains Claude CLI configuration
@@ -18,41 +25,292 @@ type ClaudeConfig struct {
 	BinaryPath string        // Path to claude binary (default: "claude")
 	Model      string        // Model to use (default: claude-haiku-4-5-20251001)
 	Timeout    time.Duration // Command timeout (default: 10 minutes)
+
+	RetryBaseDelay time.Duration // Delay before the first retry of a failed call (default: 1s)
+	RetryMaxDelay  time.Duration // Upper bound on the backoff delay (default: 30s)
+
+	SkipBinaryCheck bool // Don't verify BinaryPath in Validate (default: false)
+
+	ExtraArgs []string // Additional CLI arguments appended to every call, e.g. --max-turns 3
+
+	MaxInputTokens int // Largest estimated session size analyzed in one prompt; 0 disables the check (default: 150000)
+
+	Backend string // Model backend prompts are sent through (default: "cli")
 }
 
 // PathsConfig contains filesystem path configuration
 type PathsConfig struct {
-	AnalysisDir string // Directory for analysis sessions
+	AnalysisDir       string // Directory for analysis sessions
+	AnalysisDirLayout string // Go time layout naming AnalysisDir's per-day subdirectories (default: 2006-01-02)
+	FilterRulesFile   string // Optional JSON file overriding response classification phrases
+	RedactRulesFile   string // Optional JSON file overriding the secret patterns masked by --redact
+	ProfilesFile      string // JSON file of named analysis profiles
+	CacheDir          string // Directory of cached model responses
+	PromptsDir        string // Directory of <name>.tmpl files overriding built-in prompt templates
+}
+
+// LogConfig controls diagnostic logging on stderr
+type LogConfig struct {
+	Level slog.Level // Minimum level written (default: warn); LogLevelOff disables logging
 }
 
-// LoadConfig loads configuration from environment variables with defaults
-// Supported environment variables:
-//   - CLAUDE_BINARY_PATH: Path to claude binary (default: "claude")
-//   - CLAUDE_MODEL: Model to use (default: claude-haiku-4-5-20251001)
-//   - ANALYSIS_DIR: Analysis directory (default: ~/.universal-session-viewer/analysis)
+// LoadConfig loads configuration from environment variables, then an optional config
+// file, then built-in defaults, in that order of precedence. Cfg.Source records which
+// of these each value came from.
+//
+// The config file is ~/.universal-session-viewer/config.yaml (or config.toml), or the
+// path in SESSION_VIEWER_CONFIG. It holds flat "key: value" lines using the setting
+// names below.
+//
+// Supported environment variables (config file key in parentheses):
+//   - CLAUDE_BINARY_PATH (binary_path): Path to claude binary (default: "claude")
+//   - CLAUDE_MODEL (model): Model to use (default: claude-haiku-4-5-20251001)
+//   - CLAUDE_TIMEOUT (timeout): Command timeout as a Go duration or bare minutes, e.g. 15m or 15 (default: 10m)
+//   - ANALYSIS_DIR (analysis_dir): Analysis directory (default: ~/.universal-session-viewer/analysis)
+//   - ANALYSIS_DIR_LAYOUT (analysis_dir_layout): Go time layout of the per-day subdirectories, or "legacy" for MMDDYY (default: 2006-01-02)
+//   - SESSION_VIEWER_FILTER_RULES (filter_rules_file): JSON file of response classification phrases (default: built-in rules)
+//   - SESSION_VIEWER_REDACT_RULES (redact_rules_file): JSON file of secret patterns masked by analyze --redact (default: built-in patterns)
+//   - SESSION_VIEWER_PROFILES (profiles_file): JSON file of named analysis profiles (default: ~/.universal-session-viewer/profiles.json)
+//   - SESSION_VIEWER_CACHE_DIR (cache_dir): Directory of cached model responses (default: ~/.universal-session-viewer/cache)
+//   - SESSION_VIEWER_PROMPTS_DIR (prompts_dir): Directory of <name>.tmpl prompt template overrides (default: ~/.universal-session-viewer/prompts)
+//   - CLAUDE_RETRY_BASE_DELAY (retry_base_delay): Initial retry backoff as a Go duration (default: 1s)
+//   - CLAUDE_RETRY_MAX_DELAY (retry_max_delay): Maximum retry backoff as a Go duration (default: 30s)
+//   - CLAUDE_SKIP_BINARY_CHECK (skip_binary_check): Set to "true" or "1" to skip the binary check in Validate
+//   - CLAUDE_EXTRA_ARGS (extra_args): Extra CLI arguments, split like a shell command line (default: none)
+//   - CLAUDE_MAX_INPUT_TOKENS (max_input_tokens): Largest estimated token count of content sent in one prompt, or 0 for no limit (default: 150000)
+//   - SESSION_VIEWER_LOG_LEVEL (log_level): debug, info, warn, error, or off (default: warn)
+//   - CLAUDE_BACKEND (backend): Model backend, "cli" or "anthropic" (default: cli); anthropic reads ANTHROPIC_API_KEY
 func LoadConfig() (*Config, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
+	filePath := configFilePath(homeDir)
+	fileSettings, err := parseConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	r := &settingResolver{file: fileSettings, filePath: filePath, source: ConfigSource{}}
+
+	timeout, err := r.timeout("timeout", time.Duration(DefaultTimeout)*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	retryBaseDelay, baseLabel, err := r.duration("retry_base_delay", DefaultRetryBaseDelay)
+	if err != nil {
+		return nil, err
+	}
+	retryMaxDelay, maxLabel, err := r.duration("retry_max_delay", DefaultRetryMaxDelay)
+	if err != nil {
+		return nil, err
+	}
+	if retryBaseDelay > retryMaxDelay {
+		return nil, fmt.Errorf("%s (%v) must not exceed %s (%v)", baseLabel, retryBaseDelay, maxLabel, retryMaxDelay)
+	}
+
+	extraArgs, err := r.extraArgs("extra_args")
+	if err != nil {
+		return nil, err
+	}
+
+	maxInputTokens, err := r.nonNegativeInt("max_input_tokens", DefaultMaxInputTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := r.backend("backend")
+	if err != nil {
+		return nil, err
+	}
+
+	analysisDirLayout, err := r.dirLayout("analysis_dir_layout")
+	if err != nil {
+		return nil, err
+	}
+
+	logLevel, err := r.logLevel("log_level")
+	if err != nil {
+		return nil, err
+	}
+
+	binaryPath, _ := r.lookup("binary_path", "claude")
+	model, _ := r.lookup("model", DefaultModel)
+	skipBinaryCheck, _ := r.lookup("skip_binary_check", "")
+	analysisDir, _ := r.lookup("analysis_dir", filepath.Join(homeDir, ".universal-session-viewer", "analysis"))
+	filterRulesFile, _ := r.lookup("filter_rules_file", "")
+	redactRulesFile, _ := r.lookup("redact_rules_file", "")
+	profilesFile, _ := r.lookup("profiles_file", filepath.Join(homeDir, ".universal-session-viewer", "profiles.json"))
+	cacheDir, _ := r.lookup("cache_dir", filepath.Join(homeDir, ".universal-session-viewer", "cache"))
+	promptsDir, _ := r.lookup("prompts_dir", filepath.Join(homeDir, ".universal-session-viewer", "prompts"))
+
 	cfg := &Config{
 		Claude: ClaudeConfig{
-			BinaryPath: getEnvOrDefault("CLAUDE_BINARY_PATH", "claude"),
-			Model:      getEnvOrDefault("CLAUDE_MODEL", DefaultModel),
-			Timeout:    time.Duration(DefaultTimeout) * time.Minute,
+			BinaryPath: binaryPath,
+			Model:      model,
+			Timeout:    timeout,
+
+			RetryBaseDelay: retryBaseDelay,
+			RetryMaxDelay:  retryMaxDelay,
+
+			SkipBinaryCheck: isTruthy(skipBinaryCheck),
+
+			ExtraArgs: extraArgs,
+
+			MaxInputTokens: maxInputTokens,
+
+			Backend: backend,
 		},
 		Paths: PathsConfig{
-			AnalysisDir: ExpandPath(getEnvOrDefault(
-				"ANALYSIS_DIR",
-				filepath.Join(homeDir, ".universal-session-viewer", "analysis"),
-			)),
+			AnalysisDir:       ExpandPath(analysisDir),
+			AnalysisDirLayout: analysisDirLayout,
+			FilterRulesFile:   ExpandPath(filterRulesFile),
+			RedactRulesFile:   ExpandPath(redactRulesFile),
+			ProfilesFile:      ExpandPath(profilesFile),
+			CacheDir:          ExpandPath(cacheDir),
+			PromptsDir:        ExpandPath(promptsDir),
 		},
+		Log: LogConfig{
+			Level: logLevel,
+		},
+		Source: r.source,
 	}
 
 	return cfg, nil
 }
 
+// ErrBinaryUnavailable is wrapped by Validate's error when the Claude binary is missing
+// or not executable
+var ErrBinaryUnavailable = errors.New("claude binary unavailable")
+
+// binaryError is a Validate message about the Claude binary that matches
+// ErrBinaryUnavailable
+type binaryError struct{ message string }
+
+func (e *binaryError) Error() string { return e.message }
+func (e *binaryError) Unwrap() error { return ErrBinaryUnavailable }
+
+// Validate checks settings that can only be verified against the environment, so a
+// misconfiguration is reported before any work starts. With the CLI backend the Claude
+// binary must resolve via exec.LookPath to an executable file unless SkipBinaryCheck
+// is set; the anthropic backend needs ANTHROPIC_API_KEY instead.
+func (c *Config) Validate() error {
+	if c.Claude.Backend == BackendAnthropic {
+		if os.Getenv(AnthropicAPIKeyEnv) == "" {
+			return fmt.Errorf("%s is not set; it is required by the %s backend", AnthropicAPIKeyEnv, BackendAnthropic)
+		}
+		return nil
+	}
+	if c.Claude.SkipBinaryCheck || !c.Claude.UsesCLI() {
+		return nil
+	}
+
+	if _, err := exec.LookPath(c.Claude.BinaryPath); err != nil {
+		if errors.Is(err, exec.ErrNotFound) && !strings.ContainsRune(c.Claude.BinaryPath, filepath.Separator) {
+			return &binaryError{fmt.Sprintf("claude binary %q not found in PATH; install the Claude CLI or set CLAUDE_BINARY_PATH", c.Claude.BinaryPath)}
+		}
+		if info, statErr := os.Stat(c.Claude.BinaryPath); statErr == nil && !info.IsDir() {
+			return &binaryError{fmt.Sprintf("claude binary %q is not executable; check its permissions or CLAUDE_BINARY_PATH", c.Claude.BinaryPath)}
+		}
+		return &binaryError{fmt.Sprintf("claude binary %q not found; check CLAUDE_BINARY_PATH", c.Claude.BinaryPath)}
+	}
+	return nil
+}
+
+// Settings returns the resolved value of every setting, keyed by its config file name
+// like Source. Durations are formatted as Go durations and the log level by name.
+func (c *Config) Settings() map[string]interface{} {
+	return map[string]interface{}{
+		"binary_path":         c.Claude.BinaryPath,
+		"model":               c.Claude.Model,
+		"timeout":             c.Claude.Timeout.String(),
+		"retry_base_delay":    c.Claude.RetryBaseDelay.String(),
+		"retry_max_delay":     c.Claude.RetryMaxDelay.String(),
+		"skip_binary_check":   c.Claude.SkipBinaryCheck,
+		"extra_args":          c.Claude.ExtraArgs,
+		"max_input_tokens":    c.Claude.MaxInputTokens,
+		"backend":             c.Claude.Backend,
+		"log_level":           logLevelName(c.Log.Level),
+		"analysis_dir":        c.Paths.AnalysisDir,
+		"analysis_dir_layout": c.Paths.AnalysisDirLayout,
+		"filter_rules_file":   c.Paths.FilterRulesFile,
+		"redact_rules_file":   c.Paths.RedactRulesFile,
+		"profiles_file":       c.Paths.ProfilesFile,
+		"cache_dir":           c.Paths.CacheDir,
+		"prompts_dir":         c.Paths.PromptsDir,
+	}
+}
+
+// UsesCLI reports whether prompts go through the Claude CLI binary. An unset Backend,
+// as in a Config built by hand, means the CLI.
+func (c ClaudeConfig) UsesCLI() bool {
+	return c.Backend == "" || c.Backend == BackendCLI
+}
+
+// backend resolves a setting as one of Backends, case-insensitively
+func (r *settingResolver) backend(name string) (string, error) {
+	value, label := r.lookup(name, DefaultBackend)
+	backend := strings.ToLower(strings.TrimSpace(value))
+	for _, known := range Backends {
+		if backend == known {
+			return backend, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q: expected one of %s", label, value, strings.Join(Backends, ", "))
+}
+
+// dirLayout resolves a setting as a Go time layout for a directory name, with "legacy"
+// standing for LegacyAnalysisDirLayout. A layout whose dates would contain a path
+// separator, or format to "." or "..", is rejected.
+func (r *settingResolver) dirLayout(name string) (string, error) {
+	value, label := r.lookup(name, DefaultAnalysisDirLayout)
+	layout := strings.TrimSpace(value)
+	if strings.EqualFold(layout, "legacy") {
+		return LegacyAnalysisDirLayout, nil
+	}
+	sample := time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC).Format(layout)
+	if layout == "" || strings.ContainsAny(sample, `/\`) || sample == "." || sample == ".." {
+		return "", fmt.Errorf("invalid %s %q: expected a time layout naming a single directory, such as 2006-01-02, or legacy", label, value)
+	}
+	return layout, nil
+}
+
+// logLevel resolves a setting as a slog level name, or "off" for LogLevelOff
+func (r *settingResolver) logLevel(name string) (slog.Level, error) {
+	value, label := r.lookup(name, DefaultLogLevel)
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "off", "none":
+		return LogLevelOff, nil
+	}
+	return 0, fmt.Errorf("invalid %s %q: expected debug, info, warn, error, or off", label, value)
+}
+
+// logLevelName is the inverse of settingResolver.logLevel
+func logLevelName(level slog.Level) string {
+	if level >= LogLevelOff {
+		return "off"
+	}
+	return strings.ToLower(level.String())
+}
+
+// isTruthy reports whether an environment value enables a boolean setting
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
 // getEnvOrDefault returns environment variable value or default if not set
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -61,6 +319,55 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// duration resolves a setting as a non-negative Go duration (e.g. "500ms", "2s"),
+// returning the label of its source for error messages
+func (r *settingResolver) duration(name string, defaultValue time.Duration) (time.Duration, string, error) {
+	value, label := r.lookup(name, "")
+	if value == "" {
+		return defaultValue, label, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, label, fmt.Errorf("invalid %s %q: expected a duration such as 2s", label, value)
+	}
+	return d, label, nil
+}
+
+// nonNegativeInt resolves a setting as a whole number of zero or more
+func (r *settingResolver) nonNegativeInt(name string, defaultValue int) (int, error) {
+	value, label := r.lookup(name, "")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a whole number of 0 or more", label, value)
+	}
+	return n, nil
+}
+
+// timeout resolves a setting as a positive timeout, accepting either a Go duration
+// ("15m", "90s") or a bare number of minutes ("15")
+func (r *settingResolver) timeout(name string, defaultValue time.Duration) (time.Duration, error) {
+	value, label := r.lookup(name, "")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	var d time.Duration
+	if minutes, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(minutes) * time.Minute
+	} else if d, err = time.ParseDuration(value); err != nil {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration such as 15m or a number of minutes", label, value)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", label, value)
+	}
+	return d, nil
+}
+
 // ExpandPath expands ~ and environment variables in paths
 func ExpandPath(path string) string {
 	if len(path) == 0 {
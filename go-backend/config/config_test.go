@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -47,10 +49,12 @@ func TestLoadConfigWithEnvironmentVariables(t *testing.T) {
 	os.Setenv("CLAUDE_BINARY_PATH", "/custom/path/claude")
 	os.Setenv("CLAUDE_MODEL", "custom-model")
 	os.Setenv("ANALYSIS_DIR", "/custom/analysis")
+	os.Setenv("SESSION_VIEWER_FILTER_RULES", "/custom/rules.json")
 	defer func() {
 		os.Unsetenv("CLAUDE_BINARY_PATH")
 		os.Unsetenv("CLAUDE_MODEL")
 		os.Unsetenv("ANALYSIS_DIR")
+		os.Unsetenv("SESSION_VIEWER_FILTER_RULES")
 	}()
 
 	cfg, err := LoadConfig()
@@ -70,6 +74,10 @@ func TestLoadConfigWithEnvironmentVariables(t *testing.T) {
 	if cfg.Paths.AnalysisDir != "/custom/analysis" {
 		t.Errorf("Expected custom analysis dir, got %q", cfg.Paths.AnalysisDir)
 	}
+
+	if cfg.Paths.FilterRulesFile != "/custom/rules.json" {
+		t.Errorf("Expected custom filter rules file, got %q", cfg.Paths.FilterRulesFile)
+	}
 }
 
 // TestGetEnvOrDefault tests environment variable helper
@@ -266,3 +274,303 @@ func TestConfigStructFields(t *testing.T) {
 		t.Error("Paths.AnalysisDir field not working")
 	}
 }
+
+// TestLoadConfigRetryBackoff tests retry backoff env vars and their validation
+func TestLoadConfigRetryBackoff(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.Claude.RetryBaseDelay != DefaultRetryBaseDelay || cfg.Claude.RetryMaxDelay != DefaultRetryMaxDelay {
+			t.Errorf("Expected default backoff, got %v/%v", cfg.Claude.RetryBaseDelay, cfg.Claude.RetryMaxDelay)
+		}
+	})
+
+	t.Run("Custom values", func(t *testing.T) {
+		t.Setenv("CLAUDE_RETRY_BASE_DELAY", "500ms")
+		t.Setenv("CLAUDE_RETRY_MAX_DELAY", "1m")
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.Claude.RetryBaseDelay != 500*time.Millisecond || cfg.Claude.RetryMaxDelay != time.Minute {
+			t.Errorf("Expected custom backoff, got %v/%v", cfg.Claude.RetryBaseDelay, cfg.Claude.RetryMaxDelay)
+		}
+	})
+
+	t.Run("Base above cap", func(t *testing.T) {
+		t.Setenv("CLAUDE_RETRY_BASE_DELAY", "1m")
+		t.Setenv("CLAUDE_RETRY_MAX_DELAY", "30s")
+		if _, err := LoadConfig(); err == nil {
+			t.Error("Expected error when base exceeds cap")
+		}
+	})
+
+	t.Run("Invalid duration", func(t *testing.T) {
+		t.Setenv("CLAUDE_RETRY_BASE_DELAY", "soon")
+		if _, err := LoadConfig(); err == nil {
+			t.Error("Expected error for invalid duration")
+		}
+	})
+}
+
+// TestLoadConfigTimeout tests CLAUDE_TIMEOUT parsing
+func TestLoadConfigTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{name: "Unset uses default", value: "", expected: time.Duration(DefaultTimeout) * time.Minute},
+		{name: "Duration string", value: "15m", expected: 15 * time.Minute},
+		{name: "Mixed duration", value: "1h30m", expected: 90 * time.Minute},
+		{name: "Bare minutes", value: "25", expected: 25 * time.Minute},
+		{name: "Invalid", value: "forever", wantErr: true},
+		{name: "Zero", value: "0", wantErr: true},
+		{name: "Negative", value: "-5m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CLAUDE_TIMEOUT", tt.value)
+			cfg, err := LoadConfig()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for CLAUDE_TIMEOUT=%q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Claude.Timeout != tt.expected {
+				t.Errorf("Expected timeout %v, got %v", tt.expected, cfg.Claude.Timeout)
+			}
+		})
+	}
+}
+
+// TestLoadConfigMaxInputTokens tests the input token budget setting
+func TestLoadConfigMaxInputTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+		wantErr  bool
+	}{
+		{name: "Unset uses default", value: "", expected: DefaultMaxInputTokens},
+		{name: "Custom budget", value: "50000", expected: 50000},
+		{name: "Zero disables", value: "0", expected: 0},
+		{name: "Negative", value: "-1", wantErr: true},
+		{name: "Not a number", value: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CLAUDE_MAX_INPUT_TOKENS", tt.value)
+			cfg, err := LoadConfig()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "CLAUDE_MAX_INPUT_TOKENS") {
+					t.Errorf("Expected CLAUDE_MAX_INPUT_TOKENS error for %q, got %v", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Claude.MaxInputTokens != tt.expected {
+				t.Errorf("Expected max input tokens %d, got %d", tt.expected, cfg.Claude.MaxInputTokens)
+			}
+		})
+	}
+}
+
+// TestLoadConfigAnalysisDirLayout tests the per-day analysis subdirectory layout
+func TestLoadConfigAnalysisDirLayout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{value: "", expected: DefaultAnalysisDirLayout},
+		{value: "legacy", expected: LegacyAnalysisDirLayout},
+		{value: "Legacy", expected: LegacyAnalysisDirLayout},
+		{value: "20060102", expected: "20060102"},
+		{value: "2006/01/02", wantErr: true},
+		{value: "..", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("ANALYSIS_DIR_LAYOUT", tt.value)
+		cfg, err := LoadConfig()
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "ANALYSIS_DIR_LAYOUT") {
+				t.Errorf("Expected ANALYSIS_DIR_LAYOUT error for %q, got %v", tt.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("LoadConfig(%q) failed: %v", tt.value, err)
+		}
+		if cfg.Paths.AnalysisDirLayout != tt.expected {
+			t.Errorf("Layout for %q = %q, want %q", tt.value, cfg.Paths.AnalysisDirLayout, tt.expected)
+		}
+	}
+}
+
+// TestValidate tests the Claude binary check
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "claude")
+	if err := os.WriteFile(executable, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+	notExecutable := filepath.Join(dir, "claude-noexec")
+	if err := os.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		binary      string
+		skip        bool
+		errContains string
+	}{
+		{name: "Executable path", binary: executable},
+		{name: "Found in PATH", binary: "echo"},
+		{name: "Missing from PATH", binary: "claude-does-not-exist", errContains: "not found in PATH"},
+		{name: "Missing path", binary: filepath.Join(dir, "missing"), errContains: "not found"},
+		{name: "Not executable", binary: notExecutable, errContains: "not executable"},
+		{name: "Check skipped", binary: filepath.Join(dir, "missing"), skip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Claude: ClaudeConfig{BinaryPath: tt.binary, SkipBinaryCheck: tt.skip}}
+			err := cfg.Validate()
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Validate failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+			if !errors.Is(err, ErrBinaryUnavailable) {
+				t.Errorf("Expected ErrBinaryUnavailable, got %v", err)
+			}
+		})
+	}
+}
+
+// TestLoadConfigSkipBinaryCheck tests the CLAUDE_SKIP_BINARY_CHECK opt-out
+func TestLoadConfigSkipBinaryCheck(t *testing.T) {
+	t.Setenv("CLAUDE_SKIP_BINARY_CHECK", "true")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.Claude.SkipBinaryCheck {
+		t.Error("Expected SkipBinaryCheck to be set")
+	}
+}
+
+// TestLoadConfigBackend tests CLAUDE_BACKEND selection and validation
+func TestLoadConfigBackend(t *testing.T) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Claude.Backend != DefaultBackend || !cfg.Claude.UsesCLI() {
+		t.Errorf("Expected default backend %q, got %q", DefaultBackend, cfg.Claude.Backend)
+	}
+
+	t.Setenv("CLAUDE_BACKEND", " CLI ")
+	if cfg, err = LoadConfig(); err != nil || cfg.Claude.Backend != BackendCLI {
+		t.Errorf("Expected backend %q, got %+v, %v", BackendCLI, cfg, err)
+	}
+
+	t.Setenv("CLAUDE_BACKEND", "carrier-pigeon")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "CLAUDE_BACKEND") {
+		t.Errorf("Expected CLAUDE_BACKEND error, got %v", err)
+	}
+}
+
+// TestValidateAnthropicBackend tests that the anthropic backend needs an API key, not a binary
+func TestValidateAnthropicBackend(t *testing.T) {
+	cfg := &Config{Claude: ClaudeConfig{BinaryPath: "claude-does-not-exist", Backend: BackendAnthropic}}
+
+	t.Setenv(AnthropicAPIKeyEnv, "")
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), AnthropicAPIKeyEnv) {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+
+	t.Setenv(AnthropicAPIKeyEnv, "test-key")
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+// TestLoadConfigLogLevel tests SESSION_VIEWER_LOG_LEVEL parsing
+func TestLoadConfigLogLevel(t *testing.T) {
+	tests := []struct {
+		value string
+		want  slog.Level
+	}{
+		{"", slog.LevelWarn},
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"error", slog.LevelError},
+		{"off", LogLevelOff},
+	}
+	for _, tt := range tests {
+		t.Setenv("SESSION_VIEWER_LOG_LEVEL", tt.value)
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig(%q) failed: %v", tt.value, err)
+		}
+		if cfg.Log.Level != tt.want {
+			t.Errorf("Level for %q = %v, want %v", tt.value, cfg.Log.Level, tt.want)
+		}
+	}
+
+	t.Setenv("SESSION_VIEWER_LOG_LEVEL", "verbose")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "SESSION_VIEWER_LOG_LEVEL") {
+		t.Errorf("Expected SESSION_VIEWER_LOG_LEVEL error, got %v", err)
+	}
+}
+
+// TestConfigSettings tests that every setting is reported with its resolved value
+func TestConfigSettings(t *testing.T) {
+	t.Setenv("CLAUDE_MODEL", "test-model")
+	t.Setenv("CLAUDE_TIMEOUT", "15")
+	t.Setenv("SESSION_VIEWER_LOG_LEVEL", "off")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	settings := cfg.Settings()
+
+	for name := range settingEnvVars {
+		if _, ok := settings[name]; !ok {
+			t.Errorf("Setting %s missing from Settings", name)
+		}
+		if _, ok := cfg.Source[name]; !ok {
+			t.Errorf("Setting %s missing from Source", name)
+		}
+	}
+	if len(settings) != len(settingEnvVars) {
+		t.Errorf("Expected %d settings, got %d", len(settingEnvVars), len(settings))
+	}
+
+	if settings["model"] != "test-model" || settings["timeout"] != "15m0s" || settings["log_level"] != "off" {
+		t.Errorf("Unexpected settings: %v", settings)
+	}
+	if cfg.Source["model"] != "env:CLAUDE_MODEL" {
+		t.Errorf("Expected model from the environment, got %s", cfg.Source["model"])
+	}
+}
@@ -1,9 +1,52 @@
 package config
 
+import (
+	"log/slog"
+	"time"
+)
+
 const (
 	// DefaultModel is the Claude model used for session analysis
 	DefaultModel = "claude-haiku-4-5-20251001"
 
 	// DefaultTimeout is the command timeout in minutes
 	DefaultTimeout = 10 // minutes
+
+	// DefaultRetryBaseDelay is the delay before the first retry of a failed CLI call
+	DefaultRetryBaseDelay = 1 * time.Second
+
+	// DefaultRetryMaxDelay caps the exponentially growing delay between retries
+	DefaultRetryMaxDelay = 30 * time.Second
+
+	// DefaultMaxInputTokens is the largest estimated session size sent to the model in
+	// one prompt, leaving room in the context window for instructions and the reply
+	DefaultMaxInputTokens = 150000
+
+	// DefaultAnalysisDirLayout names the dated subdirectories of the analysis directory
+	DefaultAnalysisDirLayout = "2006-01-02"
+
+	// LegacyAnalysisDirLayout is the MMDDYY layout used before the layout was
+	// configurable; ANALYSIS_DIR_LAYOUT=legacy selects it to keep existing directories
+	LegacyAnalysisDirLayout = "010206"
+
+	// DefaultLogLevel is the minimum level of diagnostic log records written to stderr
+	DefaultLogLevel = "warn"
+
+	// BackendCLI sends prompts through the Claude CLI binary
+	BackendCLI = "cli"
+
+	// BackendAnthropic sends prompts to the Anthropic Messages API over HTTP
+	BackendAnthropic = "anthropic"
+
+	// AnthropicAPIKeyEnv holds the API key used by the anthropic backend
+	AnthropicAPIKeyEnv = "ANTHROPIC_API_KEY"
+
+	// DefaultBackend is the model backend used when CLAUDE_BACKEND is unset
+	DefaultBackend = BackendCLI
 )
+
+// LogLevelOff is above every level records are logged at, so it disables logging
+const LogLevelOff = slog.LevelError + 4
+
+// Backends lists the values accepted for CLAUDE_BACKEND
+var Backends = []string{BackendCLI, BackendAnthropic}
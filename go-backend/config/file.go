@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigSource records where each setting's value came from, keyed by the setting's
+// config file name (e.g. "model"). Values are "env:<VAR>", "file:<path>", or "default".
+type ConfigSource map[string]string
+
+// settingEnvVars maps each config file key to the environment variable that overrides it
+var settingEnvVars = map[string]string{
+	"binary_path":         "CLAUDE_BINARY_PATH",
+	"model":               "CLAUDE_MODEL",
+	"timeout":             "CLAUDE_TIMEOUT",
+	"retry_base_delay":    "CLAUDE_RETRY_BASE_DELAY",
+	"retry_max_delay":     "CLAUDE_RETRY_MAX_DELAY",
+	"skip_binary_check":   "CLAUDE_SKIP_BINARY_CHECK",
+	"extra_args":          "CLAUDE_EXTRA_ARGS",
+	"max_input_tokens":    "CLAUDE_MAX_INPUT_TOKENS",
+	"backend":             "CLAUDE_BACKEND",
+	"log_level":           "SESSION_VIEWER_LOG_LEVEL",
+	"analysis_dir":        "ANALYSIS_DIR",
+	"analysis_dir_layout": "ANALYSIS_DIR_LAYOUT",
+	"filter_rules_file":   "SESSION_VIEWER_FILTER_RULES",
+	"redact_rules_file":   "SESSION_VIEWER_REDACT_RULES",
+	"profiles_file":       "SESSION_VIEWER_PROFILES",
+	"cache_dir":           "SESSION_VIEWER_CACHE_DIR",
+	"prompts_dir":         "SESSION_VIEWER_PROMPTS_DIR",
+}
+
+// configFilePath returns the config file to read: SESSION_VIEWER_CONFIG if set,
+// otherwise config.yaml in the app directory, or config.toml if only that exists
+func configFilePath(homeDir string) string {
+	if path := os.Getenv("SESSION_VIEWER_CONFIG"); path != "" {
+		return ExpandPath(path)
+	}
+	appDir := filepath.Join(homeDir, ".universal-session-viewer")
+	yamlPath := filepath.Join(appDir, "config.yaml")
+	if _, err := os.Stat(yamlPath); err != nil {
+		tomlPath := filepath.Join(appDir, "config.toml")
+		if _, err := os.Stat(tomlPath); err == nil {
+			return tomlPath
+		}
+	}
+	return yamlPath
+}
+
+// parseConfigFile reads flat "key: value" (YAML) or "key = value" (TOML) settings.
+// Blank lines and # comments are ignored and values may be quoted. Nested sections
+// and unknown keys are rejected so typos don't silently fall back to defaults.
+// A missing file yields no settings.
+func parseConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer file.Close()
+
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" || line == "---" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("%s:%d: sections are not supported", path, lineNum)
+		}
+
+		sep := strings.IndexAny(line, ":=")
+		if sep < 0 {
+			return nil, fmt.Errorf("%s:%d: expected key: value", path, lineNum)
+		}
+		key := strings.TrimSpace(line[:sep])
+		if _, ok := settingEnvVars[key]; !ok {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, lineNum, key)
+		}
+		settings[key] = unquote(strings.TrimSpace(line[sep+1:]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return settings, nil
+}
+
+// stripComment removes a # comment that isn't inside a quoted value
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote strips matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// settingResolver looks up settings by precedence: env var > config file > default,
+// recording the winning source of each
+type settingResolver struct {
+	file     map[string]string
+	filePath string
+	source   ConfigSource
+}
+
+// lookup returns the value of the named setting and a label identifying where it came
+// from, for use in error messages
+func (r *settingResolver) lookup(name, defaultValue string) (string, string) {
+	envVar := settingEnvVars[name]
+	if value := os.Getenv(envVar); value != "" {
+		r.source[name] = "env:" + envVar
+		return value, envVar
+	}
+	if value, ok := r.file[name]; ok && value != "" {
+		r.source[name] = "file:" + r.filePath
+		return value, fmt.Sprintf("%s in %s", name, r.filePath)
+	}
+	r.source[name] = "default"
+	return defaultValue, name
+}
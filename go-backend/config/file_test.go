@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes a config file and points SESSION_VIEWER_CONFIG at it
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	t.Setenv("SESSION_VIEWER_CONFIG", path)
+	return path
+}
+
+// TestLoadConfigFile tests precedence of env var > file > default and source tracking
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `# Session viewer settings
+model: "file-model"
+timeout: 20m   # huge sessions
+analysis_dir: /file/analysis
+binary_path: 'file-claude'
+`)
+	t.Setenv("CLAUDE_BINARY_PATH", "/env/claude")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Claude.Model != "file-model" {
+		t.Errorf("Expected model from file, got %q", cfg.Claude.Model)
+	}
+	if cfg.Claude.Timeout != 20*time.Minute {
+		t.Errorf("Expected timeout from file, got %v", cfg.Claude.Timeout)
+	}
+	if cfg.Paths.AnalysisDir != "/file/analysis" {
+		t.Errorf("Expected analysis dir from file, got %q", cfg.Paths.AnalysisDir)
+	}
+	if cfg.Claude.BinaryPath != "/env/claude" {
+		t.Errorf("Expected env var to override file, got %q", cfg.Claude.BinaryPath)
+	}
+
+	expectedSources := map[string]string{
+		"model":            "file:" + path,
+		"timeout":          "file:" + path,
+		"binary_path":      "env:CLAUDE_BINARY_PATH",
+		"retry_base_delay": "default",
+	}
+	for name, expected := range expectedSources {
+		if cfg.Source[name] != expected {
+			t.Errorf("Source[%q] = %q, want %q", name, cfg.Source[name], expected)
+		}
+	}
+}
+
+// TestLoadConfigTOMLFile tests key = value syntax
+func TestLoadConfigTOMLFile(t *testing.T) {
+	writeConfigFile(t, "config.toml", `model = "toml-model"
+retry_max_delay = "10s"
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Claude.Model != "toml-model" || cfg.Claude.RetryMaxDelay != 10*time.Second {
+		t.Errorf("Unexpected config: %+v", cfg.Claude)
+	}
+}
+
+// TestLoadConfigMissingFile tests that an absent config file falls back to defaults
+func TestLoadConfigMissingFile(t *testing.T) {
+	t.Setenv("SESSION_VIEWER_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Claude.Model != DefaultModel || cfg.Source["model"] != "default" {
+		t.Errorf("Expected default model, got %q from %q", cfg.Claude.Model, cfg.Source["model"])
+	}
+}
+
+// TestLoadConfigFileErrors tests rejection of malformed files and invalid values
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		errContains string
+	}{
+		{name: "Unknown key", content: "modle: x\n", errContains: `unknown setting "modle"`},
+		{name: "Section", content: "[claude]\nmodel = x\n", errContains: "sections are not supported"},
+		{name: "No separator", content: "model\n", errContains: "expected key: value"},
+		{name: "Invalid timeout", content: "timeout: forever\n", errContains: "invalid timeout in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, "config.yaml", tt.content)
+			_, err := LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Profile bundles analysis settings under a name selected with --profile.
+// Zero values leave the corresponding default in place.
+type Profile struct {
+	Model       string `json:"model,omitempty"`        // Overrides Claude.Model
+	MaxRetries  int    `json:"max_retries,omitempty"`  // Analysis attempts before giving up
+	RedactPaths bool   `json:"redact_paths,omitempty"` // Mask home-directory paths in output
+
+	Tier   int    `json:"tier,omitempty"`   // Structured analysis tier (1 direct, 2 or 3 windowed) instead of one chosen by size
+	Output string `json:"output,omitempty"` // Output format, as with --output: json, pretty, text, or compact
+}
+
+// MaxTier is the highest processing tier a profile or --tier can select
+const MaxTier = 3
+
+// LoadProfile reads the named profile from a JSON file mapping profile names to settings, e.g.
+//
+//	{"quick": {"max_retries": 1, "output": "text"}, "deep": {"model": "claude-sonnet-4-5", "max_retries": 5, "tier": 2}}
+func LoadProfile(path, name string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles %s: %w", path, err)
+	}
+
+	var profiles map[string]*Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+
+	profile, ok := profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	if profile.Tier < 0 || profile.Tier > MaxTier {
+		return nil, fmt.Errorf("profile %q in %s has invalid tier %d (expected 1 to %d)", name, path, profile.Tier, MaxTier)
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProfiles writes a profiles file to a temp directory
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write profiles: %v", err)
+	}
+	return path
+}
+
+// TestLoadProfile tests loading a named profile
+func TestLoadProfile(t *testing.T) {
+	path := writeProfiles(t, `{
+		"quick": {"max_retries": 1, "output": "text"},
+		"deep": {"model": "claude-sonnet-4-5", "max_retries": 5, "redact_paths": true, "tier": 2}
+	}`)
+
+	profile, err := LoadProfile(path, "deep")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+
+	if profile.Model != "claude-sonnet-4-5" {
+		t.Errorf("Expected model %q, got %q", "claude-sonnet-4-5", profile.Model)
+	}
+	if profile.MaxRetries != 5 {
+		t.Errorf("Expected max retries 5, got %d", profile.MaxRetries)
+	}
+	if !profile.RedactPaths {
+		t.Error("Expected redact_paths to be true")
+	}
+	if profile.Tier != 2 || profile.Output != "" {
+		t.Errorf("Expected tier 2 and no output format, got %d and %q", profile.Tier, profile.Output)
+	}
+
+	quick, err := LoadProfile(path, "quick")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+	if quick.Model != "" {
+		t.Errorf("Expected unset model in quick profile, got %q", quick.Model)
+	}
+	if quick.Tier != 0 || quick.Output != "text" {
+		t.Errorf("Expected no tier and text output, got %d and %q", quick.Tier, quick.Output)
+	}
+}
+
+// TestLoadProfileErrors tests missing files, malformed files, and unknown names
+func TestLoadProfileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		profile     string
+		expectError string
+	}{
+		{
+			name:        "Missing file",
+			path:        "/nonexistent/profiles.json",
+			profile:     "quick",
+			expectError: "failed to read profiles",
+		},
+		{
+			name:        "Malformed file",
+			path:        writeProfiles(t, "{not json"),
+			profile:     "quick",
+			expectError: "failed to parse profiles",
+		},
+		{
+			name:        "Unknown profile",
+			path:        writeProfiles(t, `{"quick": {"max_retries": 1}}`),
+			profile:     "debug",
+			expectError: `profile "debug" not found`,
+		},
+		{
+			name:        "Invalid tier",
+			path:        writeProfiles(t, `{"quick": {"tier": 4}}`),
+			profile:     "quick",
+			expectError: "invalid tier 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadProfile(tt.path, tt.profile)
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package cache stores model responses on disk so repeated analyses of unchanged
+// content don't call the model again.
+//
+// Each entry is a JSON file named <key>.json in the cache directory, where the key is
+// a SHA-256 hash of everything that affects the response (see Key).
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// entrySuffix is the file extension of cache entries
+const entrySuffix = ".json"
+
+// now returns the current time; tests replace it to pin the clock
+var now = time.Now
+
+// Entry is one cached model response
+type Entry struct {
+	Key       string    `json:"key"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	Response  string    `json:"response"`
+}
+
+// EntryInfo describes a cached entry without its response
+type EntryInfo struct {
+	Key        string    `json:"key"`
+	Model      string    `json:"model,omitempty"`
+	SizeBytes  int64     `json:"size_bytes"`
+	CreatedAt  time.Time `json:"created_at"`
+	AgeSeconds float64   `json:"age_seconds"`
+}
+
+// Store reads and writes cache entries in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store for dir; the directory is created on the first Put
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the cache directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key derives a cache key from the values that determine a response, such as the
+// model, prompt variant, and content
+func Key(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// Get returns the entry stored under key. A missing or unreadable entry is a miss.
+func (s *Store) Get(key string) (*Entry, bool) {
+	entry, err := readEntry(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores entry under entry.Key, stamping CreatedAt if unset. The file is written
+// to a temporary name and renamed so concurrent readers never see a partial entry.
+func (s *Store) Put(entry Entry) error {
+	if entry.Key == "" {
+		return errors.New("cache entry has no key")
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now().UTC()
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, entry.Key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path(entry.Key))
+}
+
+// List describes every entry, oldest first. A missing cache directory has no entries.
+// Entries that can't be decoded are still listed, dated by their modification time.
+func (s *Store) List() ([]EntryInfo, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []EntryInfo{}, nil
+		}
+		return nil, err
+	}
+
+	current := now()
+	infos := []EntryInfo{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, entrySuffix) {
+			continue
+		}
+		stat, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		info := EntryInfo{
+			Key:       strings.TrimSuffix(name, entrySuffix),
+			SizeBytes: stat.Size(),
+			CreatedAt: stat.ModTime().UTC(),
+		}
+		if entry, err := readEntry(filepath.Join(s.dir, name)); err == nil {
+			info.Model = entry.Model
+			if !entry.CreatedAt.IsZero() {
+				info.CreatedAt = entry.CreatedAt
+			}
+		}
+		info.AgeSeconds = current.Sub(info.CreatedAt).Seconds()
+		infos = append(infos, info)
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos, nil
+}
+
+// Clear removes entries older than olderThan, or every entry when olderThan is 0,
+// and returns how many were removed
+func (s *Store) Clear(olderThan time.Duration) (int, error) {
+	infos, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, info := range infos {
+		if olderThan > 0 && info.AgeSeconds < olderThan.Seconds() {
+			continue
+		}
+		if err := os.Remove(s.path(info.Key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path returns the file an entry is stored in
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+entrySuffix)
+}
+
+// readEntry decodes an entry file
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
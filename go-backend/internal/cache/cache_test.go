@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPutGet tests that a stored entry is read back and a different key misses
+func TestPutGet(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "cache"))
+	key := Key("model", "en", "content")
+
+	if _, ok := store.Get(key); ok {
+		t.Fatal("Expected a miss before Put")
+	}
+	if err := store.Put(Entry{Key: key, Model: "model", Response: "summary"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	entry, ok := store.Get(key)
+	if !ok {
+		t.Fatal("Expected a hit after Put")
+	}
+	if entry.Response != "summary" || entry.Model != "model" || entry.CreatedAt.IsZero() {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if _, ok := store.Get(Key("model", "en", "other content")); ok {
+		t.Error("Expected a miss for different content")
+	}
+	if Key("a", "bc") == Key("ab", "c") {
+		t.Error("Expected key parts to be separated")
+	}
+}
+
+// TestListAndClear tests listing entries by age and clearing with and without a cutoff
+func TestListAndClear(t *testing.T) {
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return base }
+
+	dir := t.TempDir()
+	store := NewStore(dir)
+	for _, entry := range []Entry{
+		{Key: "new", Model: "m1", CreatedAt: base.Add(-time.Hour), Response: "a"},
+		{Key: "old", Model: "m2", CreatedAt: base.Add(-72 * time.Hour), Response: "b"},
+	} {
+		if err := store.Put(entry); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	// Files that aren't entries are ignored
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+
+	infos, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Key != "old" || infos[1].Key != "new" {
+		t.Fatalf("Expected old then new, got %+v", infos)
+	}
+	if infos[0].Model != "m2" || infos[0].SizeBytes == 0 || infos[0].AgeSeconds != 72*3600 {
+		t.Errorf("Unexpected info: %+v", infos[0])
+	}
+
+	removed, err := store.Clear(24 * time.Hour)
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected 1 removed, got %d (%v)", removed, err)
+	}
+	if _, ok := store.Get("new"); !ok {
+		t.Error("Expected the recent entry to survive")
+	}
+
+	removed, err = store.Clear(0)
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected 1 removed, got %d (%v)", removed, err)
+	}
+}
+
+// TestListMissingDir tests that a cache that was never written is empty
+func TestListMissingDir(t *testing.T) {
+	infos, err := NewStore(filepath.Join(t.TempDir(), "missing")).List()
+	if err != nil || len(infos) != 0 {
+		t.Errorf("Expected no entries, got %v (%v)", infos, err)
+	}
+}
@@ -0,0 +1,13 @@
+package llm
+
+import "context"
+
+// Analyzer sends prompts to a model backend. *claude.Wrapper implements it over the
+// Claude CLI; other backends (e.g. an HTTP API client) implement the same contract.
+type Analyzer interface {
+	// SendConversationalPrompt returns the model's raw text reply to prompt
+	SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error)
+
+	// SendStructuredPrompt asks for Analysis JSON and returns the validated reply
+	SendStructuredPrompt(ctx context.Context, prompt string, sessionID string) (*Analysis, error)
+}
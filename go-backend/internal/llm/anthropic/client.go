@@ -0,0 +1,234 @@
+// Package anthropic implements llm.Analyzer over the Anthropic Messages API, for
+// environments that have an API key but not the Claude CLI.
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/retry"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+const (
+	// DefaultBaseURL is the API endpoint used unless ANTHROPIC_BASE_URL is set
+	DefaultBaseURL = "https://api.anthropic.com"
+
+	// APIVersion is sent as the anthropic-version header
+	APIVersion = "2023-06-01"
+
+	// DefaultMaxTokens bounds the length of each reply
+	DefaultMaxTokens = 4096
+)
+
+// Client is the HTTP implementation of llm.Analyzer. Timeouts and empty replies are
+// reported as llm.ErrTimeout and llm.ErrEmptyResponse, so callers handle both
+// backends alike.
+type Client struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	timeout    time.Duration
+	httpClient *http.Client
+	processing llm.ProcessingConfig
+	validation validator.Options
+}
+
+var _ llm.Analyzer = (*Client)(nil)
+
+// NewClient creates a client for cfg's model and timeout, reading the API key from
+// ANTHROPIC_API_KEY and the endpoint from ANTHROPIC_BASE_URL (default DefaultBaseURL)
+func NewClient(cfg *config.Config) (*Client, error) {
+	apiKey := os.Getenv(config.AnthropicAPIKeyEnv)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%s is not set; it is required by the %s backend", config.AnthropicAPIKeyEnv, config.BackendAnthropic)
+	}
+
+	baseURL := DefaultBaseURL
+	if value := os.Getenv("ANTHROPIC_BASE_URL"); value != "" {
+		baseURL = strings.TrimSuffix(value, "/")
+	}
+
+	return &Client{
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		model:      cfg.Claude.Model,
+		timeout:    cfg.Claude.Timeout,
+		httpClient: &http.Client{},
+		processing: llm.ProcessingConfig{
+			MaxRetries: retry.DefaultStructuredRetries,
+		},
+	}, nil
+}
+
+// SetProcessingConfig replaces the processing settings used for structured prompts
+func (c *Client) SetProcessingConfig(processing llm.ProcessingConfig) {
+	c.processing = processing
+}
+
+// SetModel makes every request use model instead of the configured one. An empty model
+// keeps the current one.
+func (c *Client) SetModel(model string) {
+	if model != "" {
+		c.model = model
+	}
+}
+
+// SetValidationOptions adds requirements that structured replies must meet
+func (c *Client) SetValidationOptions(opts validator.Options) {
+	c.validation = opts
+}
+
+// APIError is an error response from the Messages API
+type APIError struct {
+	StatusCode int
+	Type       string // e.g. "rate_limit_error", "overloaded_error"
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("anthropic API request failed: status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("anthropic API request failed: status %d, %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// messagesRequest is the body of POST /v1/messages
+type messagesRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []message `json:"messages"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// messagesResponse is the subset of a Messages API reply the client reads
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SendConversationalPrompt sends prompt as a single user message and returns the
+// reply's text blocks joined together. The API is stateless, so sessionID is unused.
+func (c *Client) SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error) {
+	body, err := json.Marshal(messagesRequest{
+		Model:     c.model,
+		MaxTokens: DefaultMaxTokens,
+		Messages:  []message{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", APIVersion)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if reqCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return "", fmt.Errorf("%w after %v", llm.ErrTimeout, c.timeout)
+		}
+		return "", fmt.Errorf("anthropic API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if reqCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return "", fmt.Errorf("%w after %v", llm.ErrTimeout, c.timeout)
+		}
+		return "", fmt.Errorf("anthropic API request failed: %w", err)
+	}
+
+	var decoded messagesResponse
+	decodeErr := json.Unmarshal(data, &decoded)
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		if decodeErr == nil && decoded.Error != nil {
+			apiErr.Type, apiErr.Message = decoded.Error.Type, decoded.Error.Message
+		}
+		return "", apiErr
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("anthropic API returned invalid JSON: %w", decodeErr)
+	}
+
+	var text strings.Builder
+	for _, block := range decoded.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", llm.ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+// SendWithRetry sends a conversational prompt, retrying transient failures (see
+// IsRetryable) with the same backoff as the CLI backend
+func (c *Client) SendWithRetry(ctx context.Context, prompt string, sessionID string, cfg llm.ProcessingConfig) (string, error) {
+	return retry.Do(ctx, cfg, IsRetryable, func() (string, error) {
+		return c.SendConversationalPrompt(ctx, prompt, sessionID)
+	})
+}
+
+// SendStructuredPrompt asks for Analysis JSON and validates the reply, resending the
+// prompt with a corrective instruction as the CLI backend does
+func (c *Client) SendStructuredPrompt(ctx context.Context, prompt string, sessionID string) (*llm.Analysis, error) {
+	return retry.SendStructured(ctx, c, prompt, sessionID, c.processing, c.validation)
+}
+
+// IsRetryable reports whether an API failure may succeed if tried again: timeouts,
+// empty replies, network errors, rate limiting, and server-side errors. Client errors
+// such as a bad API key and cancellation are permanent.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, llm.ErrTimeout) || errors.Is(err, llm.ErrEmptyResponse) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode == http.StatusConflict,
+			apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode >= 500:
+			return true
+		}
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,190 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// validAnalysisJSON is a structured reply that passes validation
+const validAnalysisJSON = `{"episodes":[{"id":"ep1","phase":"implementation","confidence":0.9,"description":"Built it","start_line":1,"end_line":5}],"patterns":{},"recommendations":["Add tests"],"metadata":{}}`
+
+// textReply is a Messages API success body carrying text
+func textReply(text string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+	})
+	return string(data)
+}
+
+// newTestClient points a client at handler with a 5s timeout
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	t.Setenv(config.AnthropicAPIKeyEnv, "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL+"/")
+	client, err := NewClient(&config.Config{Claude: config.ClaudeConfig{Model: "test-model", Timeout: 5 * time.Second}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return client
+}
+
+// TestNewClientRequiresKey tests that a missing ANTHROPIC_API_KEY is reported
+func TestNewClientRequiresKey(t *testing.T) {
+	t.Setenv(config.AnthropicAPIKeyEnv, "")
+	if _, err := NewClient(&config.Config{}); err == nil || !strings.Contains(err.Error(), config.AnthropicAPIKeyEnv) {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+}
+
+// TestSendConversationalPrompt tests the request the client sends and reply parsing
+func TestSendConversationalPrompt(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != APIVersion {
+			t.Errorf("Unexpected headers: %v", r.Header)
+		}
+
+		var req messagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		if req.Model != "test-model" || req.MaxTokens != DefaultMaxTokens || len(req.Messages) != 1 || req.Messages[0].Content != "Summarize this" {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"Part one. "},{"type":"tool_use"},{"type":"text","text":"Part two."}]}`)
+	})
+
+	response, err := client.SendConversationalPrompt(context.Background(), "Summarize this", "")
+	if err != nil {
+		t.Fatalf("SendConversationalPrompt failed: %v", err)
+	}
+	if response != "Part one. Part two." {
+		t.Errorf("Unexpected response %q", response)
+	}
+}
+
+// TestSendConversationalPromptErrors tests the mapping of failures onto CLI error shapes
+func TestSendConversationalPromptErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		delay     time.Duration
+		wantErr   error
+		wantType  string
+		retryable bool
+	}{
+		{name: "Rate limited", status: 429, body: `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, wantType: "rate_limit_error", retryable: true},
+		{name: "Overloaded", status: 529, body: `{"type":"error","error":{"type":"overloaded_error","message":"busy"}}`, wantType: "overloaded_error", retryable: true},
+		{name: "Bad key", status: 401, body: `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, wantType: "authentication_error"},
+		{name: "Non-JSON error", status: 502, body: "Bad Gateway", retryable: true},
+		{name: "Empty reply", status: 200, body: `{"content":[]}`, wantErr: llm.ErrEmptyResponse, retryable: true},
+		{name: "Timeout", status: 200, body: textReply("late"), delay: 200 * time.Millisecond, wantErr: llm.ErrTimeout, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			client.timeout = 50 * time.Millisecond
+
+			_, err := client.SendConversationalPrompt(context.Background(), "prompt", "")
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Type != tt.wantType {
+					t.Errorf("Expected APIError %d %q, got %v", tt.status, tt.wantType, err)
+				}
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, !tt.retryable, tt.retryable)
+			}
+		})
+	}
+}
+
+// TestSendWithRetry tests that transient API failures are retried and permanent ones are not
+func TestSendWithRetry(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(529)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"overloaded_error","message":"busy"}}`)
+			return
+		}
+		fmt.Fprint(w, textReply("Recovered."))
+	})
+
+	response, err := client.SendWithRetry(context.Background(), "prompt", "", llm.ProcessingConfig{MaxRetries: 2, RetryDelay: time.Millisecond})
+	if err != nil || response != "Recovered." {
+		t.Fatalf("Expected recovery, got %q, %v", response, err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	denied := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	if _, err := denied.SendWithRetry(context.Background(), "prompt", "", llm.ProcessingConfig{MaxRetries: 2}); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a permanent failure not to be retried, got %d calls", calls)
+	}
+}
+
+// TestSendStructuredPrompt tests validation and the corrective retry
+func TestSendStructuredPrompt(t *testing.T) {
+	var prompts []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req messagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		prompts = append(prompts, req.Messages[0].Content)
+		if len(prompts) == 1 {
+			fmt.Fprint(w, textReply("Sure, here is my analysis!"))
+			return
+		}
+		fmt.Fprint(w, textReply(validAnalysisJSON))
+	})
+
+	analysis, err := client.SendStructuredPrompt(context.Background(), "Analyze", "")
+	if err != nil {
+		t.Fatalf("SendStructuredPrompt failed: %v", err)
+	}
+	if len(analysis.Episodes) != 1 || analysis.Metadata.HierarchicalInfo[llm.InfoPromptVariant] != llm.PromptVariantCorrective {
+		t.Errorf("Unexpected analysis: %+v", analysis)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "YOUR PREVIOUS RESPONSE WAS REJECTED") {
+		t.Errorf("Expected a corrective retry, got %q", prompts)
+	}
+}
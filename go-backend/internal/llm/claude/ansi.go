@@ -0,0 +1,95 @@
+package claude
+
+import "strings"
+
+// Control characters that start terminal escape sequences
+const (
+	esc = '\x1b'
+	bel = '\a'
+	csi = '\u009b' // Single-character form of ESC [
+)
+
+// stripANSI removes terminal escape sequences and carriage-return redraws from CLI
+// output, so a binary wrapper that colors its output or draws a progress spinner
+// returns the same text as the plain CLI. CSI (colors, cursor movement), OSC (window
+// titles, hyperlinks), and two-character escapes are dropped, including a sequence cut
+// off at the end of the output. Within each line only the text after the last carriage
+// return is kept, as a terminal would show it; CRLF line endings become LF.
+func stripANSI(s string) string {
+	if !strings.ContainsAny(s, "\x1b\u009b\r") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == csi:
+			i = skipCSI(runes, i+1)
+		case r != esc:
+			b.WriteRune(r)
+		case i+1 >= len(runes):
+			// Lone ESC at the end of the output
+		case runes[i+1] == '[':
+			i = skipCSI(runes, i+2)
+		case runes[i+1] == ']':
+			i = skipOSC(runes, i+2)
+		case runes[i+1] < 0x20:
+			// Stray ESC before a control character such as a newline; keep the newline
+		default:
+			// ESC, any intermediate bytes (e.g. "(" in a charset switch), then one final
+			i++
+			for i < len(runes)-1 && runes[i] >= 0x20 && runes[i] <= 0x2f {
+				i++
+			}
+		}
+	}
+
+	return collapseCarriageReturns(b.String())
+}
+
+// skipCSI returns the index of the final character of a CSI sequence whose parameters
+// start at i, or the last index if the sequence is unterminated
+func skipCSI(runes []rune, i int) int {
+	for ; i < len(runes); i++ {
+		if r := runes[i]; r >= 0x40 && r <= 0x7e {
+			return i
+		}
+		// A character that can't appear in a CSI sequence ends a malformed one early
+		if r := runes[i]; r < 0x20 || r > 0x3f {
+			return i - 1
+		}
+	}
+	return len(runes) - 1
+}
+
+// skipOSC returns the index of the terminator of an OSC sequence whose payload starts
+// at i (BEL, or the backslash of ESC \), or the last index if it is unterminated
+func skipOSC(runes []rune, i int) int {
+	for ; i < len(runes); i++ {
+		if runes[i] == bel {
+			return i
+		}
+		if runes[i] == esc && i+1 < len(runes) && runes[i+1] == '\\' {
+			return i + 1
+		}
+	}
+	return len(runes) - 1
+}
+
+// collapseCarriageReturns keeps, for each line, the text after its last carriage return
+func collapseCarriageReturns(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if cr := strings.LastIndexByte(line, '\r'); cr >= 0 {
+			line = line[cr+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
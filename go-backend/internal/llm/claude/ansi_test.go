@@ -0,0 +1,69 @@
+package claude
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestStripANSI tests colors, cursor control, OSC sequences, partial sequences, and
+// carriage-return spinners
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Plain text", "Domain: Go", "Domain: Go"},
+		{"Colors", "\x1b[1;32m**Domain**\x1b[0m: Go\x1b[m", "**Domain**: Go"},
+		{"256 and truecolor", "\x1b[38;5;208mA\x1b[38;2;255;0;0mB\x1b[0m", "AB"},
+		{"Cursor and erase", "\x1b[2K\x1b[1GSummary\x1b[?25h", "Summary"},
+		{"Single-character CSI", "\u009b31mred\u009b0m", "red"},
+		{"OSC title with BEL", "\x1b]0;claude\aSummary", "Summary"},
+		{"OSC hyperlink with ST", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"Charset switch", "\x1b(BText\x1b=", "Text"},
+		{"Truncated CSI", "Summary\x1b[38;5", "Summary"},
+		{"Truncated OSC", "Summary\x1b]0;tit", "Summary"},
+		{"Lone ESC", "Summary\x1b", "Summary"},
+		{"ESC before newline", "one\x1b\ntwo", "one\ntwo"},
+		{"Malformed CSI", "\x1b[12\nnext", "\nnext"},
+		{"Spinner", "Thinking |\rThinking /\r\x1b[KDomain: Go\nDone", "Domain: Go\nDone"},
+		{"CRLF", "line one\r\nline two\r\n", "line one\nline two\n"},
+		{"Unicode kept", "\x1b[1mrésumé 日本\x1b[0m", "résumé 日本"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripANSI(tt.input); got != tt.want {
+				t.Errorf("stripANSI(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSendConversationalPromptStripsANSI tests that colored CLI output is returned as
+// plain text, and that output with nothing but escapes counts as empty
+func TestSendConversationalPromptStripsANSI(t *testing.T) {
+	binary, _ := writeFakeClaude(t, "\x1b[?25l⠋ Working\r\x1b[2K\x1b[32mDomain\x1b[0m: Go backend\n", "\x1b[0m\x1b[?25h")
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+
+	response, err := wrapper.SendConversationalPrompt(context.Background(), "Summarize", "")
+	if err != nil {
+		t.Fatalf("SendConversationalPrompt failed: %v", err)
+	}
+	if response != "Domain: Go backend\n" {
+		t.Errorf("Expected escapes stripped, got %q", response)
+	}
+
+	if _, err := wrapper.SendConversationalPrompt(context.Background(), "Summarize", ""); err != llm.ErrEmptyResponse {
+		t.Errorf("Expected ErrEmptyResponse for escape-only output, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger drops every record; it is used until SetLogger provides a logger
+var discardLogger = slog.New(discardHandler{})
+
+// discardHandler is a slog.Handler that is never enabled
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// SetLogger sets where the wrapper reports temp directory housekeeping: debug records
+// for directories created and removed, warnings for cleanup failures
+func (w *Wrapper) SetLogger(logger *slog.Logger) {
+	w.logger = logger
+}
+
+// log returns the wrapper's logger, or one that discards everything if none is set
+func (w *Wrapper) log() *slog.Logger {
+	if w.logger == nil {
+		return discardLogger
+	}
+	return w.logger
+}
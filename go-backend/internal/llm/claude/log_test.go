@@ -0,0 +1,102 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// recordingHandler keeps every record it handles so tests can assert on them
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// find returns the first record with the given level and message
+func (h *recordingHandler) find(level slog.Level, message string) (slog.Record, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Level == level && r.Message == message {
+			return r, true
+		}
+	}
+	return slog.Record{}, false
+}
+
+// recordAttr returns the string value of a record attribute
+func recordAttr(r slog.Record, key string) string {
+	var value string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == key {
+			value = a.Value.String()
+			return false
+		}
+		return true
+	})
+	return value
+}
+
+// TestWrapperLogsTempDirectories tests debug records for temp directory housekeeping
+func TestWrapperLogsTempDirectories(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	handler := &recordingHandler{}
+	w := &Wrapper{}
+	w.SetLogger(slog.New(handler))
+
+	dir, err := w.createTempAnalysisDirectory("log-test")
+	if err != nil {
+		t.Fatalf("createTempAnalysisDirectory failed: %v", err)
+	}
+	w.cleanupTempAnalysisDirectory(dir, "log-test")
+
+	for _, message := range []string{"Created temporary analysis directory", "Cleaned up temporary analysis directory"} {
+		record, ok := handler.find(slog.LevelDebug, message)
+		if !ok {
+			t.Errorf("Expected debug record %q, got %d records", message, len(handler.records))
+			continue
+		}
+		if recordAttr(record, "dir") != dir {
+			t.Errorf("Expected dir attribute %q on %q, got %q", dir, message, recordAttr(record, "dir"))
+		}
+	}
+}
+
+// TestWrapperLogsCleanupFailures tests that cleanup problems are logged as warnings
+func TestWrapperLogsCleanupFailures(t *testing.T) {
+	t.Setenv("HOME", "")
+	handler := &recordingHandler{}
+	w := &Wrapper{}
+	w.SetLogger(slog.New(handler))
+
+	w.cleanupTempAnalysisDirectory(t.TempDir(), "no-home")
+
+	record, ok := handler.find(slog.LevelWarn, "Could not get home directory for session cleanup")
+	if !ok {
+		t.Fatalf("Expected a warning record, got %d records", len(handler.records))
+	}
+	if recordAttr(record, "error") == "" {
+		t.Error("Expected an error attribute on the warning")
+	}
+}
+
+// TestWrapperWithoutLogger tests that a wrapper without a logger discards records
+func TestWrapperWithoutLogger(t *testing.T) {
+	w := &Wrapper{}
+	if w.log().Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected the default logger to discard every record")
+	}
+}
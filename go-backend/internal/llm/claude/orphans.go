@@ -0,0 +1,50 @@
+package claude
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempDirPrefix names the per-call temp directories created by createTempAnalysisDirectory
+const tempDirPrefix = "claude-analysis-"
+
+// DefaultOrphanAge is how old a temp directory must be before CleanupOrphanedTempDirs
+// treats it as abandoned rather than in use by a running analysis
+const DefaultOrphanAge = time.Hour
+
+// CleanupOrphanedTempDirs removes claude-analysis-* directories in os.TempDir() last
+// modified more than maxAge ago, left behind when session-viewer was killed before
+// cleaning up after a call, along with their ~/.claude/projects session files.
+// Removals and failures are reported to logger, which may be nil.
+// Returns the directories removed.
+func CleanupOrphanedTempDirs(maxAge time.Duration, logger *slog.Logger) ([]string, error) {
+	tempDir := os.TempDir()
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := []string{}
+	w := &Wrapper{logger: logger}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, tempDirPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		dir := filepath.Join(tempDir, name)
+		w.cleanupTempAnalysisDirectory(dir, strings.TrimPrefix(name, tempDirPrefix))
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			removed = append(removed, dir)
+		}
+	}
+	return removed, nil
+}
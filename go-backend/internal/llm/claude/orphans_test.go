@@ -0,0 +1,63 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCleanupOrphanedTempDirs tests that only old claude-analysis-* directories and
+// their Claude CLI session files are removed
+func TestCleanupOrphanedTempDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", t.TempDir())
+
+	old := time.Now().Add(-2 * time.Hour)
+	makeDir := func(name string, modTime time.Time) string {
+		dir := filepath.Join(os.TempDir(), name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set time on %s: %v", dir, err)
+		}
+		return dir
+	}
+
+	orphan := makeDir(tempDirPrefix+"orphan-id", old)
+	recent := makeDir(tempDirPrefix+"recent-id", time.Now())
+	unrelated := makeDir("other-old-dir", old)
+
+	w := &Wrapper{}
+	projectDir := filepath.Join(home, ".claude", "projects", w.sanitizeProjectPath(orphan))
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	sessionFile := filepath.Join(projectDir, "orphan-id.jsonl")
+	if err := os.WriteFile(sessionFile, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+
+	removed, err := CleanupOrphanedTempDirs(time.Hour, nil)
+	if err != nil {
+		t.Fatalf("CleanupOrphanedTempDirs failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != orphan {
+		t.Errorf("Expected only %s removed, got %v", orphan, removed)
+	}
+
+	for path, wantExists := range map[string]bool{
+		orphan:      false,
+		sessionFile: false,
+		projectDir:  false,
+		recent:      true,
+		unrelated:   true,
+	} {
+		_, err := os.Stat(path)
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s: expected exists=%v", path, wantExists)
+		}
+	}
+}
@@ -1,50 +1,145 @@
 package claude
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/retry"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
 )
 
+// terminateGracePeriod is how long a cancelled CLI call has to exit after SIGTERM
+// before it is killed; tests shorten it
+var terminateGracePeriod = 5 * time.Second
+
+// ErrSessionNotFound is returned when a session ID that wasn't made by NewSessionID has
+// no transcript in any analysis directory, so there is nothing to resume
+var ErrSessionNotFound = errors.New("session not found")
+
 // Wrapper provides interface to Claude CLI
 type Wrapper struct {
-	config *config.Config
+	config     *config.Config
+	processing llm.ProcessingConfig
+	validation validator.Options
+	logger     *slog.Logger
+	random     io.Reader // Source of session ID bytes (default: crypto/rand.Reader)
+	model      string    // Overrides config.Claude.Model when set
+
+	mu          sync.Mutex
+	newSessions map[string]bool // IDs from NewSessionID, which may not have a transcript yet
 }
 
+// Wrapper is the CLI implementation of llm.Analyzer
+var _ llm.Analyzer = (*Wrapper)(nil)
+
 // NewWrapper creates a Claude CLI wrapper with the given configuration
 func NewWrapper(cfg *config.Config) *Wrapper {
 	return &Wrapper{
 		config: cfg,
+		processing: llm.ProcessingConfig{
+			MaxRetries: retry.DefaultStructuredRetries,
+		},
+		random:      rand.Reader,
+		newSessions: make(map[string]bool),
+	}
+}
+
+// SetProcessingConfig replaces the processing settings used for structured prompts
+func (w *Wrapper) SetProcessingConfig(processing llm.ProcessingConfig) {
+	w.processing = processing
+}
+
+// SetRandomSource replaces the source session IDs are generated from, so tests can
+// inject a fixed reader and get reproducible IDs. The reader must be safe for the
+// wrapper's concurrent use.
+func (w *Wrapper) SetRandomSource(r io.Reader) {
+	w.random = r
+}
+
+// SetModel makes every call use model instead of the configured one, without changing
+// the configuration. An empty model restores the configured one.
+func (w *Wrapper) SetModel(model string) {
+	w.model = model
+}
+
+// Model returns the model calls are made with
+func (w *Wrapper) Model() string {
+	if w.model != "" {
+		return w.model
 	}
+	return w.config.Claude.Model
 }
 
-// generateSessionID creates a unique session ID for conversation tracking
+// SetValidationOptions adds requirements that structured replies must meet; a reply
+// that misses them is retried like any other invalid reply
+func (w *Wrapper) SetValidationOptions(opts validator.Options) {
+	w.validation = opts
+}
+
+// generateSessionID creates a unique session ID for conversation tracking: a random
+// (version 4, RFC 4122 variant) UUID
 func (w *Wrapper) generateSessionID() (string, error) {
+	random := w.random
+	if random == nil {
+		random = rand.Reader
+	}
 	bytes := make([]byte, 16)
-	if _, err := rand.Read(bytes); err != nil {
+	if _, err := io.ReadFull(random, bytes); err != nil {
 		return "", err
 	}
+	bytes[6] = bytes[6]&0x0f | 0x40 // Version 4
+	bytes[8] = bytes[8]&0x3f | 0x80 // Variant 10xx
 	return fmt.Sprintf("%x-%x-%x-%x-%x", bytes[0:4], bytes[4:6], bytes[6:8], bytes[8:10], bytes[10:16]), nil
 }
 
+// NewSessionID returns a fresh session ID. Passing it to SendConversationalPrompt starts
+// a conversation in the analysis directory that later prompts with the same ID continue.
+func (w *Wrapper) NewSessionID() (string, error) {
+	id, err := w.generateSessionID()
+	if err != nil {
+		return "", err
+	}
+	w.mu.Lock()
+	w.newSessions[id] = true
+	w.mu.Unlock()
+	return id, nil
+}
+
+// isNewSession reports whether sessionID came from NewSessionID
+func (w *Wrapper) isNewSession(sessionID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.newSessions[sessionID]
+}
+
 // createTempAnalysisDirectory creates a temporary directory for analysis session
 func (w *Wrapper) createTempAnalysisDirectory(sessionID string) (string, error) {
-	tempDir := filepath.Join(os.TempDir(), "claude-analysis-"+sessionID)
+	tempDir := filepath.Join(os.TempDir(), tempDirPrefix+sessionID)
 
 	err := os.MkdirAll(tempDir, 0755)
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp analysis directory %s: %w", tempDir, err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Created temporary analysis directory: %s\n", tempDir)
+	w.log().Debug("Created temporary analysis directory", "dir", tempDir)
 	return tempDir, nil
 }
 
@@ -52,29 +147,26 @@ func (w *Wrapper) createTempAnalysisDirectory(sessionID string) (string, error)
 // as well as the specific Claude CLI session file created in ~/.claude/projects/
 func (w *Wrapper) cleanupTempAnalysisDirectory(tempDir string, sessionID string) {
 	if err := os.RemoveAll(tempDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not cleanup temp analysis directory %s: %v\n", tempDir, err)
+		w.log().Warn("Could not clean up temporary analysis directory", "dir", tempDir, "error", err)
 	} else {
-		fmt.Fprintf(os.Stderr, "Cleaned up temporary analysis directory: %s\n", tempDir)
+		w.log().Debug("Cleaned up temporary analysis directory", "dir", tempDir)
 	}
 
 	// Also clean up the specific Claude CLI session file in ~/.claude/projects/
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not get home directory for session cleanup: %v\n", err)
+		w.log().Warn("Could not get home directory for session cleanup", "error", err)
 		return
 	}
 
-	// Convert temp dir path to Claude's sanitized format (e.g., /private/tmp/foo -> -private-tmp-foo)
-	sanitizedPath := w.sanitizeProjectPath(tempDir)
-	claudeProjectDir := filepath.Join(homeDir, ".claude", "projects", sanitizedPath)
-
 	// Remove only the specific session JSONL file
-	sessionFile := filepath.Join(claudeProjectDir, sessionID+".jsonl")
+	sessionFile := w.claudeSessionFile(homeDir, tempDir, sessionID)
+	claudeProjectDir := filepath.Dir(sessionFile)
 	if _, err := os.Stat(sessionFile); err == nil {
 		if err := os.Remove(sessionFile); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not cleanup Claude CLI session file %s: %v\n", sessionFile, err)
+			w.log().Warn("Could not clean up Claude CLI session file", "file", sessionFile, "error", err)
 		} else {
-			fmt.Fprintf(os.Stderr, "Cleaned up Claude CLI session file: %s\n", sessionFile)
+			w.log().Debug("Cleaned up Claude CLI session file", "file", sessionFile)
 		}
 	}
 
@@ -82,15 +174,21 @@ func (w *Wrapper) cleanupTempAnalysisDirectory(tempDir string, sessionID string)
 	entries, err := os.ReadDir(claudeProjectDir)
 	if err == nil && len(entries) == 0 {
 		if err := os.Remove(claudeProjectDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not cleanup empty Claude CLI project directory %s: %v\n", claudeProjectDir, err)
+			w.log().Warn("Could not clean up empty Claude CLI project directory", "dir", claudeProjectDir, "error", err)
 		} else {
-			fmt.Fprintf(os.Stderr, "Cleaned up empty Claude CLI project directory: %s\n", claudeProjectDir)
+			w.log().Debug("Cleaned up empty Claude CLI project directory", "dir", claudeProjectDir)
 		}
 	}
 }
 
+// claudeSessionFile returns where the Claude CLI keeps the transcript of a session run
+// in dir, converting dir to Claude's sanitized format (e.g., /private/tmp/foo -> -private-tmp-foo)
+func (w *Wrapper) claudeSessionFile(homeDir, dir, sessionID string) string {
+	return filepath.Join(homeDir, ".claude", "projects", w.sanitizeProjectPath(dir), sessionID+".jsonl")
+}
+
 // sanitizeProjectPath converts a file path to Claude Code's project directory format
-// Example: /Users/username/.universal-session-viewer/analysis/121025 -> -Users-username-.universal-session-viewer-analysis-121025
+// Example: /Users/username/.universal-session-viewer/analysis/2025-12-10 -> -Users-username-.universal-session-viewer-analysis-2025-12-10
 func (w *Wrapper) sanitizeProjectPath(path string) string {
 	// Remove leading slash and replace all path separators with dashes
 	sanitized := strings.TrimPrefix(path, "/")
@@ -100,12 +198,29 @@ func (w *Wrapper) sanitizeProjectPath(path string) string {
 }
 
 // getAnalysisDirectory creates and returns the analysis directory for today.
-// Uses date-based subdirectories (MMDDYY format) for organization.
+// Uses date-based subdirectories named by Paths.AnalysisDirLayout for organization.
+// Paths.AnalysisDir must be an absolute path, so a misconfiguration can't create
+// directories relative to wherever the process happens to run, and the dated
+// subdirectory must stay inside it.
 func (w *Wrapper) getAnalysisDirectory() (string, error) {
-	now := time.Now()
-	dateStr := now.Format("010206") // MMDDYY format
+	base := w.config.Paths.AnalysisDir
+	if base == "" {
+		return "", fmt.Errorf("analysis directory is not configured; set ANALYSIS_DIR")
+	}
+	if !filepath.IsAbs(base) {
+		return "", fmt.Errorf("analysis directory %q must be an absolute path", base)
+	}
+
+	layout := w.config.Paths.AnalysisDirLayout
+	if layout == "" {
+		layout = config.DefaultAnalysisDirLayout
+	}
+	dateStr := time.Now().Format(layout)
 
-	analysisDir := filepath.Join(w.config.Paths.AnalysisDir, dateStr)
+	analysisDir := filepath.Join(base, dateStr)
+	if rel, err := filepath.Rel(filepath.Clean(base), analysisDir); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("analysis directory %s for layout %q is outside %s", analysisDir, layout, base)
+	}
 
 	err := os.MkdirAll(analysisDir, 0755)
 	if err != nil {
@@ -116,12 +231,39 @@ func (w *Wrapper) getAnalysisDirectory() (string, error) {
 	err = w.setupAgentsDirectory(analysisDir)
 	if err != nil {
 		// Log warning but don't fail - agents are optional
-		fmt.Fprintf(os.Stderr, "warning: failed to setup agents directory: %v\n", err)
+		w.log().Warn("Could not set up agents directory", "dir", analysisDir, "error", err)
 	}
 
 	return analysisDir, nil
 }
 
+// findSessionDirectory returns the analysis directory the CLI ran sessionID in, or ""
+// if it has no transcript. Every directory under Paths.AnalysisDir is checked, not just
+// today's, so a session can be resumed after the date changes.
+func (w *Wrapper) findSessionDirectory(homeDir, sessionID string) (string, error) {
+	found := ""
+	err := filepath.WalkDir(w.config.Paths.AnalysisDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".claude" {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(w.claudeSessionFile(homeDir, path, sessionID)); err == nil {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search analysis directories for session %s: %w", sessionID, err)
+	}
+	return found, nil
+}
+
 // setupAgentsDirectory creates .claude/agents directory structure.
 // Agents are optional - errors don't fail the session.
 func (w *Wrapper) setupAgentsDirectory(analysisDir string) error {
@@ -136,67 +278,299 @@ func (w *Wrapper) setupAgentsDirectory(analysisDir string) error {
 	return nil
 }
 
+// promptSession is the working directory and session a single CLI call runs in
+type promptSession struct {
+	dir       string // Directory the CLI runs in
+	sessionID string
+	tempDir   string // Set when a throwaway directory was created for this call
+	resume    bool   // The CLI already has a transcript for sessionID, so continue it
+}
+
+// preparePromptSession resolves the directory and session ID for a CLI call. A given
+// session ID must pass llm.ValidateSessionID, and is resumed in whichever analysis
+// directory the CLI has already run it in. An ID from NewSessionID without a transcript
+// starts the session in today's analysis directory; any other unknown ID fails with
+// ErrSessionNotFound. Without an ID a new one is generated and the call runs in a
+// temporary directory so the main analysis directory isn't polluted; call cleanup when
+// the CLI has exited.
+func (w *Wrapper) preparePromptSession(sessionID string) (*promptSession, error) {
+	analysisDir, err := w.getAnalysisDirectory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analysis directory: %w", err)
+	}
+
+	session := &promptSession{dir: analysisDir, sessionID: sessionID}
+	if sessionID != "" {
+		// The ID names the session file, so it must not reach outside the CLI's directories
+		if err := llm.ValidateSessionID(sessionID); err != nil {
+			return nil, err
+		}
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dir, err := w.findSessionDirectory(homeDir, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case dir != "":
+			session.dir, session.resume = dir, true
+		case !w.isNewSession(sessionID):
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+		}
+		return session, nil
+	}
+
+	session.sessionID, err = w.generateSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	session.tempDir, err = w.createTempAnalysisDirectory(session.sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp analysis directory: %w", err)
+	}
+	session.dir = session.tempDir
+	return session, nil
+}
+
+// cleanup removes the temporary directory and session file if one was created
+func (w *Wrapper) cleanup(session *promptSession) {
+	if session.tempDir != "" {
+		w.cleanupTempAnalysisDirectory(session.tempDir, session.sessionID)
+	}
+}
+
+// commandArgs builds the CLI arguments for one call: the model and session, any
+// per-call flags, the configured extra args, and finally the prompt. The CLI refuses
+// --session-id for a session that already exists, so those are passed with --resume.
+func (w *Wrapper) commandArgs(session *promptSession, prompt string, flags ...string) []string {
+	sessionFlag := "--session-id"
+	if session.resume {
+		sessionFlag = "--resume"
+	}
+	args := []string{"--model", w.Model(), sessionFlag, session.sessionID}
+	args = append(args, flags...)
+	args = append(args, w.config.Claude.ExtraArgs...)
+	return append(args, "-p", prompt)
+}
+
+// terminateGracefully makes a cancelled or timed-out call send SIGTERM instead of
+// SIGKILL, so the CLI can stop its own child processes and close its session file.
+// A CLI still running after terminateGracePeriod is killed. Windows has no SIGTERM,
+// so there the process is killed right away as before.
+func terminateGracefully(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		if runtime.GOOS == "windows" {
+			return cmd.Process.Kill()
+		}
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = terminateGracePeriod
+}
+
+// PromptResult is the outcome of a CLI call
+type PromptResult struct {
+	Text   string // Response text from stdout
+	Stderr string // Anything the CLI wrote to stderr, such as rate-limit or deprecation notices
+}
+
 // SendConversationalPrompt sends a prompt and returns raw text response (no JSON validation).
 // Used for interactive conversations, not for structured analysis.
 // Handles temp directory cleanup, session ID generation, and timeout management.
 func (w *Wrapper) SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error) {
-	analysisDir, err := w.getAnalysisDirectory()
+	result, err := w.SendConversationalPromptVerbose(ctx, prompt, sessionID)
+	return result.Text, err
+}
+
+// SendConversationalPromptVerbose is like SendConversationalPrompt but also returns
+// the CLI's stderr, which a successful call may still use for warnings worth logging.
+// Stderr is set whenever the CLI ran, including when an error is returned.
+func (w *Wrapper) SendConversationalPromptVerbose(ctx context.Context, prompt string, sessionID string) (PromptResult, error) {
+	session, err := w.preparePromptSession(sessionID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get analysis directory: %w", err)
+		return PromptResult{}, err
 	}
 
 	cmdCtx, cancel := context.WithTimeout(ctx, w.config.Claude.Timeout)
 	defer cancel()
 
-	tempAnalysisDir := ""
+	cmd := exec.CommandContext(cmdCtx, w.config.Claude.BinaryPath, w.commandArgs(session, prompt)...)
 
-	// Build command - use session ID if provided, otherwise create new one
-	if sessionID == "" {
-		var err error
-		sessionID, err = w.generateSessionID()
-		if err != nil {
-			return "", fmt.Errorf("failed to generate session ID: %w", err)
-		}
+	cmd.Dir = session.dir
+	terminateGracefully(cmd)
 
-		// Create a temporary directory for this analysis to avoid polluting the main analysis directory
-		tempAnalysisDir, err = w.createTempAnalysisDirectory(sessionID)
-		if err != nil {
-			return "", fmt.Errorf("failed to create temp analysis directory: %w", err)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	w.cleanup(session)
+
+	result := PromptResult{Stderr: strings.TrimSpace(stripANSI(stderr.String()))}
+	if err != nil {
+		if cmdCtx.Err() == context.DeadlineExceeded {
+			return result, fmt.Errorf("%w after %v", llm.ErrTimeout, w.config.Claude.Timeout)
 		}
-		analysisDir = tempAnalysisDir // Use temp directory instead
+		return result, fmt.Errorf("claude command failed: %w, stderr: %s", err, stderr.String())
 	}
 
+	// A wrapper script may color its output or draw a spinner
+	result.Text = stripANSI(stdout.String())
+
+	if result.Text == "" {
+		return result, llm.ErrEmptyResponse
+	}
+
+	return result, nil
+}
+
+// streamEvent is the subset of a Claude CLI stream-json event that carries text
+type streamEvent struct {
+	Type    string `json:"type"`
+	Result  string `json:"result"`
+	Message struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"message"`
+	Event struct {
+		Delta struct {
+			Text string `json:"text"`
+		} `json:"delta"`
+	} `json:"event"`
+}
+
+// SendConversationalPromptStream is like SendConversationalPrompt but runs the CLI with
+// --output-format stream-json and calls onChunk with each piece of response text as it
+// arrives, so callers can show progress during long analyses. The full response text is
+// still returned once the process exits. Output lines that aren't JSON are passed
+// through as text.
+func (w *Wrapper) SendConversationalPromptStream(ctx context.Context, prompt string, sessionID string, onChunk func(string)) (string, error) {
+	session, err := w.preparePromptSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+	defer w.cleanup(session)
+
+	cmdCtx, cancel := context.WithTimeout(ctx, w.config.Claude.Timeout)
+	defer cancel()
+
 	cmd := exec.CommandContext(cmdCtx, w.config.Claude.BinaryPath,
-		"--model", w.config.Claude.Model,
-		"--session-id", sessionID,
-		"-p", prompt,
-	)
+		w.commandArgs(session, prompt, "--output-format", "stream-json", "--verbose")...)
 
-	cmd.Dir = analysisDir
+	cmd.Dir = session.dir
+	terminateGracefully(cmd)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err = cmd.Run()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to open claude stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("claude command failed: %w", err)
+	}
 
-	// Cleanup temporary directory and session file if we created one
-	if tempAnalysisDir != "" {
-		w.cleanupTempAnalysisDirectory(tempAnalysisDir, sessionID)
+	var streamed strings.Builder
+	result := ""
+	sawDeltas := false // With partial messages enabled, assistant events repeat the deltas
+	emit := func(text string) {
+		if text == "" {
+			return
+		}
+		streamed.WriteString(text)
+		if onChunk != nil {
+			onChunk(text)
+		}
 	}
 
-	if err != nil {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			var event streamEvent
+			if json.Unmarshal([]byte(trimmed), &event) != nil {
+				emit(stripANSI(line))
+			} else {
+				switch event.Type {
+				case "assistant":
+					if sawDeltas {
+						break
+					}
+					for _, block := range event.Message.Content {
+						if block.Type == "text" {
+							emit(block.Text)
+						}
+					}
+				case "stream_event":
+					sawDeltas = true
+					emit(event.Event.Delta.Text)
+				case "result":
+					result = event.Result
+				}
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
 		if cmdCtx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("claude command timed out after %v", w.config.Claude.Timeout)
+			return "", fmt.Errorf("%w after %v", llm.ErrTimeout, w.config.Claude.Timeout)
 		}
 		return "", fmt.Errorf("claude command failed: %w, stderr: %s", err, stderr.String())
 	}
 
-	responseText := stdout.String()
-
+	// The result event holds the authoritative final text; fall back to what streamed
+	responseText := result
 	if responseText == "" {
-		return "", fmt.Errorf("claude returned empty response")
+		responseText = streamed.String()
+	}
+	if responseText == "" {
+		return "", llm.ErrEmptyResponse
 	}
 
 	return responseText, nil
 }
+
+// SendWithRetry sends a conversational prompt, retrying transient failures up to
+// cfg.MaxRetries times. Delays start at cfg.RetryDelay and double after each attempt
+// up to cfg.RetryMaxDelay, with jitter applied. Permanent failures (see IsRetryable)
+// are returned immediately.
+func (w *Wrapper) SendWithRetry(ctx context.Context, prompt string, sessionID string, cfg llm.ProcessingConfig) (string, error) {
+	return retry.Do(ctx, cfg, IsRetryable, func() (string, error) {
+		return w.SendConversationalPrompt(ctx, prompt, sessionID)
+	})
+}
+
+// IsRetryable reports whether a CLI failure may succeed if tried again: timeouts,
+// empty responses, and non-zero exits. A missing or non-executable binary and
+// cancellation are permanent.
+func IsRetryable(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, llm.ErrTimeout) || errors.Is(err, llm.ErrEmptyResponse) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// SendStructuredPrompt sends a prompt that asks for Analysis JSON and validates the reply.
+// When validation fails the prompt is resent with a corrective instruction listing the
+// validation errors, up to ProcessingConfig.MaxRetries more times. Returns a
+// *retry.ValidationError with the last attempt's errors if no reply is valid. The returned
+// metadata records under llm.InfoPromptVariant whether a corrective retry was needed.
+func (w *Wrapper) SendStructuredPrompt(ctx context.Context, prompt string, sessionID string) (*llm.Analysis, error) {
+	return retry.SendStructured(ctx, w, prompt, sessionID, w.processing, w.validation)
+}
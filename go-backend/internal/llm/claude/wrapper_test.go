@@ -1,14 +1,23 @@
 package claude
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/tadschnitzer/universal-session-viewer/go-backend/config"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/retry"
 )
 
 // TestNewWrapper tests wrapper initialization
@@ -60,6 +69,14 @@ func TestGenerateSessionID(t *testing.T) {
 		t.Errorf("Expected 5 parts in session ID, got %d: %s", len(parts), sessionID)
 	}
 
+	// Version 4, RFC 4122 variant: xxxxxxxx-xxxx-4xxx-[89ab]xxx-xxxxxxxxxxxx
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(sessionID) {
+		t.Errorf("Expected a version 4 UUID, got %s", sessionID)
+	}
+	if sessionID[14] != '4' {
+		t.Errorf("Expected version nibble 4, got %c in %s", sessionID[14], sessionID)
+	}
+
 	// Generate another and verify they're different
 	sessionID2, err := wrapper.generateSessionID()
 	if err != nil {
@@ -71,6 +88,39 @@ func TestGenerateSessionID(t *testing.T) {
 	}
 }
 
+// TestGenerateSessionIDRandomSource tests reproducible IDs from an injected reader
+func TestGenerateSessionIDRandomSource(t *testing.T) {
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	wrapper := NewWrapper(&config.Config{})
+	wrapper.SetRandomSource(bytes.NewReader(seed))
+
+	first, err := wrapper.generateSessionID()
+	if err != nil {
+		t.Fatalf("generateSessionID failed: %v", err)
+	}
+	// Version and variant bits are set over the reader's bytes
+	if first != "00010203-0405-4607-8809-0a0b0c0d0e0f" {
+		t.Errorf("Unexpected ID from fixed bytes: %s", first)
+	}
+
+	second, err := wrapper.generateSessionID()
+	if err != nil {
+		t.Fatalf("generateSessionID failed on second call: %v", err)
+	}
+	if second != "10111213-1415-4617-9819-1a1b1c1d1e1f" {
+		t.Errorf("Expected the next 16 bytes to be used, got %s", second)
+	}
+
+	// The reader is exhausted
+	if _, err := wrapper.generateSessionID(); err == nil {
+		t.Error("Expected an error once the random source runs out")
+	}
+}
+
 // TestCreateTempAnalysisDirectory tests temp directory creation
 func TestCreateTempAnalysisDirectory(t *testing.T) {
 	cfg := &config.Config{
@@ -213,10 +263,58 @@ func TestGetAnalysisDirectory(t *testing.T) {
 		t.Errorf("Analysis dir %q should be under %q", analysisDir, tempBase)
 	}
 
-	// Verify date-based subdirectory format (MMDDYY)
-	dateStr := time.Now().Format("010206")
-	if !strings.Contains(analysisDir, dateStr) {
-		t.Errorf("Expected analysis dir to contain date %q, got: %s", dateStr, analysisDir)
+	// Verify date-based subdirectory format (YYYY-MM-DD by default)
+	if want := filepath.Join(tempBase, time.Now().Format("2006-01-02")); analysisDir != want {
+		t.Errorf("Expected analysis dir %s, got: %s", want, analysisDir)
+	}
+
+	// The legacy MMDDYY layout keeps existing directory structures
+	cfg.Paths.AnalysisDirLayout = config.LegacyAnalysisDirLayout
+	if analysisDir, err = wrapper.getAnalysisDirectory(); err != nil {
+		t.Fatalf("getAnalysisDirectory failed: %v", err)
+	}
+	if want := filepath.Join(tempBase, time.Now().Format("010206")); analysisDir != want {
+		t.Errorf("Expected legacy analysis dir %s, got: %s", want, analysisDir)
+	}
+}
+
+// TestGetAnalysisDirectorySafeguards tests that a missing or relative base, or a layout
+// escaping it, is refused before any directory is created
+func TestGetAnalysisDirectorySafeguards(t *testing.T) {
+	base := t.TempDir()
+	tests := []struct {
+		name    string
+		dir     string
+		layout  string
+		wantErr string
+	}{
+		{name: "Empty base", dir: "", wantErr: "not configured"},
+		{name: "Relative base", dir: "analysis", wantErr: "must be an absolute path"},
+		{name: "Layout naming the parent", dir: base, layout: "..", wantErr: "is outside"},
+		{name: "Layout naming the base", dir: base, layout: ".", wantErr: "is outside"},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(t.TempDir()); err != nil {
+				t.Fatalf("Chdir failed: %v", err)
+			}
+			cfg := &config.Config{Paths: config.PathsConfig{AnalysisDir: tt.dir, AnalysisDirLayout: tt.layout}}
+
+			_, err := NewWrapper(cfg).getAnalysisDirectory()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if entries, _ := os.ReadDir("."); len(entries) != 0 {
+				t.Errorf("Expected nothing created in the working directory, found %d entries", len(entries))
+			}
+		})
 	}
 }
 
@@ -252,6 +350,9 @@ func TestSetupAgentsDirectory(t *testing.T) {
 
 // TestSendConversationalPromptWithSessionID tests using existing session ID
 func TestSendConversationalPromptWithSessionID(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
 	// Create temp directory for testing
 	tempBase, err := os.MkdirTemp("", "test-session-*")
 	if err != nil {
@@ -279,6 +380,7 @@ func TestSendConversationalPromptWithSessionID(t *testing.T) {
 
 	ctx := context.Background()
 	sessionID := "existing-session-123"
+	writeTranscript(t, wrapper, home, analysisDir, sessionID)
 
 	// This should not create a temp directory since session ID is provided
 	result, err := wrapper.SendConversationalPrompt(ctx, "test prompt", sessionID)
@@ -305,6 +407,24 @@ func TestSendConversationalPromptWithSessionID(t *testing.T) {
 	_ = result // Ignore result content for this test
 }
 
+// TestSendConversationalPromptRejectsUnsafeSessionID tests that a session ID that could
+// escape the analysis directory is refused before the CLI runs
+func TestSendConversationalPromptRejectsUnsafeSessionID(t *testing.T) {
+	binary, promptFile := writeFakeClaude(t, "reply")
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+
+	_, err := NewWrapper(cfg).SendConversationalPrompt(context.Background(), "prompt", "../../etc/something")
+	if !errors.Is(err, llm.ErrInvalidSessionID) {
+		t.Fatalf("Expected ErrInvalidSessionID, got %v", err)
+	}
+	if _, err := os.Stat(promptFile); err == nil {
+		t.Error("Expected the CLI not to be run")
+	}
+}
+
 // TestWrapperConfigAccess tests that wrapper respects config
 func TestWrapperConfigAccess(t *testing.T) {
 	customModel := "custom-test-model"
@@ -366,3 +486,571 @@ func TestSendConversationalPromptErrorHandling(t *testing.T) {
 		t.Error("Expected error for nonexistent binary, got nil")
 	}
 }
+
+// writeFakeClaude writes a script that records each -p prompt and replies with the
+// given responses in order, repeating the last. Returns the script and prompt log paths.
+func writeFakeClaude(t *testing.T, responses ...string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	promptFile := filepath.Join(dir, "prompts")
+	counterFile := filepath.Join(dir, "count")
+
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("while [ $# -gt 0 ]; do\n")
+	script.WriteString("  if [ \"$1\" = \"-p\" ]; then printf '%s\\0' \"$2\" >> '" + promptFile + "'; fi\n")
+	script.WriteString("  shift\n")
+	script.WriteString("done\n")
+	script.WriteString("n=$(cat '" + counterFile + "' 2>/dev/null || echo 0)\n")
+	script.WriteString("echo $((n + 1)) > '" + counterFile + "'\n")
+	script.WriteString("case $n in\n")
+	for i, response := range responses {
+		pattern := strconv.Itoa(i)
+		if i == len(responses)-1 {
+			pattern = "*"
+		}
+		responsePath := filepath.Join(dir, "response-"+strconv.Itoa(i))
+		if err := os.WriteFile(responsePath, []byte(response), 0644); err != nil {
+			t.Fatalf("Failed to write fake response: %v", err)
+		}
+		script.WriteString("  " + pattern + ") cat '" + responsePath + "' ;;\n")
+	}
+	script.WriteString("esac\n")
+
+	scriptPath := filepath.Join(dir, "claude")
+	if err := os.WriteFile(scriptPath, []byte(script.String()), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+	return scriptPath, promptFile
+}
+
+// validAnalysisJSON passes validator.ValidateAnalysisJSON
+const validAnalysisJSON = `{"episodes":[{"id":"ep1","phase":"debugging","confidence":0.8,"description":"Fixed a bug","start_line":1,"end_line":5}],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":[],"metadata":{}}`
+
+// TestSendStructuredPrompt tests validation retries with a corrective instruction
+func TestSendStructuredPrompt(t *testing.T) {
+	binary, promptFile := writeFakeClaude(t, "Sure! Here is the analysis.", `{"episodes":[]}`, validAnalysisJSON)
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+
+	analysis, err := wrapper.SendStructuredPrompt(context.Background(), "Analyze this", "")
+	if err != nil {
+		t.Fatalf("SendStructuredPrompt failed: %v", err)
+	}
+	if len(analysis.Episodes) != 1 || analysis.Episodes[0].Phase != "debugging" {
+		t.Errorf("Unexpected analysis: %+v", analysis)
+	}
+	if variant := analysis.Metadata.HierarchicalInfo[llm.InfoPromptVariant]; variant != llm.PromptVariantCorrective {
+		t.Errorf("Expected corrective prompt variant after retries, got %v", variant)
+	}
+
+	data, err := os.ReadFile(promptFile)
+	if err != nil {
+		t.Fatalf("Failed to read prompts: %v", err)
+	}
+	prompts := strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+	if len(prompts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(prompts))
+	}
+	if prompts[0] != "Analyze this" {
+		t.Errorf("Expected original prompt first, got %q", prompts[0])
+	}
+	if !strings.HasPrefix(prompts[1], "Analyze this") || !strings.Contains(prompts[1], "No JSON object found") {
+		t.Errorf("Expected retry to echo validation errors, got %q", prompts[1])
+	}
+	if !strings.Contains(prompts[2], "Missing required field: patterns") {
+		t.Errorf("Expected second retry to echo latest errors, got %q", prompts[2])
+	}
+}
+
+// TestSendStructuredPromptExhausted tests the error returned when every attempt is invalid
+func TestSendStructuredPromptExhausted(t *testing.T) {
+	binary, promptFile := writeFakeClaude(t, "not json")
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+	wrapper.SetProcessingConfig(llm.ProcessingConfig{MaxRetries: 1})
+
+	_, err := wrapper.SendStructuredPrompt(context.Background(), "Analyze this", "")
+
+	var validationErr *retry.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *retry.ValidationError, got %v", err)
+	}
+	if validationErr.Attempts != 2 || len(validationErr.Errors) == 0 {
+		t.Errorf("Unexpected validation error: %+v", validationErr)
+	}
+
+	data, _ := os.ReadFile(promptFile)
+	if n := strings.Count(string(data), "\x00"); n != 2 {
+		t.Errorf("Expected 2 attempts, got %d", n)
+	}
+}
+
+// TestSendStructuredPromptNormalizes tests that accepted replies are normalized
+func TestSendStructuredPromptNormalizes(t *testing.T) {
+	binary, _ := writeFakeClaude(t, `{"episodes":[`+
+		`{"id":"ep2","phase":"debugging","confidence":0.8,"description":"Fixed it","start_line":6,"end_line":9},`+
+		`{"id":"ep1","phase":"debugging","confidence":0.7,"description":"Reproduced","start_line":1,"end_line":5}`+
+		`],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":[],"metadata":{}}`)
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	analysis, err := NewWrapper(cfg).SendStructuredPrompt(context.Background(), "Analyze this", "")
+	if err != nil {
+		t.Fatalf("SendStructuredPrompt failed: %v", err)
+	}
+	if len(analysis.Episodes) != 1 || analysis.Episodes[0].ID != "ep1" || analysis.Episodes[0].EndLine != 9 {
+		t.Errorf("Expected the touching episodes merged in line order, got %+v", analysis.Episodes)
+	}
+}
+
+// TestSendConversationalPromptStream tests incremental chunk delivery from stream-json output
+func TestSendConversationalPromptStream(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" > '" + argsFile + "'\n" +
+		`echo '{"type":"system","subtype":"init"}'` + "\n" +
+		`echo '{"type":"assistant","message":{"content":[{"type":"text","text":"Domain: Go. "}]}}'` + "\n" +
+		`echo '{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read"},{"type":"text","text":"Complexity: Simple."}]}}'` + "\n" +
+		`echo '{"type":"result","subtype":"success","result":"Domain: Go. Complexity: Simple."}'` + "\n"
+	binary := filepath.Join(dir, "claude")
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+
+	var chunks []string
+	text, err := NewWrapper(cfg).SendConversationalPromptStream(context.Background(), "Summarize", "", func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("SendConversationalPromptStream failed: %v", err)
+	}
+
+	if len(chunks) != 2 || chunks[0] != "Domain: Go. " || chunks[1] != "Complexity: Simple." {
+		t.Errorf("Unexpected chunks: %q", chunks)
+	}
+	if text != "Domain: Go. Complexity: Simple." {
+		t.Errorf("Expected final result text, got %q", text)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "--output-format stream-json") {
+		t.Errorf("Expected stream-json output format, got args %q", args)
+	}
+}
+
+// TestSendConversationalPromptStreamPartialMessages tests that deltas aren't repeated
+// by the assembled assistant message
+func TestSendConversationalPromptStreamPartialMessages(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		`echo '{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"Hel"}}}'` + "\n" +
+		`echo '{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"lo"}}}'` + "\n" +
+		`echo '{"type":"assistant","message":{"content":[{"type":"text","text":"Hello"}]}}'` + "\n"
+	binary := filepath.Join(dir, "claude")
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+
+	text, err := NewWrapper(cfg).SendConversationalPromptStream(context.Background(), "Hi", "", nil)
+	if err != nil {
+		t.Fatalf("SendConversationalPromptStream failed: %v", err)
+	}
+	if text != "Hello" {
+		t.Errorf("Expected streamed text without the result event, got %q", text)
+	}
+}
+
+// TestSendWithRetry tests that a transient non-zero exit is retried
+func TestSendWithRetry(t *testing.T) {
+	dir := t.TempDir()
+	counterFile := filepath.Join(dir, "count")
+	script := "#!/bin/sh\n" +
+		"n=$(cat '" + counterFile + "' 2>/dev/null || echo 0)\n" +
+		"echo $((n + 1)) > '" + counterFile + "'\n" +
+		"if [ $n -lt 2 ]; then echo 'overloaded' >&2; exit 1; fi\n" +
+		"echo 'Summary text'\n"
+	binary := filepath.Join(dir, "claude")
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+
+	t.Run("Succeeds after transient failures", func(t *testing.T) {
+		os.Remove(counterFile)
+		response, err := wrapper.SendWithRetry(context.Background(), "Summarize", "", llm.ProcessingConfig{MaxRetries: 2, RetryDelay: time.Millisecond})
+		if err != nil {
+			t.Fatalf("SendWithRetry failed: %v", err)
+		}
+		if strings.TrimSpace(response) != "Summary text" {
+			t.Errorf("Unexpected response %q", response)
+		}
+	})
+
+	t.Run("Gives up after MaxRetries", func(t *testing.T) {
+		os.Remove(counterFile)
+		_, err := wrapper.SendWithRetry(context.Background(), "Summarize", "", llm.ProcessingConfig{MaxRetries: 1, RetryDelay: time.Millisecond})
+		if err == nil || !strings.Contains(err.Error(), "overloaded") {
+			t.Errorf("Expected last failure to be returned, got %v", err)
+		}
+		if count, _ := os.ReadFile(counterFile); strings.TrimSpace(string(count)) != "2" {
+			t.Errorf("Expected 2 attempts, got %s", count)
+		}
+	})
+}
+
+// TestSendWithRetryPermanentFailure tests that a missing binary isn't retried
+func TestSendWithRetryPermanentFailure(t *testing.T) {
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: "/nonexistent/binary/claude", Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+
+	start := time.Now()
+	_, err := NewWrapper(cfg).SendWithRetry(context.Background(), "Summarize", "", llm.ProcessingConfig{MaxRetries: 3, RetryDelay: time.Second})
+	if err == nil {
+		t.Fatal("Expected error for nonexistent binary")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected no retry delay for a permanent failure, took %v", elapsed)
+	}
+}
+
+// TestIsRetryable tests transient and permanent error classification
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Timeout", fmt.Errorf("%w after 5m0s", llm.ErrTimeout), true},
+		{"Empty response", llm.ErrEmptyResponse, true},
+		{"Non-zero exit", fmt.Errorf("claude command failed: %w", &exec.ExitError{}), true},
+		{"Binary not found", fmt.Errorf("claude command failed: %w", exec.ErrNotFound), false},
+		{"Missing file", fmt.Errorf("claude command failed: %w", os.ErrNotExist), false},
+		{"Canceled", context.Canceled, false},
+		{"Other", errors.New("failed to create temp analysis directory"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.expected {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSendConversationalPromptExtraArgs tests that configured extra args are passed
+// before the prompt
+func TestSendConversationalPromptExtraArgs(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\necho ok\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{
+			BinaryPath: binary,
+			Model:      "test-model",
+			Timeout:    5 * time.Second,
+			ExtraArgs:  []string{"--max-turns", "3", "--allowedTools", "Read Grep"},
+		},
+		Paths: config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+	sessionID, err := wrapper.NewSessionID()
+	if err != nil {
+		t.Fatalf("NewSessionID failed: %v", err)
+	}
+	if _, err := wrapper.SendConversationalPrompt(context.Background(), "Summarize", sessionID); err != nil {
+		t.Fatalf("SendConversationalPrompt failed: %v", err)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read args: %v", err)
+	}
+	want := "--model\ntest-model\n--session-id\n" + sessionID + "\n--max-turns\n3\n--allowedTools\nRead Grep\n-p\nSummarize\n"
+	if string(data) != want {
+		t.Errorf("Expected args:\n%s\ngot:\n%s", want, data)
+	}
+}
+
+// TestSendConversationalPromptModelOverride tests that SetModel changes the model passed
+// to the CLI without changing the configuration
+func TestSendConversationalPromptModelOverride(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\necho ok\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+	wrapper.SetModel("other-model")
+	if _, err := wrapper.SendConversationalPrompt(context.Background(), "Summarize", ""); err != nil {
+		t.Fatalf("SendConversationalPrompt failed: %v", err)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read args: %v", err)
+	}
+	if args := strings.Split(string(data), "\n"); args[1] != "other-model" {
+		t.Errorf("Expected --model other-model, got %s", args[1])
+	}
+	if cfg.Claude.Model != "test-model" {
+		t.Errorf("Expected config model unchanged, got %q", cfg.Claude.Model)
+	}
+
+	wrapper.SetModel("")
+	if wrapper.Model() != "test-model" {
+		t.Errorf("Expected an empty override to restore the configured model, got %q", wrapper.Model())
+	}
+}
+
+// TestSendConversationalPromptResumesSession tests that a session the CLI already has a
+// transcript for is continued with --resume instead of started with --session-id
+func TestSendConversationalPromptResumesSession(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\necho ok\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+	sessionID, err := wrapper.NewSessionID()
+	if err != nil {
+		t.Fatalf("NewSessionID failed: %v", err)
+	}
+
+	sessionArgs := func() string {
+		t.Helper()
+		if _, err := wrapper.SendConversationalPrompt(context.Background(), "Question", sessionID); err != nil {
+			t.Fatalf("SendConversationalPrompt failed: %v", err)
+		}
+		data, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatalf("Failed to read args: %v", err)
+		}
+		return strings.Split(string(data), "\n")[2]
+	}
+
+	if flag := sessionArgs(); flag != "--session-id" {
+		t.Errorf("Expected a new session to use --session-id, got %s", flag)
+	}
+
+	// Stand in for the transcript the real CLI writes on the first call
+	analysisDir, err := wrapper.getAnalysisDirectory()
+	if err != nil {
+		t.Fatalf("getAnalysisDirectory failed: %v", err)
+	}
+	writeTranscript(t, wrapper, home, analysisDir, sessionID)
+
+	if flag := sessionArgs(); flag != "--resume" {
+		t.Errorf("Expected an existing session to use --resume, got %s", flag)
+	}
+}
+
+// TestSendConversationalPromptResumesAcrossDates tests that a session started under an
+// earlier date's analysis directory is resumed there, and that an ID with no transcript
+// that didn't come from NewSessionID is refused instead of starting a new session
+func TestSendConversationalPromptResumesAcrossDates(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\npwd > '" + argsFile + "'\nprintf '%s\\n' \"$@\" >> '" + argsFile + "'\necho ok\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	base := t.TempDir()
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: base},
+	}
+
+	// The session was started on an earlier day, by another process
+	earlier := filepath.Join(base, "2000-01-01")
+	if err := os.MkdirAll(earlier, 0755); err != nil {
+		t.Fatalf("Failed to create earlier analysis dir: %v", err)
+	}
+	wrapper := NewWrapper(cfg)
+	writeTranscript(t, wrapper, home, earlier, "earlier-session")
+
+	if _, err := wrapper.SendConversationalPrompt(context.Background(), "Question", "earlier-session"); err != nil {
+		t.Fatalf("SendConversationalPrompt failed: %v", err)
+	}
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read args: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if lines[0] != earlier || lines[3] != "--resume" {
+		t.Errorf("Expected --resume in %s, got %s and %s", earlier, lines[3], lines[0])
+	}
+
+	os.Remove(argsFile)
+	_, err = wrapper.SendConversationalPrompt(context.Background(), "Question", "unknown-session")
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+	if _, err := os.Stat(argsFile); err == nil {
+		t.Error("Expected the CLI not to be run for an unknown session")
+	}
+}
+
+// writeTranscript stands in for the transcript the CLI keeps for a session run in dir
+func writeTranscript(t *testing.T, wrapper *Wrapper, home, dir, sessionID string) {
+	t.Helper()
+	transcript := wrapper.claudeSessionFile(home, dir, sessionID)
+	if err := os.MkdirAll(filepath.Dir(transcript), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(transcript, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+}
+
+// TestSendConversationalPromptVerbose tests that stderr warnings from a successful
+// call are returned alongside the response
+func TestSendConversationalPromptVerbose(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\necho 'Warning: approaching rate limit' >&2\necho 'Summary text'\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake claude: %v", err)
+	}
+
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 5 * time.Second},
+		Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+	}
+	wrapper := NewWrapper(cfg)
+
+	result, err := wrapper.SendConversationalPromptVerbose(context.Background(), "Summarize", "")
+	if err != nil {
+		t.Fatalf("SendConversationalPromptVerbose failed: %v", err)
+	}
+	if result.Text != "Summary text\n" {
+		t.Errorf("Unexpected text %q", result.Text)
+	}
+	if result.Stderr != "Warning: approaching rate limit" {
+		t.Errorf("Expected stderr warning, got %q", result.Stderr)
+	}
+
+	text, err := wrapper.SendConversationalPrompt(context.Background(), "Summarize", "")
+	if err != nil || text != "Summary text\n" {
+		t.Errorf("Expected plain variant to return only text, got %q, %v", text, err)
+	}
+}
+
+// TestSendConversationalPromptTimeoutTerminates tests that a timed-out CLI receives
+// SIGTERM and can exit cleanly, that one ignoring it is killed after the grace period,
+// and that the temp directory is removed either way
+func TestSendConversationalPromptTimeoutTerminates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not available on Windows")
+	}
+	t.Setenv("HOME", t.TempDir())
+	defer func(orig time.Duration) { terminateGracePeriod = orig }(terminateGracePeriod)
+	terminateGracePeriod = 200 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		trap       string
+		wantMarker bool
+	}{
+		{name: "Exits on SIGTERM", trap: "trap 'echo terminated > \"$MARKER\"; exit 1' TERM", wantMarker: true},
+		{name: "Ignores SIGTERM", trap: "trap '' TERM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			marker := filepath.Join(dir, "marker")
+			cwdFile := filepath.Join(dir, "cwd")
+			binary := filepath.Join(dir, "claude")
+			script := "#!/bin/sh\n" + tt.trap + "\npwd > '" + cwdFile + "'\nwhile :; do sleep 0.05; done\n"
+			if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+				t.Fatalf("Failed to write fake claude: %v", err)
+			}
+			t.Setenv("MARKER", marker)
+
+			cfg := &config.Config{
+				Claude: config.ClaudeConfig{BinaryPath: binary, Model: "test-model", Timeout: 300 * time.Millisecond},
+				Paths:  config.PathsConfig{AnalysisDir: t.TempDir()},
+			}
+
+			start := time.Now()
+			_, err := NewWrapper(cfg).SendConversationalPrompt(context.Background(), "Summarize", "")
+			if !errors.Is(err, llm.ErrTimeout) {
+				t.Fatalf("Expected ErrTimeout, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Expected the CLI to be stopped promptly, took %v", elapsed)
+			}
+
+			_, statErr := os.Stat(marker)
+			if tt.wantMarker != (statErr == nil) {
+				t.Errorf("Expected SIGTERM handler ran = %v, stat error %v", tt.wantMarker, statErr)
+			}
+
+			cwd, err := os.ReadFile(cwdFile)
+			if err != nil {
+				t.Fatalf("Failed to read CLI working directory: %v", err)
+			}
+			if _, err := os.Stat(strings.TrimSpace(string(cwd))); !os.IsNotExist(err) {
+				t.Errorf("Expected temp directory %s to be removed, stat error %v", cwd, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationUnits maps the unit words models write in Episode.Duration to their length
+var durationUnits = map[string]time.Duration{
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+}
+
+// durationTerm matches one "<number> <unit>" term of a spelled-out duration
+var durationTerm = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z]+)[\s,]*(?:and\s+)?`)
+
+// ParseDuration converts an Episode.Duration string to a time.Duration. Besides Go
+// durations ("5m", "1h30m") it accepts spaced and spelled-out units, as in "1h 30m",
+// "90 minutes", or "1 hour, 5 mins". Negative durations and bare numbers are rejected.
+func ParseDuration(value string) (time.Duration, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	if d, err := time.ParseDuration(strings.ReplaceAll(s, " ", "")); err == nil {
+		if d < 0 {
+			return 0, fmt.Errorf("negative duration %q", value)
+		}
+		return d, nil
+	}
+
+	var total time.Duration
+	for s != "" {
+		match := durationTerm.FindStringSubmatch(s)
+		if match == nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		unit, ok := durationUnits[match[2]]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q: unknown unit %q", value, match[2])
+		}
+		amount, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += time.Duration(amount * float64(unit))
+		s = s[len(match[0]):]
+	}
+	return total, nil
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseDuration tests Go, spaced, and spelled-out duration formats
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "5m", want: 5 * time.Minute},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: " 1h 30m ", want: 90 * time.Minute},
+		{value: "45s", want: 45 * time.Second},
+		{value: "90 minutes", want: 90 * time.Minute},
+		{value: "1 hour, 5 mins", want: 65 * time.Minute},
+		{value: "2 hours and 15 minutes", want: 135 * time.Minute},
+		{value: "1.5 hours", want: 90 * time.Minute},
+		{value: "10 Min", want: 10 * time.Minute},
+		{value: "", wantErr: true},
+		{value: "15", wantErr: true},
+		{value: "-5m", wantErr: true},
+		{value: "a while", wantErr: true},
+		{value: "3 fortnights", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %v, expected an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
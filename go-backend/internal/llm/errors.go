@@ -0,0 +1,9 @@
+package llm
+
+import "errors"
+
+// ErrTimeout is returned by a backend when a call exceeds its configured timeout
+var ErrTimeout = errors.New("model call timed out")
+
+// ErrEmptyResponse is returned by a backend when a call succeeds without any reply text
+var ErrEmptyResponse = errors.New("model returned empty response")
@@ -0,0 +1,153 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Default is returned when the language can't be determined with confidence
+const Default = "en"
+
+// minScriptShare is the fraction of letters a non-Latin script needs to decide the language
+const minScriptShare = 0.3
+
+// minStopwordHits is how many stopwords a Latin-script language needs to be chosen
+const minStopwordHits = 3
+
+// names maps the ISO 639-1 codes DetectLanguage can return to English names
+var names = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"it": "Italian",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"ko": "Korean",
+	"ru": "Russian",
+	"ar": "Arabic",
+	"he": "Hebrew",
+	"el": "Greek",
+	"th": "Thai",
+	"hi": "Hindi",
+}
+
+// scripts maps Unicode scripts to the language they most likely indicate.
+// Han is handled separately since it is shared by Chinese and Japanese.
+var scripts = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// stopwords are frequent function words used to tell Latin-script languages apart.
+// Words shared between languages (e.g. "de", "a") are left out.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "with", "this", "that", "it", "for", "was", "have", "not"},
+	"es": {"el", "los", "las", "y", "es", "está", "con", "para", "por", "que", "una", "del", "pero", "como"},
+	"fr": {"le", "les", "et", "est", "une", "des", "du", "pour", "avec", "dans", "pas", "sur", "mais", "qui"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "für", "auf", "ich", "zu", "den"},
+	"pt": {"os", "as", "não", "com", "para", "uma", "um", "do", "da", "em", "mas", "isso", "são", "também"},
+	"it": {"il", "gli", "non", "con", "per", "una", "della", "che", "sono", "anche", "questo", "ma", "nel", "è"},
+}
+
+// DetectLanguage guesses the ISO 639-1 code of the dominant natural language in
+// content. Non-Latin scripts are identified by character class; Latin-script text
+// is scored by stopword frequency. Ambiguous or mostly non-linguistic input (code,
+// short snippets) returns Default.
+func DetectLanguage(content string) string {
+	var letters, kana, han int
+	counts := make(map[string]int)
+	for _, r := range content {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.code]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return Default
+	}
+
+	// Japanese mixes kana with Han; Han without kana is most likely Chinese
+	if share(kana+han, letters) >= minScriptShare {
+		if kana > 0 {
+			return "ja"
+		}
+		return "zh"
+	}
+	bestScript, bestCount := "", 0
+	for code, n := range counts {
+		if n > bestCount || (n == bestCount && code < bestScript) {
+			bestScript, bestCount = code, n
+		}
+	}
+	if share(bestCount, letters) >= minScriptShare {
+		return bestScript
+	}
+
+	return detectLatin(content)
+}
+
+// detectLatin scores Latin-script text by stopword hits, requiring a clear winner
+func detectLatin(content string) string {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	scores := make(map[string]int)
+	for _, word := range words {
+		for code, list := range stopwords {
+			for _, stopword := range list {
+				if word == stopword {
+					scores[code]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestScore, tied := Default, 0, false
+	for code, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = code, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+
+	if bestScore < minStopwordHits || tied {
+		return Default
+	}
+	return best
+}
+
+// Name returns the English name of an ISO 639-1 code, or "" if it is unknown
+func Name(code string) string {
+	return names[code]
+}
+
+func share(n, total int) float64 {
+	return float64(n) / float64(total)
+}
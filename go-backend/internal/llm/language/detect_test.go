@@ -0,0 +1,76 @@
+package language
+
+import "testing"
+
+// TestDetectLanguage tests language detection on representative samples
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "English",
+			content:  "The user asked how to fix the failing test and the assistant found that it was a race condition in the setup code.",
+			expected: "en",
+		},
+		{
+			name:     "Spanish",
+			content:  "El usuario pidió ayuda para corregir el error en la base de datos y el asistente propuso una solución con los índices para mejorar el rendimiento.",
+			expected: "es",
+		},
+		{
+			name:     "Japanese",
+			content:  "ユーザーはテストの失敗を修正する方法を尋ねました。アシスタントはセットアップコードの競合状態を見つけました。",
+			expected: "ja",
+		},
+		{
+			name:     "Chinese",
+			content:  "用户询问如何修复失败的测试，助手发现设置代码中存在竞争条件。",
+			expected: "zh",
+		},
+		{
+			name:     "Russian",
+			content:  "Пользователь спросил, как исправить падающий тест.",
+			expected: "ru",
+		},
+		{
+			name:     "Japanese with English code",
+			content:  "このエラーを直してください: func main() { fmt.Println(x) }",
+			expected: "ja",
+		},
+		{
+			name:     "Empty defaults to English",
+			content:  "",
+			expected: Default,
+		},
+		{
+			name:     "Code only defaults to English",
+			content:  "x := y + z; return err",
+			expected: Default,
+		},
+		{
+			name:     "Too short to tell",
+			content:  "ok gracias",
+			expected: Default,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.content); got != tt.expected {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestName tests code to name lookup
+func TestName(t *testing.T) {
+	if Name("es") != "Spanish" {
+		t.Errorf("Expected Spanish, got %q", Name("es"))
+	}
+	if Name("xx") != "" {
+		t.Errorf("Expected empty name for unknown code, got %q", Name("xx"))
+	}
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Loop detection thresholds used by DetectLoops
+const (
+	// LoopSimilarity is the minimum word-set similarity for two messages to count as
+	// the same message repeated
+	LoopSimilarity = 0.8
+
+	// LoopMinRepetitions is how many times an exchange must occur in a row to be a loop
+	LoopMinRepetitions = 3
+
+	// LoopMaxPeriod is the longest cycle, in messages, that DetectLoops looks for
+	LoopMaxPeriod = 4
+)
+
+// loopExcerptLength caps the excerpt recorded for a loop, in runes
+const loopExcerptLength = 120
+
+// LoopInfo describes a run of messages where the same exchange repeats
+type LoopInfo struct {
+	StartIndex  int     `json:"start_index"`          // Index of the first looping message
+	EndIndex    int     `json:"end_index"`            // Index of the last looping message, inclusive
+	StartLine   int     `json:"start_line,omitempty"` // Source line of the first looping message, when known
+	EndLine     int     `json:"end_line,omitempty"`   // Source line of the last looping message, when known
+	Period      int     `json:"period"`               // Messages per repetition, e.g. 2 for a user/assistant exchange
+	Repetitions int     `json:"repetitions"`          // Complete repetitions of the exchange
+	Similarity  float64 `json:"similarity"`           // Mean similarity of each message to its repeat
+	Excerpt     string  `json:"excerpt"`              // Start of the first repeated message
+}
+
+// DetectLoops finds runs where the same exchange of messages repeats back to back,
+// such as a user reporting the same failure and the assistant giving the same fix.
+// Messages are compared by the overlap of their normalized words, so a repeat with a
+// different line number or timestamp still matches. Only repeats of the same message
+// type count, and a run is reported once it reaches LoopMinRepetitions.
+func DetectLoops(messages []FilteredMessage) []LoopInfo {
+	words := make([]map[string]bool, len(messages))
+	for i, msg := range messages {
+		words[i] = normalizedWords(msg.Content)
+	}
+
+	// similar returns how alike message i is to message j, or -1 if they don't match
+	similar := func(i, j int) float64 {
+		if messages[i].Type != messages[j].Type {
+			return -1
+		}
+		score := wordSimilarity(words[i], words[j])
+		if score < LoopSimilarity {
+			return -1
+		}
+		return score
+	}
+
+	var loops []LoopInfo
+	for start := 0; start < len(messages); {
+		best := LoopInfo{}
+		for period := 1; period <= LoopMaxPeriod; period++ {
+			// Extend the run while each message matches the one a period earlier
+			matched := 0
+			for i := start + period; i < len(messages) && similar(i-period, i) >= 0; i++ {
+				matched++
+			}
+
+			// Only whole repetitions are part of the loop; prefer the period covering
+			// the most messages, and the shortest one on a tie
+			repetitions := 1 + matched/period
+			if repetitions < LoopMinRepetitions || repetitions*period <= best.Repetitions*best.Period {
+				continue
+			}
+			best = LoopInfo{
+				StartIndex:  start,
+				EndIndex:    start + repetitions*period - 1,
+				Period:      period,
+				Repetitions: repetitions,
+			}
+		}
+
+		if best.Repetitions == 0 {
+			start++
+			continue
+		}
+
+		best.Similarity = loopSimilarity(best, similar)
+		best.StartLine = messages[best.StartIndex].Line
+		best.EndLine = messages[best.EndIndex].Line
+		best.Excerpt = excerpt(messages[best.StartIndex].Content, loopExcerptLength)
+		loops = append(loops, best)
+		start = best.EndIndex + 1
+	}
+	return loops
+}
+
+// loopSimilarity averages the similarity of each looping message to its repeat
+func loopSimilarity(loop LoopInfo, similar func(i, j int) float64) float64 {
+	total, count := 0.0, 0
+	for i := loop.StartIndex + loop.Period; i <= loop.EndIndex; i++ {
+		total += similar(i-loop.Period, i)
+		count++
+	}
+	return total / float64(count)
+}
+
+// normalizedWords splits text into lowercase words, with every run of digits replaced
+// by "#" so counters, line numbers, and timestamps don't hide a repeat
+func normalizedWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, field := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		var b strings.Builder
+		inDigits := false
+		for _, r := range field {
+			if unicode.IsDigit(r) {
+				if !inDigits {
+					b.WriteByte('#')
+				}
+				inDigits = true
+				continue
+			}
+			inDigits = false
+			b.WriteRune(r)
+		}
+		words[b.String()] = true
+	}
+	return words
+}
+
+// wordSimilarity is the Jaccard index of two word sets; two empty messages are identical
+func wordSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// excerpt returns the first limit runes of text on one line
+func excerpt(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "..."
+}
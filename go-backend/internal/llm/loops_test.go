@@ -0,0 +1,85 @@
+package llm
+
+import "testing"
+
+// failingLoop is a session where the user reports the same failing test three times
+// and the assistant proposes the same fix each time, between two distinct messages
+var failingLoop = []FilteredMessage{
+	{Type: "user", Content: "Please add a retry to the uploader", Line: 1},
+	{Type: "user", Content: "The test TestUpload still fails at upload_test.go:42", Line: 2},
+	{Type: "assistant", Content: "I'll fix the nil check in upload.go and rerun the tests.", Line: 3},
+	{Type: "user", Content: "The test TestUpload still fails at upload_test.go:57", Line: 4},
+	{Type: "assistant", Content: "I'll fix the nil check in upload.go and rerun the tests.", Line: 5},
+	{Type: "user", Content: "the test TestUpload still FAILS at upload_test.go:61", Line: 6},
+	{Type: "assistant", Content: "I'll fix the nil check in upload.go, then rerun the tests.", Line: 7},
+	{Type: "user", Content: "Let's try a different approach", Line: 8},
+}
+
+// TestDetectLoops tests that a repeated failing exchange is found with its span
+func TestDetectLoops(t *testing.T) {
+	loops := DetectLoops(failingLoop)
+	if len(loops) != 1 {
+		t.Fatalf("Expected 1 loop, got %+v", loops)
+	}
+
+	loop := loops[0]
+	if loop.StartIndex != 1 || loop.EndIndex != 6 {
+		t.Errorf("Expected messages 1-6, got %d-%d", loop.StartIndex, loop.EndIndex)
+	}
+	if loop.StartLine != 2 || loop.EndLine != 7 {
+		t.Errorf("Expected lines 2-7, got %d-%d", loop.StartLine, loop.EndLine)
+	}
+	if loop.Period != 2 || loop.Repetitions != 3 {
+		t.Errorf("Expected 3 repetitions of 2 messages, got %d of %d", loop.Repetitions, loop.Period)
+	}
+	if loop.Similarity < LoopSimilarity || loop.Similarity > 1 {
+		t.Errorf("Unexpected similarity %v", loop.Similarity)
+	}
+	if loop.Excerpt != failingLoop[1].Content {
+		t.Errorf("Unexpected excerpt %q", loop.Excerpt)
+	}
+}
+
+// TestDetectLoopsSingleMessage tests a message repeated on its own
+func TestDetectLoopsSingleMessage(t *testing.T) {
+	messages := []FilteredMessage{
+		{Type: "user", Content: "continue"},
+		{Type: "user", Content: "continue"},
+		{Type: "user", Content: "Continue."},
+		{Type: "user", Content: "continue"},
+	}
+	loops := DetectLoops(messages)
+	if len(loops) != 1 || loops[0].Period != 1 || loops[0].Repetitions != 4 {
+		t.Errorf("Expected one 4x loop of 1 message, got %+v", loops)
+	}
+}
+
+// TestDetectLoopsNone tests that distinct messages and short repeats aren't flagged
+func TestDetectLoopsNone(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []FilteredMessage
+	}{
+		{"Empty", nil},
+		{"Distinct", []FilteredMessage{
+			{Type: "user", Content: "Add a login page"},
+			{Type: "assistant", Content: "Created login.tsx with a form"},
+			{Type: "user", Content: "Now add logout"},
+			{Type: "assistant", Content: "Added a logout button to the header"},
+		}},
+		{"Two repetitions", failingLoop[:5]},
+		{"Same text, different roles", []FilteredMessage{
+			{Type: "user", Content: "ok"},
+			{Type: "assistant", Content: "ok"},
+			{Type: "user", Content: "ok"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if loops := DetectLoops(tt.messages); len(loops) != 0 {
+				t.Errorf("Expected no loops, got %+v", loops)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package llm
+
+// Keys recorded in AnalysisMetadata.HierarchicalInfo (and WindowResult.Metadata) to
+// correlate output quality with prompt choices
+const (
+	InfoPromptTemplate = "prompt_template" // PromptTemplate the analysis was requested with
+	InfoPromptVariant  = "prompt_variant"  // "initial", or "corrective" after a validation retry
+	InfoSelectedTier   = "selected_tier"   // Tier chosen by SelectTier, when it differs from ProcessingTier
+
+	InfoLowConfidenceDropped = "low_confidence_dropped" // Episodes removed by a minimum confidence threshold
+)
+
+// Prompt variants recorded under InfoPromptVariant
+const (
+	PromptVariantInitial    = "initial"
+	PromptVariantCorrective = "corrective"
+)
+
+// SetInfo records a value in HierarchicalInfo, creating the map on first use
+func (m *AnalysisMetadata) SetInfo(key string, value interface{}) {
+	if m.HierarchicalInfo == nil {
+		m.HierarchicalInfo = make(map[string]interface{})
+	}
+	m.HierarchicalInfo[key] = value
+}
+
+// PromptTemplate returns the template recorded with SetInfo, or "" if none was
+func (m *AnalysisMetadata) PromptTemplate() PromptTemplate {
+	template, _ := m.HierarchicalInfo[InfoPromptTemplate].(string)
+	return PromptTemplate(template)
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"reflect"
+	"sort"
+)
+
+// NormalizeAnalysis tidies the episodes of a model reply in place: nil and exact
+// duplicate episodes are dropped, the rest are sorted by StartLine, and episodes with
+// the same phase whose line ranges overlap or touch are merged (see Episode.Merge).
+// Durations ParseDuration understands are rewritten in Go form, e.g. "1h30m0s".
+// Normalizing an already normalized analysis changes nothing.
+func NormalizeAnalysis(analysis *Analysis) {
+	if analysis == nil {
+		return
+	}
+
+	candidates := make([]*Episode, 0, len(analysis.Episodes))
+	for _, episode := range analysis.Episodes {
+		if episode == nil || containsEpisode(candidates, episode) {
+			continue
+		}
+		candidates = append(candidates, episode)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].StartLine < candidates[j].StartLine
+	})
+
+	// Kept episodes of a phase never touch, so only the latest one can touch the next
+	episodes := make([]*Episode, 0, len(candidates))
+	latest := make(map[string]*Episode)
+	for _, episode := range candidates {
+		if prev, ok := latest[episode.Phase]; ok && episode.StartLine <= prev.EndLine+1 {
+			prev.Merge(episode)
+			continue
+		}
+		episodes = append(episodes, episode)
+		latest[episode.Phase] = episode
+	}
+	for _, episode := range episodes {
+		if d, err := ParseDuration(episode.Duration); err == nil {
+			episode.Duration = d.String()
+		}
+	}
+	analysis.Episodes = episodes
+}
+
+// containsEpisode reports whether episodes holds an episode equal to episode
+func containsEpisode(episodes []*Episode, episode *Episode) bool {
+	for _, existing := range episodes {
+		if reflect.DeepEqual(existing, episode) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge folds src into e, widening the line and time ranges and unioning insights and
+// evidence. The higher confidence and the longer description are kept.
+func (e *Episode) Merge(src *Episode) {
+	if src.StartLine < e.StartLine {
+		e.StartLine = src.StartLine
+	}
+	if src.EndLine > e.EndLine {
+		e.EndLine = src.EndLine
+	}
+
+	if !src.StartTime.IsZero() && (e.StartTime.IsZero() || src.StartTime.Before(e.StartTime)) {
+		e.StartTime = src.StartTime
+	}
+	if src.EndTime.After(e.EndTime) {
+		e.EndTime = src.EndTime
+	}
+	if !e.StartTime.IsZero() && !e.EndTime.IsZero() {
+		e.Duration = e.EndTime.Sub(e.StartTime).String()
+	}
+
+	if src.Confidence > e.Confidence {
+		e.Confidence = src.Confidence
+	}
+	if len(src.Description) > len(e.Description) {
+		e.Description = src.Description
+	}
+	if e.SubPhase == "" {
+		e.SubPhase = src.SubPhase
+	}
+	if e.Resolution == "" {
+		e.Resolution = src.Resolution
+	}
+
+	e.KeyInsights = unionStrings(e.KeyInsights, src.KeyInsights)
+	e.Evidence = unionStrings(e.Evidence, src.Evidence)
+}
+
+// unionStrings appends the values of b not already in a, preserving order
+func unionStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	result := append([]string(nil), a...)
+	for _, s := range a {
+		seen[s] = true
+	}
+	for _, s := range b {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// unorderedEpisodes has an out-of-order episode, an exact duplicate, a nil entry, two
+// touching debugging episodes around an unrelated overlapping one, and spelled-out and
+// unparseable durations
+func unorderedEpisodes() []*Episode {
+	return []*Episode{
+		{ID: "ep3", Phase: "testing", Confidence: 0.9, StartLine: 31, EndLine: 40, Duration: "a while"},
+		{ID: "ep1", Phase: "debugging", Confidence: 0.6, StartLine: 1, EndLine: 10,
+			Description: "Found it", KeyInsights: []string{"Map was nil"}, Evidence: []string{"line 4"}},
+		nil,
+		{ID: "ep2", Phase: "planning", Confidence: 0.7, StartLine: 5, EndLine: 8, Duration: "90 minutes"},
+		{ID: "ep3", Phase: "testing", Confidence: 0.9, StartLine: 31, EndLine: 40, Duration: "a while"},
+		{ID: "ep1b", Phase: "debugging", Confidence: 0.8, StartLine: 11, EndLine: 30,
+			Description: "Found the nil map", KeyInsights: []string{"Map was nil", "Add a constructor"}, Evidence: []string{"line 20"}},
+	}
+}
+
+// TestNormalizeAnalysis tests sorting, duplicate removal, merging of touching episodes,
+// and duration rewriting
+func TestNormalizeAnalysis(t *testing.T) {
+	analysis := &Analysis{Episodes: unorderedEpisodes()}
+	NormalizeAnalysis(analysis)
+
+	want := []*Episode{
+		{ID: "ep1", Phase: "debugging", Confidence: 0.8, StartLine: 1, EndLine: 30, Description: "Found the nil map",
+			KeyInsights: []string{"Map was nil", "Add a constructor"}, Evidence: []string{"line 4", "line 20"}},
+		{ID: "ep2", Phase: "planning", Confidence: 0.7, StartLine: 5, EndLine: 8, Duration: "1h30m0s"},
+		{ID: "ep3", Phase: "testing", Confidence: 0.9, StartLine: 31, EndLine: 40, Duration: "a while"},
+	}
+	if !reflect.DeepEqual(analysis.Episodes, want) {
+		t.Errorf("Unexpected episodes:")
+		for _, ep := range analysis.Episodes {
+			t.Logf("  %+v", *ep)
+		}
+	}
+}
+
+// TestNormalizeAnalysisIdempotent tests that a second pass leaves the result unchanged
+func TestNormalizeAnalysisIdempotent(t *testing.T) {
+	once := &Analysis{Episodes: unorderedEpisodes()}
+	NormalizeAnalysis(once)
+	twice := &Analysis{Episodes: unorderedEpisodes()}
+	NormalizeAnalysis(twice)
+	NormalizeAnalysis(twice)
+
+	if !reflect.DeepEqual(once, twice) {
+		t.Errorf("Expected normalization to be idempotent:\n once %+v\ntwice %+v", once.Episodes, twice.Episodes)
+	}
+}
+
+// TestNormalizeAnalysisKeepsSeparateEpisodes tests that a gap or a phase change
+// prevents merging
+func TestNormalizeAnalysisKeepsSeparateEpisodes(t *testing.T) {
+	analysis := &Analysis{Episodes: []*Episode{
+		{Phase: "debugging", StartLine: 1, EndLine: 10},
+		{Phase: "debugging", StartLine: 12, EndLine: 20},
+		{Phase: "testing", StartLine: 21, EndLine: 30},
+	}}
+	NormalizeAnalysis(analysis)
+
+	if len(analysis.Episodes) != 3 {
+		t.Errorf("Expected 3 episodes, got %d", len(analysis.Episodes))
+	}
+	NormalizeAnalysis(nil)
+}
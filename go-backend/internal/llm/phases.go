@@ -0,0 +1,54 @@
+package llm
+
+import "time"
+
+// PhaseStats rolls up the episodes of one phase
+type PhaseStats struct {
+	Episodes          int           // Number of episodes in the phase
+	TotalTime         time.Duration // Summed duration of the timed episodes
+	TimedEpisodes     int           // Episodes that contributed to TotalTime
+	AverageConfidence float64       // Mean confidence over all episodes
+}
+
+// AggregateByPhase totals time, episode count, and average confidence per phase. An
+// episode's time is EndTime - StartTime when both are set, otherwise its Duration
+// string (see ParseDuration); episodes with neither are counted but add no time.
+func AggregateByPhase(analysis *Analysis) map[string]PhaseStats {
+	stats := make(map[string]PhaseStats)
+	if analysis == nil {
+		return stats
+	}
+
+	confidence := make(map[string]float64)
+	for _, episode := range analysis.Episodes {
+		if episode == nil {
+			continue
+		}
+		s := stats[episode.Phase]
+		s.Episodes++
+		if d, ok := episodeDuration(episode); ok {
+			s.TotalTime += d
+			s.TimedEpisodes++
+		}
+		stats[episode.Phase] = s
+		confidence[episode.Phase] += episode.Confidence
+	}
+
+	for phase, s := range stats {
+		s.AverageConfidence = confidence[phase] / float64(s.Episodes)
+		stats[phase] = s
+	}
+	return stats
+}
+
+// episodeDuration returns how long an episode lasted, and false if that is unknown
+func episodeDuration(episode *Episode) (time.Duration, bool) {
+	if !episode.StartTime.IsZero() && !episode.EndTime.IsZero() && !episode.EndTime.Before(episode.StartTime) {
+		return episode.EndTime.Sub(episode.StartTime), true
+	}
+	if episode.Duration == "" {
+		return 0, false
+	}
+	d, err := ParseDuration(episode.Duration)
+	return d, err == nil
+}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestAggregateByPhase tests time, count, and confidence rollups per phase
+func TestAggregateByPhase(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	analysis := &Analysis{Episodes: []*Episode{
+		{Phase: "debugging", Confidence: 0.9, StartTime: start, EndTime: start.Add(20 * time.Minute), Duration: "1h"},
+		{Phase: "debugging", Confidence: 0.5, Duration: "1h30m"},
+		{Phase: "implementation", Confidence: 0.8, Duration: "5m"},
+		{Phase: "implementation", Confidence: 0.6, Duration: "a while"},
+		nil,
+	}}
+
+	stats := AggregateByPhase(analysis)
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 phases, got %+v", stats)
+	}
+
+	// Timestamps take precedence over the Duration string
+	debugging := stats["debugging"]
+	if debugging.Episodes != 2 || debugging.TimedEpisodes != 2 || debugging.TotalTime != 110*time.Minute {
+		t.Errorf("Unexpected debugging stats: %+v", debugging)
+	}
+	if math.Abs(debugging.AverageConfidence-0.7) > 1e-9 {
+		t.Errorf("Expected average confidence 0.7, got %v", debugging.AverageConfidence)
+	}
+
+	implementation := stats["implementation"]
+	if implementation.Episodes != 2 || implementation.TimedEpisodes != 1 || implementation.TotalTime != 5*time.Minute {
+		t.Errorf("Unexpected implementation stats: %+v", implementation)
+	}
+
+	if empty := AggregateByPhase(nil); len(empty) != 0 {
+		t.Errorf("Expected no stats for a nil analysis, got %+v", empty)
+	}
+}
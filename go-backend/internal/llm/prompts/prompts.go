@@ -0,0 +1,143 @@
+// Package prompts holds the prompt templates sent to the model. Each llm.PromptTemplate
+// names a text/template body rendered with the conversation content as Data.
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// FileExt is the extension of template override files: dir/<name>.tmpl replaces the
+// built-in template <name>, e.g. tier1_direct.tmpl
+const FileExt = ".tmpl"
+
+// Data is what a template is rendered with. Window fields are only set for the tier 2
+// window prompt.
+type Data struct {
+	// Content is the conversation text the model analyzes
+	Content string
+
+	// Window is the 1-based window number out of TotalWindows
+	Window       int
+	TotalWindows int
+
+	// StartLine and EndLine are the source lines the window covers
+	StartLine int
+	EndLine   int
+}
+
+// Set is a parsed template per name. A nil Set renders the built-in templates.
+type Set struct {
+	templates map[llm.PromptTemplate]*template.Template
+}
+
+// defaults are the parsed built-in bodies
+var defaults = &Set{templates: parseAll(defaultBodies)}
+
+// parseAll parses every body, panicking on a malformed built-in template
+func parseAll(bodies map[llm.PromptTemplate]string) map[llm.PromptTemplate]*template.Template {
+	parsed := make(map[llm.PromptTemplate]*template.Template, len(bodies))
+	for name, body := range bodies {
+		parsed[name] = template.Must(template.New(string(name)).Parse(body))
+	}
+	return parsed
+}
+
+// Names lists the templates that have a body and can be overridden, sorted
+func Names() []llm.PromptTemplate {
+	names := make([]llm.PromptTemplate, 0, len(defaultBodies))
+	for name := range defaultBodies {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// LoadDir returns the built-in templates with each one that has a <name>.tmpl file in
+// dir replaced by that file. A missing dir, or a missing file, keeps the built-in.
+// Overrides are validated by parsing them and rendering them with sample data, so a
+// broken template is reported here rather than mid-analysis; a .tmpl file naming no
+// template is an error too, since it would otherwise be silently ignored.
+func LoadDir(dir string) (*Set, error) {
+	if dir == "" {
+		return defaults, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt templates %s: %w", dir, err)
+	}
+
+	set := &Set{templates: map[llm.PromptTemplate]*template.Template{}}
+	for name, tmpl := range defaults.templates {
+		set.templates[name] = tmpl
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != FileExt {
+			continue
+		}
+		name := llm.PromptTemplate(strings.TrimSuffix(entry.Name(), FileExt))
+		if _, ok := defaultBodies[name]; !ok {
+			return nil, fmt.Errorf("unknown prompt template file %s (known: %s)", filepath.Join(dir, entry.Name()), knownFiles())
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+		tmpl, err := template.New(string(name)).Parse(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %s: %w", path, err)
+		}
+		if err := tmpl.Execute(&strings.Builder{}, Data{Content: "user: hello", Window: 1, TotalWindows: 1, StartLine: 1, EndLine: 1}); err != nil {
+			return nil, fmt.Errorf("invalid prompt template %s: %w", path, err)
+		}
+		set.templates[name] = tmpl
+	}
+	return set, nil
+}
+
+// knownFiles lists the accepted override file names
+func knownFiles() string {
+	var files []string
+	for _, name := range Names() {
+		files = append(files, string(name)+FileExt)
+	}
+	return strings.Join(files, ", ")
+}
+
+// Render executes the named template with data. Tier 3 has no templates yet, so its
+// names return an error.
+func (s *Set) Render(name llm.PromptTemplate, data Data) (string, error) {
+	if s == nil {
+		s = defaults
+	}
+	tmpl, ok := s.templates[name]
+	if !ok {
+		return "", fmt.Errorf("no prompt template named %q", name)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render prompt template %q: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// SummaryTemplate selects the summary template for an attempt: the first uses the
+// standard prompt and every retry the strict one
+func SummaryTemplate(attempt int) llm.PromptTemplate {
+	if attempt <= 1 {
+		return llm.PromptSummary
+	}
+	return llm.PromptSummaryRetry
+}
@@ -0,0 +1,108 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestRender tests that each built-in template renders its data
+func TestRender(t *testing.T) {
+	var builtin *Set
+	for _, name := range []llm.PromptTemplate{llm.PromptSummary, llm.PromptSummaryRetry, llm.PromptTier1Direct} {
+		prompt, err := builtin.Render(name, Data{Content: "user: hello"})
+		if err != nil {
+			t.Fatalf("Render(%q) failed: %v", name, err)
+		}
+		if !strings.HasSuffix(prompt, "\nuser: hello") {
+			t.Errorf("Render(%q): expected the content at the end, got %q", name, prompt)
+		}
+	}
+
+	prompt, err := builtin.Render(llm.PromptTier2Window, Data{Content: "[line 5] user: hello\n", Window: 2, TotalWindows: 3, StartLine: 5, EndLine: 9})
+	if err != nil {
+		t.Fatalf("Render window failed: %v", err)
+	}
+	if !strings.HasPrefix(prompt, "Analyze window 2 of 3 of a Claude conversation (lines 5-9).") || !strings.HasSuffix(prompt, "Conversation:\n[line 5] user: hello\n") {
+		t.Errorf("Unexpected window prompt: %q", prompt)
+	}
+
+	if _, err := builtin.Render(llm.PromptTier3Coarse, Data{}); err == nil {
+		t.Error("Expected an error for a template without a body")
+	}
+}
+
+// TestSummaryTemplate tests template selection by attempt
+func TestSummaryTemplate(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    llm.PromptTemplate
+	}{
+		{1, llm.PromptSummary},
+		{2, llm.PromptSummaryRetry},
+		{5, llm.PromptSummaryRetry},
+	}
+	for _, tt := range tests {
+		if got := SummaryTemplate(tt.attempt); got != tt.want {
+			t.Errorf("SummaryTemplate(%d) = %q, want %q", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// TestLoadDir tests that override files replace only their own template
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tier1_direct.tmpl"), []byte("Custom episodes prompt:\n{{.Content}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("{{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	if prompt, _ := set.Render(llm.PromptTier1Direct, Data{Content: "user: hello"}); prompt != "Custom episodes prompt:\nuser: hello" {
+		t.Errorf("Expected the override, got %q", prompt)
+	}
+	if prompt, _ := set.Render(llm.PromptSummary, Data{Content: "user: hello"}); !strings.HasPrefix(prompt, "Analyze this Claude conversation and provide a concise summary") {
+		t.Errorf("Expected the built-in summary template, got %q", prompt)
+	}
+
+	for _, missing := range []string{"", filepath.Join(dir, "missing")} {
+		set, err := LoadDir(missing)
+		if err != nil || set != defaults {
+			t.Errorf("LoadDir(%q): expected the built-ins, got %v", missing, err)
+		}
+	}
+}
+
+// TestLoadDirInvalid tests that broken override files are reported at load time
+func TestLoadDirInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		body string
+		want string
+	}{
+		{"Parse error", "summary.tmpl", "{{.Content", "failed to parse prompt template"},
+		{"Unknown field", "summary.tmpl", "{{.Conversation}}", "invalid prompt template"},
+		{"Unknown template", "tier1.tmpl", "{{.Content}}", "unknown prompt template file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.body), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadDir(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), tt.file) {
+				t.Errorf("Expected %q naming %s, got %v", tt.want, tt.file, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package prompts
+
+import "github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+
+// defaultBodies are the built-in template bodies, rendered with Data
+var defaultBodies = map[llm.PromptTemplate]string{
+	// First summary attempt: standard prompt
+	llm.PromptSummary: `Analyze this Claude conversation and provide a concise summary:
+
+1. Main topic/domain (e.g., "React development", "Python scripting")
+2. Key tasks accomplished
+3. Important outcomes or decisions
+4. Session complexity (Simple/Moderate/Complex)
+
+Keep it under 150 words. Focus only on the actual conversation content between user and assistant.
+
+Conversation data:
+{{.Content}}`,
+
+	// Summary retries: strict prompt with system/role/few-shot techniques
+	llm.PromptSummaryRetry: `SYSTEM: You are a professional conversation analyst. Your role is to provide objective, third-person analysis of completed conversations.
+
+CRITICAL RULES:
+1. Write ONLY in third person (never use "I", "we", "you")
+2. Provide ANALYTICAL SUMMARY (not conversational responses)
+3. Do NOT engage, validate, question, or provide advice
+4. Do NOT start with exclamations, agreements, or disagreements (no "!", "No!", "Yes!", "You're right")
+
+EXAMPLE - WRONG (Conversational):
+"No! We're not removing that functionality. Let me explain the fix..."
+"You're absolutely right! I made an error. Here's what we should do..."
+
+EXAMPLE - CORRECT (Analytical):
+"**Domain**: Python backend development
+**Main Topic**: Debugging structured output retry wrapper implementation
+**Key Tasks**: Resolved schema initialization issue in criterion analysis wrapper
+**Complexity**: Moderate"
+
+YOUR TASK: Analyze the conversation below and provide a structured summary with:
+- Main topic/domain
+- Key tasks accomplished
+- Important outcomes
+- Complexity level (Simple/Moderate/Complex)
+
+Write objectively in third person. Maximum 150 words.
+
+Conversation:
+{{.Content}}`,
+
+	// Whole-session structured analysis
+	llm.PromptTier1Direct: `Analyze this Claude conversation and break it into development episodes.
+
+Reply with JSON only, in this shape:
+{
+  "episodes": [{"id": "ep1", "phase": "exploration|implementation|debugging|testing|refactoring|documentation", "confidence": 0.0-1.0, "description": "...", "start_line": 1, "end_line": 10}],
+  "patterns": {"workflow": "...", "efficiency": "..."},
+  "recommendations": ["..."],
+  "metadata": {}
+}
+
+Conversation data:
+{{.Content}}`,
+
+	// One window of a windowed structured analysis; Content holds the window's messages,
+	// one "[line N] type: content" line each
+	llm.PromptTier2Window: `Analyze window {{.Window}} of {{.TotalWindows}} of a Claude conversation (lines {{.StartLine}}-{{.EndLine}}).
+Identify development episodes (phase, start_line, end_line, description, confidence) and reply with JSON only:
+{"episodes": [...], "patterns": {"workflow": "...", "efficiency": "..."}, "recommendations": [...], "metadata": {}}
+Episodes may continue from the previous window or into the next one; use the source line numbers shown.
+
+Conversation:
+{{.Content}}`,
+}
@@ -0,0 +1,155 @@
+// Package retry holds the backend-neutral retry helpers every llm.Analyzer shares:
+// transport retries with exponential backoff, and structured prompts whose invalid
+// replies are resent with a corrective instruction.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"strings"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// DefaultStructuredRetries is how many corrective retries SendStructured makes when a
+// backend has no processing config set
+const DefaultStructuredRetries = 2
+
+// ValidationError is returned by SendStructured when no attempt produced valid
+// Analysis JSON. It carries the validation errors of the last attempt.
+type ValidationError struct {
+	Attempts int
+	Errors   []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("response failed validation after %d attempt(s): %s", e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// sleep waits for d or until ctx is done; tests replace it to record delays
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// jitter randomizes a backoff delay to somewhere in [d/2, d] so concurrent callers
+// don't retry in lockstep; tests replace it to make delays deterministic
+var jitter = func(d time.Duration) time.Duration {
+	return d/2 + time.Duration(mathrand.Int63n(int64(d/2)+1))
+}
+
+// backoffDelay returns the delay before retry number attempt (0-based): base doubled
+// per attempt and clamped at maxDelay (0 means uncapped)
+func backoffDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < attempt; i++ {
+		if maxDelay > 0 && delay >= maxDelay {
+			break
+		}
+		if delay > math.MaxInt64/2 {
+			return time.Duration(math.MaxInt64)
+		}
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// Do calls send until it succeeds, retrying failures that retryable accepts up to
+// cfg.MaxRetries times. Delays start at cfg.RetryDelay and double after each attempt
+// up to cfg.RetryMaxDelay, with jitter applied. Other failures, and any failure once
+// ctx is done, are returned immediately.
+func Do(ctx context.Context, cfg llm.ProcessingConfig, retryable func(error) bool, send func() (string, error)) (string, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := send()
+		if err == nil {
+			return response, nil
+		}
+		if attempt >= cfg.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			return "", err
+		}
+
+		if delay := backoffDelay(attempt, cfg.RetryDelay, cfg.RetryMaxDelay); delay > 0 {
+			if sleep(ctx, jitter(delay)) != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// Sender sends a prompt and returns the model's raw text reply; every llm.Analyzer
+// backend implements it
+type Sender interface {
+	SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error)
+}
+
+// SendStructured implements SendStructuredPrompt on top of any conversational sender,
+// retrying invalid replies with a corrective instruction up to processing.MaxRetries
+// times, so every backend validates and corrects structured replies the same way.
+// Accepted replies are tidied with llm.NormalizeAnalysis, and their metadata records
+// under llm.InfoPromptVariant whether a corrective retry was needed. Returns a
+// *ValidationError with the last attempt's errors if no reply is valid.
+func SendStructured(ctx context.Context, sender Sender, prompt string, sessionID string, processing llm.ProcessingConfig, validation validator.Options) (*llm.Analysis, error) {
+	maxRetries := processing.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	// The prompt asks for empty metadata; callers stamp it once the reply is accepted
+	opts := validation
+	opts.AllowMissingTimestamp = true
+
+	currentPrompt := prompt
+	var lastErrors []string
+	for attempt := 1; attempt <= maxRetries+1; attempt++ {
+		response, err := sender.SendConversationalPrompt(ctx, currentPrompt, sessionID)
+		if err != nil {
+			// Transport or timeout failure - a corrective prompt won't help
+			return nil, err
+		}
+
+		result := validator.ValidateAnalysisJSONWithOptions(response, opts)
+		if result.Valid {
+			variant := llm.PromptVariantInitial
+			if attempt > 1 {
+				variant = llm.PromptVariantCorrective
+			}
+			llm.NormalizeAnalysis(result.Extracted)
+			result.Extracted.Metadata.SetInfo(llm.InfoPromptVariant, variant)
+			return result.Extracted, nil
+		}
+		lastErrors = result.Errors
+
+		if attempt <= maxRetries && processing.RetryDelay > 0 {
+			if err := sleep(ctx, processing.RetryDelay); err != nil {
+				return nil, err
+			}
+		}
+		currentPrompt = prompt + correctiveInstruction(result.Errors)
+	}
+
+	return nil, &ValidationError{Attempts: maxRetries + 1, Errors: lastErrors}
+}
+
+// correctiveInstruction echoes validation errors back to the model for a retry
+func correctiveInstruction(errors []string) string {
+	var b strings.Builder
+	b.WriteString("\n\nYOUR PREVIOUS RESPONSE WAS REJECTED. It failed validation with these errors:\n")
+	for _, e := range errors {
+		b.WriteString("- " + e + "\n")
+	}
+	b.WriteString("Respond again with ONLY a valid JSON object matching the requested structure, with no surrounding text.")
+	return b.String()
+}
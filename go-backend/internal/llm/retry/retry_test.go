@@ -0,0 +1,124 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+)
+
+// TestBackoffDelay tests the exponential schedule and the cap
+func TestBackoffDelay(t *testing.T) {
+	expected := []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		30 * time.Second,
+		30 * time.Second,
+	}
+	for attempt, want := range expected {
+		if got := backoffDelay(attempt, time.Second, 30*time.Second); got != want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	if got := backoffDelay(100, time.Second, 0); got <= 0 {
+		t.Errorf("Expected uncapped delay not to overflow, got %v", got)
+	}
+}
+
+// TestDoBackoffSchedule tests the delays Do sleeps between attempts
+func TestDoBackoffSchedule(t *testing.T) {
+	var delays []time.Duration
+	origSleep, origJitter := sleep, jitter
+	sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	jitter = func(d time.Duration) time.Duration { return d }
+	defer func() { sleep, jitter = origSleep, origJitter }()
+
+	calls := 0
+	_, err := Do(context.Background(), llm.ProcessingConfig{
+		MaxRetries:    5,
+		RetryDelay:    time.Second,
+		RetryMaxDelay: 5 * time.Second,
+	}, func(error) bool { return true }, func() (string, error) {
+		calls++
+		return "", llm.ErrEmptyResponse
+	})
+	if err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+	if calls != 6 {
+		t.Errorf("Expected 6 attempts, got %d", calls)
+	}
+
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %v", len(expected), delays)
+	}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Delay %d = %v, want %v", i, delays[i], expected[i])
+		}
+	}
+}
+
+// TestDoPermanentFailure tests that a failure retryable rejects is returned at once
+func TestDoPermanentFailure(t *testing.T) {
+	permanent := errors.New("bad request")
+	calls := 0
+	_, err := Do(context.Background(), llm.ProcessingConfig{MaxRetries: 3, RetryDelay: time.Hour},
+		func(err error) bool { return err != permanent },
+		func() (string, error) {
+			calls++
+			return "", permanent
+		})
+	if err != permanent || calls != 1 {
+		t.Errorf("Expected one attempt returning the failure, got %d and %v", calls, err)
+	}
+}
+
+// TestSendStructuredCorrects tests that an invalid reply is resent with its validation
+// errors, and that exhausting the retries returns a *ValidationError
+func TestSendStructuredCorrects(t *testing.T) {
+	valid := `{"episodes":[{"id":"ep1","phase":"debugging","confidence":0.8,"description":"Fixed a bug","start_line":1,"end_line":5}],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":[],"metadata":{}}`
+	sender := &fakeSender{replies: []string{"not json", valid}}
+
+	analysis, err := SendStructured(context.Background(), sender, "Analyze this", "", llm.ProcessingConfig{MaxRetries: 1}, validator.Options{})
+	if err != nil {
+		t.Fatalf("SendStructured failed: %v", err)
+	}
+	if len(analysis.Episodes) != 1 || analysis.Metadata.HierarchicalInfo[llm.InfoPromptVariant] != llm.PromptVariantCorrective {
+		t.Errorf("Unexpected analysis: %+v", analysis)
+	}
+	if len(sender.prompts) != 2 || !strings.Contains(sender.prompts[1], "YOUR PREVIOUS RESPONSE WAS REJECTED") {
+		t.Errorf("Expected a corrective retry, got %q", sender.prompts)
+	}
+
+	sender = &fakeSender{replies: []string{"not json"}}
+	_, err = SendStructured(context.Background(), sender, "Analyze this", "", llm.ProcessingConfig{MaxRetries: 1}, validator.Options{})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Attempts != 2 {
+		t.Errorf("Expected *ValidationError after 2 attempts, got %v", err)
+	}
+}
+
+// fakeSender replies with replies in order, repeating the last one
+type fakeSender struct {
+	replies []string
+	prompts []string
+}
+
+func (s *fakeSender) SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	reply := s.replies[min(len(s.prompts), len(s.replies))-1]
+	return reply, nil
+}
@@ -0,0 +1,28 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidSessionID is returned for session IDs that are unsafe to use in file names
+var ErrInvalidSessionID = errors.New("invalid session ID")
+
+// ValidateSessionID checks that a caller-supplied session ID can be used as a file
+// name component, as backends do for temp directories and session files. IDs with
+// path separators, "..", or control characters are rejected, so a session ID can
+// never point outside the directory it is joined to. Empty IDs are left to callers,
+// which typically generate one.
+func ValidateSessionID(id string) error {
+	switch {
+	case strings.ContainsAny(id, `/\`):
+		return fmt.Errorf("%w %q: contains a path separator", ErrInvalidSessionID, id)
+	case strings.Contains(id, ".."):
+		return fmt.Errorf("%w %q: contains \"..\"", ErrInvalidSessionID, id)
+	case strings.IndexFunc(id, unicode.IsControl) >= 0:
+		return fmt.Errorf("%w %q: contains a control character", ErrInvalidSessionID, id)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateSessionID tests which session IDs are safe to use in file names
+func TestValidateSessionID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"", true},
+		{"0b7c3c3e-8a61-4b1f-9d3e-5f1e2a4c6d80", true},
+		{"my-session_2.v1", true},
+		{"../../etc/passwd", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"..", false},
+		{"session..old", false},
+		{"line\nbreak", false},
+		{"nul\x00byte", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSessionID(tt.id)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateSessionID(%q) = %v, want nil", tt.id, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidSessionID) {
+			t.Errorf("ValidateSessionID(%q) = %v, want ErrInvalidSessionID", tt.id, err)
+		}
+	}
+}
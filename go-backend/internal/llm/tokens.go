@@ -0,0 +1,50 @@
+package llm
+
+import "unicode"
+
+// Token thresholds used by SelectTier
+const (
+	// Tier1MaxTokens is the largest session analyzed with a single direct prompt. It also
+	// keeps the prompt well under the OS limit on a single command-line argument.
+	Tier1MaxTokens = 20000
+
+	// Tier2MaxTokens is the largest session analyzed with overlapping windows; larger
+	// sessions call for hierarchical (coarse then fine) analysis
+	Tier2MaxTokens = 100000
+)
+
+// EstimateTokens gives a rough token count for text without a tokenizer. Plain words
+// cost about one token per 4 characters; whitespace between words is free since it
+// merges into the next token, but newlines and ASCII punctuation (dense in code and
+// JSON) usually split tokens and cost more. CJK characters count one token each.
+func EstimateTokens(text string) int {
+	var wordChars, symbols, newlines, ideographs int
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			newlines++
+		case unicode.IsSpace(r):
+			// Absorbed into the following token
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			ideographs++
+		case r < unicode.MaxASCII && !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			symbols++
+		default:
+			wordChars++
+		}
+	}
+	return (wordChars+3)/4 + (symbols+1)/2 + (newlines+1)/2 + ideographs
+}
+
+// SelectTier maps an estimated token count to a processing tier: 1 for a direct
+// prompt, 2 for windowed analysis, and 3 for hierarchical analysis
+func SelectTier(tokenCount int) int {
+	switch {
+	case tokenCount <= Tier1MaxTokens:
+		return 1
+	case tokenCount <= Tier2MaxTokens:
+		return 2
+	default:
+		return 3
+	}
+}
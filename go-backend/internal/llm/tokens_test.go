@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEstimateTokens tests the heuristic on prose, code, and CJK text
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"Empty", "", 0},
+		{"Single word", "hello", 2},
+		{"Spaces are free", "the quick brown fox", 4},
+		{"Punctuation costs more", "f(a, b);", 3},
+		{"Newlines split tokens", "one\ntwo\nthree\n", 5},
+		{"CJK counts per character", "日本語のテキスト", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEstimateTokensCodeDenserThanProse tests that code of the same length costs more than prose
+func TestEstimateTokensCodeDenserThanProse(t *testing.T) {
+	prose := strings.Repeat("please refactor the parser module ", 20)
+	code := strings.Repeat("if (x[i] != y) { z(); }\n", 29)[:len(prose)]
+
+	if EstimateTokens(code) <= EstimateTokens(prose) {
+		t.Errorf("Expected code (%d) to estimate above prose (%d)", EstimateTokens(code), EstimateTokens(prose))
+	}
+}
+
+// TestSelectTier tests the tier thresholds
+func TestSelectTier(t *testing.T) {
+	tests := []struct {
+		tokens int
+		want   int
+	}{
+		{0, 1},
+		{Tier1MaxTokens, 1},
+		{Tier1MaxTokens + 1, 2},
+		{Tier2MaxTokens, 2},
+		{Tier2MaxTokens + 1, 3},
+	}
+
+	for _, tt := range tests {
+		if got := SelectTier(tt.tokens); got != tt.want {
+			t.Errorf("SelectTier(%d) = %d, want %d", tt.tokens, got, tt.want)
+		}
+	}
+}
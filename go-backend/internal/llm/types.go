@@ -4,12 +4,29 @@ import (
 	"time"
 )
 
+// FilteredMessage represents a simplified message extracted from a session JSONL file
+type FilteredMessage struct {
+	Type      string `json:"type"`
+	Content   string `json:"content"`
+	Timestamp string `json:"timestamp"`
+	Line      int    `json:"line,omitempty"` // 1-based line in the source JSONL file
+}
+
 // Analysis represents the complete analysis result from Claude
 type Analysis struct {
 	Episodes        []*Episode        `json:"episodes"`
 	Patterns        *WorkflowPatterns `json:"patterns"`
 	Recommendations []string          `json:"recommendations"`
 	Metadata        AnalysisMetadata  `json:"metadata"`
+
+	// Recommendations with how many merged inputs mentioned each, most frequent first
+	RankedRecommendations []RankedRecommendation `json:"ranked_recommendations,omitempty"`
+}
+
+// RankedRecommendation is a recommendation and the number of analyses that made it
+type RankedRecommendation struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
 }
 
 // Episode represents a single development episode
@@ -48,6 +65,16 @@ type AnalysisMetadata struct {
 	AnalysisVersion  string                 `json:"analysis_version"`
 	Timestamp        time.Time              `json:"timestamp"`
 	HierarchicalInfo map[string]interface{} `json:"hierarchical_info,omitempty"`
+
+	Truncation *TruncationInfo `json:"truncation,omitempty"` // Set when the content was cut to fit the input budget
+}
+
+// TruncationInfo records how content over the input budget was cut before analysis,
+// so a summary's scope is clear
+type TruncationInfo struct {
+	Strategy       string `json:"strategy"`        // Part kept: head, tail, or middle (both ends)
+	OriginalTokens int    `json:"original_tokens"` // Estimated tokens before the cut
+	ElidedChars    int    `json:"elided_chars"`    // Characters replaced by the elision marker
 }
 
 // WindowResult represents analysis result for a single window
@@ -60,6 +87,8 @@ type WindowResult struct {
 	ContinuesFrom bool                   `json:"continues_from_previous"`
 	OverlapRegion *OverlapInfo           `json:"overlap_region,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+
+	Recommendations []string `json:"recommendations,omitempty"` // Recommendations made for this window
 }
 
 // OverlapInfo contains information about window overlap regions
@@ -74,6 +103,8 @@ type OverlapInfo struct {
 type PromptTemplate string
 
 const (
+	PromptSummary          PromptTemplate = "summary"
+	PromptSummaryRetry     PromptTemplate = "summary_retry"
 	PromptTier1Direct      PromptTemplate = "tier1_direct"
 	PromptTier2Window      PromptTemplate = "tier2_window"
 	PromptTier3Coarse      PromptTemplate = "tier3_coarse"
@@ -84,6 +115,7 @@ const (
 type ProcessingConfig struct {
 	MaxRetries       int
 	RetryDelay       time.Duration
+	RetryMaxDelay    time.Duration // Cap on the backoff delay; 0 means uncapped
 	Timeout          time.Duration
 	CacheEnabled     bool
 	ParallelWindows  int
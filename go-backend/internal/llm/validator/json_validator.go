@@ -2,22 +2,89 @@ package validator
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
 )
 
+// MaxClockSkew is how far ahead of the current time a timestamp may be before it is flagged
+const MaxClockSkew = 5 * time.Minute
+
+// An episode's duration may differ from its timestamp span by durationTolerance of the
+// span, and always by less than minDurationMismatch, before a warning is raised
+const (
+	durationTolerance   = 0.25
+	minDurationMismatch = time.Minute
+)
+
+// DefaultPhases are the episode phases the analysis prompts ask for. Other phases are
+// accepted with a warning, so a typo is flagged without rejecting a novel phase.
+var DefaultPhases = []string{"exploration", "implementation", "debugging", "testing", "refactoring", "documentation", "review"}
+
+// now returns the current time; tests replace it to pin the clock
+var now = time.Now
+
 // ValidationResult represents the result of JSON validation
 type ValidationResult struct {
 	Valid      bool     `json:"valid"`
 	Errors     []string `json:"errors,omitempty"`
 	Warnings   []string `json:"warnings,omitempty"`
 	Extracted  *llm.Analysis `json:"extracted,omitempty"`
+
+	InvalidEpisodes []InvalidEpisode `json:"invalid_episodes,omitempty"` // Episodes with at least one error
+
+	SyntaxError *SyntaxErrorLocation `json:"syntax_error,omitempty"` // Where decoding failed, for "Invalid JSON syntax"
+}
+
+// SyntaxErrorLocation pinpoints where a response stopped being valid JSON
+type SyntaxErrorLocation struct {
+	Offset  int    `json:"offset"`  // Byte offset into the response of the first invalid byte
+	Line    int    `json:"line"`    // 1-based line of the offset
+	Column  int    `json:"column"`  // 1-based column, in characters
+	Snippet string `json:"snippet"` // The offending line, then a ^ under the offset
+}
+
+// InvalidEpisode pairs an episode that failed validation with its errors
+type InvalidEpisode struct {
+	Index   int          `json:"index"`
+	Episode *llm.Episode `json:"episode"`
+	Errors  []FieldError `json:"errors"`
+}
+
+// FieldError is a validation error located by the JSON path of the offending field
+type FieldError struct {
+	Path    string `json:"path"` // e.g. "episodes[2].phase"
+	Message string `json:"message"`
+}
+
+// Options adds caller-specific requirements on top of the schema checks
+type Options struct {
+	MinEpisodes int // Fail analyses with fewer episodes, e.g. when the model gave up early (0 = no minimum)
+
+	// AllowMissingTimestamp accepts a zero metadata timestamp. Model replies leave
+	// metadata empty and are stamped by the caller, so only saved analyses need one.
+	AllowMissingTimestamp bool
+
+	// AllowedPhases replaces DefaultPhases as the set of expected episode phases,
+	// compared case-insensitively
+	AllowedPhases []string
+
+	// Strict rejects trailing commas and // comments as syntax errors instead of
+	// removing them before decoding
+	Strict bool
 }
 
 // ValidateAnalysisJSON validates if the given text contains valid Analysis JSON
 func ValidateAnalysisJSON(text string) *ValidationResult {
+	return ValidateAnalysisJSONWithOptions(text, Options{})
+}
+
+// ValidateAnalysisJSONWithOptions validates like ValidateAnalysisJSON and also enforces opts
+func ValidateAnalysisJSONWithOptions(text string, opts Options) *ValidationResult {
 	result := &ValidationResult{
 		Valid:    false,
 		Errors:   []string{},
@@ -26,9 +93,10 @@ func ValidateAnalysisJSON(text string) *ValidationResult {
 
 	// Try to parse as direct JSON first
 	var analysis llm.Analysis
-	if err := json.Unmarshal([]byte(text), &analysis); err == nil {
+	candidate, repaired := opts.prepare(text)
+	if err := json.Unmarshal([]byte(candidate), &analysis); err == nil {
 		// Direct JSON worked, now validate structure
-		return validateAnalysisStructure(&analysis, result)
+		return checkDecoded(&analysis, result, opts, repaired)
 	}
 
 	// Try to extract JSON from markdown
@@ -38,16 +106,90 @@ func ValidateAnalysisJSON(text string) *ValidationResult {
 		return result
 	}
 
-	if err := json.Unmarshal([]byte(jsonStr), &analysis); err != nil {
+	candidate, repaired = opts.prepare(jsonStr)
+	if err := json.Unmarshal([]byte(candidate), &analysis); err != nil {
+		// Candidates are substrings of text, and repairs keep offsets, so an offset in
+		// the candidate maps straight back to the response
+		if offset, ok := decodeErrorOffset(err); ok {
+			result.SyntaxError = locateOffset(text, strings.Index(text, jsonStr)+offset)
+			err = fmt.Errorf("line %d, column %d: %w", result.SyntaxError.Line, result.SyntaxError.Column, err)
+		}
 		result.Errors = append(result.Errors, fmt.Sprintf("Invalid JSON syntax: %v", err))
 		return result
 	}
 
-	return validateAnalysisStructure(&analysis, result)
+	return checkDecoded(&analysis, result, opts, repaired)
+}
+
+// prepare returns candidate ready for decoding: unchanged in strict mode, otherwise with
+// trailing commas and comments removed. Reports whether anything was removed.
+func (opts Options) prepare(candidate string) (string, bool) {
+	if opts.Strict {
+		return candidate, false
+	}
+	relaxed := relaxJSON(candidate)
+	return relaxed, relaxed != candidate
+}
+
+// decodeErrorOffset returns the byte offset json.Unmarshal reported for err, if any
+func decodeErrorOffset(err error) (int, bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return int(syntaxErr.Offset), true
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return int(typeErr.Offset), true
+	}
+	return 0, false
+}
+
+// snippetRadius is how many characters of the offending line are shown on each side of
+// a syntax error
+const snippetRadius = 40
+
+// locateOffset describes the byte json.Unmarshal failed on by line and column, with a
+// snippet of its line marked by a caret. The decoder reports how many bytes it had read,
+// which includes the failing byte, so readOffset is one past it.
+func locateOffset(text string, readOffset int) *SyntaxErrorLocation {
+	offset := max(0, min(readOffset-1, len(text)))
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+	lineEnd := len(text)
+	if i := strings.IndexByte(text[offset:], '\n'); i != -1 {
+		lineEnd = offset + i
+	}
+
+	line := []rune(text[lineStart:lineEnd])
+	column := utf8.RuneCountInString(text[lineStart:offset])
+	from := max(0, column-snippetRadius)
+	to := min(len(line), column+snippetRadius)
+
+	return &SyntaxErrorLocation{
+		Offset:  offset,
+		Line:    strings.Count(text[:offset], "\n") + 1,
+		Column:  column + 1,
+		Snippet: string(line[from:to]) + "\n" + strings.Repeat(" ", column-from) + "^",
+	}
+}
+
+// checkDecoded notes a lenient repair, then validates the decoded analysis
+func checkDecoded(analysis *llm.Analysis, result *ValidationResult, opts Options, repaired bool) *ValidationResult {
+	if repaired {
+		result.Warnings = append(result.Warnings, "Removed trailing commas or comments before decoding")
+	}
+	return checkOptions(analysis, result, opts)
+}
+
+// checkOptions records violations of opts and then validates the analysis structure
+func checkOptions(analysis *llm.Analysis, result *ValidationResult, opts Options) *ValidationResult {
+	if opts.MinEpisodes > 0 && len(analysis.Episodes) < opts.MinEpisodes {
+		result.Errors = append(result.Errors, fmt.Sprintf("Analysis has %d episode(s), fewer than the minimum of %d", len(analysis.Episodes), opts.MinEpisodes))
+	}
+	return validateAnalysisStructure(analysis, result, opts)
 }
 
 // validateAnalysisStructure checks if the Analysis object has required fields
-func validateAnalysisStructure(analysis *llm.Analysis, result *ValidationResult) *ValidationResult {
+func validateAnalysisStructure(analysis *llm.Analysis, result *ValidationResult, opts Options) *ValidationResult {
 	// Check required fields
 	if analysis.Episodes == nil {
 		result.Errors = append(result.Errors, "Missing required field: episodes")
@@ -62,22 +204,78 @@ func validateAnalysisStructure(analysis *llm.Analysis, result *ValidationResult)
 		result.Warnings = append(result.Warnings, "Metadata appears incomplete")
 	}
 
+	// Analyses are sorted and displayed by their timestamp, so it must be set and sane.
+	// Timestamps ahead of now usually mean a clock or model error.
+	latest := now().Add(MaxClockSkew)
+	if analysis.Metadata.Timestamp.IsZero() {
+		if !opts.AllowMissingTimestamp {
+			result.Errors = append(result.Errors, "Missing metadata timestamp")
+		}
+	} else if analysis.Metadata.Timestamp.After(latest) {
+		result.Errors = append(result.Errors, fmt.Sprintf("Metadata timestamp %s is in the future", analysis.Metadata.Timestamp.Format(time.RFC3339)))
+	}
+
+	if len(analysis.Recommendations) == 0 {
+		result.Warnings = append(result.Warnings, "No recommendations")
+	}
+
+	phases := opts.AllowedPhases
+	if phases == nil {
+		phases = DefaultPhases
+	}
+
 	// Validate episodes structure
 	if analysis.Episodes != nil {
 		for i, episode := range analysis.Episodes {
+			// A null array element decodes to nil; there are no fields to check
+			if episode == nil {
+				message := fmt.Sprintf("Episode %d is null", i)
+				result.Errors = append(result.Errors, message)
+				result.InvalidEpisodes = append(result.InvalidEpisodes, InvalidEpisode{Index: i, Errors: []FieldError{{Path: fmt.Sprintf("episodes[%d]", i), Message: message}}})
+				continue
+			}
+
+			var fieldErrors []FieldError
+			addError := func(field, message string) {
+				result.Errors = append(result.Errors, message)
+				fieldErrors = append(fieldErrors, FieldError{Path: fmt.Sprintf("episodes[%d].%s", i, field), Message: message})
+			}
+
 			if episode.ID == "" {
-				result.Errors = append(result.Errors, fmt.Sprintf("Episode %d missing ID", i))
+				addError("id", fmt.Sprintf("Episode %d missing ID", i))
 			}
 			if episode.Phase == "" {
-				result.Errors = append(result.Errors, fmt.Sprintf("Episode %d missing phase", i))
+				addError("phase", fmt.Sprintf("Episode %d missing phase", i))
+			}
+			if episode.Phase != "" && !allowedPhase(episode.Phase, phases) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("Episode %d has unknown phase %q (expected one of: %s)", i, episode.Phase, strings.Join(phases, ", ")))
 			}
 			if episode.Description == "" {
 				result.Warnings = append(result.Warnings, fmt.Sprintf("Episode %d missing description", i))
 			}
 			if episode.Confidence < 0 || episode.Confidence > 1 {
-				result.Errors = append(result.Errors, fmt.Sprintf("Episode %d confidence must be between 0.0 and 1.0", i))
+				addError("confidence", fmt.Sprintf("Episode %d confidence must be between 0.0 and 1.0", i))
+			}
+			// Line ranges are highlighted in the source view, so they must be usable
+			if episode.StartLine < 1 {
+				addError("start_line", fmt.Sprintf("Episode %d start_line must be at least 1, got %d", i, episode.StartLine))
+			}
+			if episode.EndLine < episode.StartLine {
+				addError("end_line", fmt.Sprintf("Episode %d end_line %d is before start_line %d", i, episode.EndLine, episode.StartLine))
+			}
+			if episode.StartTime.After(latest) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("Episode %d start_time is in the future", i))
+			}
+			if episode.EndTime.After(latest) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("Episode %d end_time is in the future", i))
+			}
+			warnEpisodeDuration(i, episode, result)
+
+			if len(fieldErrors) > 0 {
+				result.InvalidEpisodes = append(result.InvalidEpisodes, InvalidEpisode{Index: i, Episode: episode, Errors: fieldErrors})
 			}
 		}
+		warnOverlappingEpisodes(analysis.Episodes, result)
 	}
 
 	// Validate patterns structure
@@ -99,53 +297,177 @@ func validateAnalysisStructure(analysis *llm.Analysis, result *ValidationResult)
 	return result
 }
 
-// Extracts JSON from markdown-wrapped response or raw text
+// warnEpisodeDuration warns when an episode's duration doesn't parse (see
+// llm.ParseDuration), or disagrees with its start_time to end_time span by more than
+// durationTolerance of the span and at least minDurationMismatch
+func warnEpisodeDuration(i int, episode *llm.Episode, result *ValidationResult) {
+	if episode.Duration == "" {
+		return
+	}
+	d, err := llm.ParseDuration(episode.Duration)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Episode %d duration %q is not a recognizable duration", i, episode.Duration))
+		return
+	}
+	if episode.StartTime.IsZero() || episode.EndTime.IsZero() || episode.EndTime.Before(episode.StartTime) {
+		return
+	}
+
+	span := episode.EndTime.Sub(episode.StartTime)
+	mismatch := d - span
+	if mismatch < 0 {
+		mismatch = -mismatch
+	}
+	if mismatch >= minDurationMismatch && float64(mismatch) > durationTolerance*float64(span) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Episode %d duration %s doesn't match its start_time to end_time span of %s", i, episode.Duration, span))
+	}
+}
+
+// warnOverlappingEpisodes warns about each pair of episodes whose line ranges share a
+// line. Null episodes and episodes with an invalid range were already reported and are
+// skipped.
+func warnOverlappingEpisodes(episodes []*llm.Episode, result *ValidationResult) {
+	for i, a := range episodes {
+		if a == nil || a.StartLine < 1 || a.EndLine < a.StartLine {
+			continue
+		}
+		for j := i + 1; j < len(episodes); j++ {
+			b := episodes[j]
+			if b == nil || b.StartLine < 1 || b.EndLine < b.StartLine {
+				continue
+			}
+			if a.StartLine <= b.EndLine && b.StartLine <= a.EndLine {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("Episodes %d and %d have overlapping line ranges (%d-%d and %d-%d)", i, j, a.StartLine, a.EndLine, b.StartLine, b.EndLine))
+			}
+		}
+	}
+}
+
+// allowedPhase reports whether phase is one of phases, ignoring case
+func allowedPhase(phase string, phases []string) bool {
+	for _, allowed := range phases {
+		if strings.EqualFold(phase, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractJSON finds the analysis JSON in a markdown-wrapped or chatty response. Models
+// sometimes put a small example or a {"thinking": ...} object before the real payload,
+// so every JSON code block and every top-level {...} object is considered, and the first
+// that decodes to an object with an "episodes" key wins. Otherwise the last complete
+// object is returned, or the last fenced block if no object was found.
 func extractJSON(text string) string {
-	// Look for JSON code block
-	start := strings.Index(text, "```json")
-	if start != -1 {
-		start += 7 // Skip ```json
+	fenced := fencedJSONBlocks(text)
+	objects := topLevelObjects(text)
+
+	for _, candidate := range append(fenced, objects...) {
+		if hasEpisodesKey(candidate) {
+			return candidate
+		}
+	}
+
+	if len(objects) > 0 {
+		return objects[len(objects)-1]
+	}
+	if len(fenced) > 0 {
+		return fenced[len(fenced)-1]
+	}
+	return ""
+}
+
+// fencedJSONBlocks returns the trimmed contents of each code block that holds JSON:
+// blocks tagged ```json, and untagged ``` blocks whose contents start with {
+func fencedJSONBlocks(text string) []string {
+	var blocks []string
+	for {
+		start := strings.Index(text, "```")
+		if start == -1 {
+			return blocks
+		}
+		start += 3 // Skip ```
 		end := strings.Index(text[start:], "```")
-		if end != -1 {
-			return strings.TrimSpace(text[start : start+end])
+		if end == -1 {
+			return blocks
+		}
+		block := text[start : start+end]
+		text = text[start+end+3:]
+
+		// The language tag runs to the end of the opening line
+		tag, body := "", block
+		if newline := strings.IndexByte(block, '\n'); newline != -1 {
+			tag, body = strings.TrimSpace(block[:newline]), block[newline+1:]
+		} else if strings.HasPrefix(block, "json") {
+			tag, body = "json", block[4:]
+		}
+		body = strings.TrimSpace(body)
+
+		if strings.EqualFold(tag, "json") || (tag == "" && strings.HasPrefix(body, "{")) {
+			blocks = append(blocks, body)
 		}
 	}
+}
 
-	// Look for raw JSON object
-	start = strings.Index(text, "{")
-	if start != -1 {
-		// Find matching closing brace
-		depth := 0
-		inString := false
-		escape := false
+// topLevelObjects returns each balanced {...} span in text that isn't nested in
+// another, ignoring braces inside JSON strings. A { that is never closed, such as one
+// in prose, is skipped so it can't swallow a later object.
+func topLevelObjects(text string) []string {
+	var objects []string
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' {
+			continue
+		}
+		if end := matchingBrace(text, i); end != -1 {
+			objects = append(objects, text[i:end+1])
+			i = end
+		}
+	}
+	return objects
+}
 
-		for i := start; i < len(text); i++ {
-			if escape {
-				escape = false
-				continue
-			}
+// matchingBrace returns the index of the } closing the { at start, or -1
+func matchingBrace(text string, start int) int {
+	depth := 0
+	inString := false
+	escape := false
 
-			switch text[i] {
-			case '\\':
-				escape = true
-			case '"':
-				inString = !inString
-			case '{':
-				if !inString {
-					depth++
-				}
-			case '}':
-				if !inString {
-					depth--
-					if depth == 0 {
-						return text[start : i+1]
-					}
+	for i := start; i < len(text); i++ {
+		if escape {
+			escape = false
+			continue
+		}
+
+		switch text[i] {
+		case '\\':
+			escape = true
+		case '"':
+			inString = !inString
+		case '{':
+			if !inString {
+				depth++
+			}
+		case '}':
+			if !inString {
+				depth--
+				if depth == 0 {
+					return i
 				}
 			}
 		}
 	}
+	return -1
+}
 
-	return ""
+// hasEpisodesKey reports whether candidate decodes to a JSON object with an episodes
+// key, forgiving the slips relaxJSON repairs so a sloppy analysis is still preferred
+func hasEpisodesKey(candidate string) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(relaxJSON(candidate)), &fields); err != nil {
+		return false
+	}
+	_, ok := fields["episodes"]
+	return ok
 }
 
 // FormatValidationErrors creates a human-readable error message
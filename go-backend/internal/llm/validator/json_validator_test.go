@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -74,6 +75,11 @@ func TestValidateAnalysisJSON(t *testing.T) {
 			}` + "\n```",
 			expectValid: true,
 		},
+		{
+			name:        "Valid JSON in bare fence",
+			input:       "```\n" + `{"episodes": [{"id": "ep1", "phase": "testing", "confidence": 0.9, "description": "ok", "start_line": 1, "end_line": 2}], "patterns": {"workflow": "linear", "efficiency": "high"}, "recommendations": ["a"], "metadata": {"timestamp": "2024-01-01T00:00:00Z"}}` + "\n```",
+			expectValid: true,
+		},
 		{
 			name:        "Missing episodes",
 			input:       `{"patterns": {"workflow": "test"}, "metadata": {}, "recommendations": []}`,
@@ -92,6 +98,11 @@ func TestValidateAnalysisJSON(t *testing.T) {
 			expectValid: false,
 			expectError: "Invalid JSON syntax",
 		},
+		{
+			name:        "Preamble JSON before the analysis",
+			input:       `{"thinking": "short session"}` + "\n" + `{"episodes": [{"id": "ep1", "phase": "testing", "confidence": 0.9, "description": "ok", "start_line": 1, "end_line": 2}], "patterns": {"workflow": "linear", "efficiency": "high"}, "recommendations": ["a"], "metadata": {"timestamp": "2024-01-01T00:00:00Z"}}`,
+			expectValid: true,
+		},
 		{
 			name:        "No JSON found",
 			input:       "This is just plain text without any JSON",
@@ -145,6 +156,8 @@ func TestValidateAnalysisStructure(t *testing.T) {
 						Phase:       "implementation",
 						Confidence:  0.9,
 						Description: "Test episode",
+						StartLine:   1,
+						EndLine:     10,
 					},
 				},
 				Patterns: &llm.WorkflowPatterns{
@@ -154,6 +167,7 @@ func TestValidateAnalysisStructure(t *testing.T) {
 				Metadata: llm.AnalysisMetadata{
 					Model:           "test-model",
 					AnalysisVersion: "1.0",
+					Timestamp:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 				},
 			},
 			expectValid: true,
@@ -166,6 +180,8 @@ func TestValidateAnalysisStructure(t *testing.T) {
 						Phase:       "implementation",
 						Confidence:  0.9,
 						Description: "Test episode",
+						StartLine:   1,
+						EndLine:     10,
 					},
 				},
 				Patterns: &llm.WorkflowPatterns{
@@ -185,6 +201,8 @@ func TestValidateAnalysisStructure(t *testing.T) {
 						ID:          "ep1",
 						Confidence:  0.9,
 						Description: "Test episode",
+						StartLine:   1,
+						EndLine:     10,
 					},
 				},
 				Patterns: &llm.WorkflowPatterns{
@@ -205,6 +223,8 @@ func TestValidateAnalysisStructure(t *testing.T) {
 						Phase:       "implementation",
 						Confidence:  1.5,
 						Description: "Test episode",
+						StartLine:   1,
+						EndLine:     10,
 					},
 				},
 				Patterns: &llm.WorkflowPatterns{
@@ -224,7 +244,7 @@ func TestValidateAnalysisStructure(t *testing.T) {
 				Errors:   []string{},
 				Warnings: []string{},
 			}
-			result = validateAnalysisStructure(tt.analysis, result)
+			result = validateAnalysisStructure(tt.analysis, result, Options{})
 
 			if result.Valid != tt.expectValid {
 				t.Errorf("Expected valid=%v, got %v. Errors: %v", tt.expectValid, result.Valid, result.Errors)
@@ -258,6 +278,26 @@ func TestExtractJSON(t *testing.T) {
 			input:    "```json\n{\"key\": \"value\"}\n```",
 			expected: "{\"key\": \"value\"}",
 		},
+		{
+			name:     "JSON in bare code block",
+			input:    "Here you go:\n```\n  {\"key\": \"value\"}\n```\nDone.",
+			expected: "{\"key\": \"value\"}",
+		},
+		{
+			name:     "Bare fence on one line",
+			input:    "```{\"key\": \"value\"}```",
+			expected: "{\"key\": \"value\"}",
+		},
+		{
+			name:     "Uppercase language tag",
+			input:    "```JSON\n{\"episodes\": []}\n```",
+			expected: "{\"episodes\": []}",
+		},
+		{
+			name:     "Other languages are skipped",
+			input:    "```go\nfunc f() { return }\n```\n```\n{\"episodes\": []}\n```",
+			expected: "{\"episodes\": []}",
+		},
 		{
 			name:     "Raw JSON object",
 			input:    "Some text before {\"key\": \"value\"} some text after",
@@ -278,6 +318,36 @@ func TestExtractJSON(t *testing.T) {
 			input:    "Just plain text without JSON",
 			expected: "",
 		},
+		{
+			name:     "Thinking block before analysis",
+			input:    `{"thinking": "Two episodes"} Here is the result: {"episodes": [], "patterns": {}}`,
+			expected: `{"episodes": [], "patterns": {}}`,
+		},
+		{
+			name:     "Example fence before analysis fence",
+			input:    "For example:\n```json\n{\"id\": \"ep1\"}\n```\nResult:\n```json\n{\"episodes\": [{\"id\": \"ep1\"}]}\n```",
+			expected: `{"episodes": [{"id": "ep1"}]}`,
+		},
+		{
+			name:     "Nested episodes key doesn't count",
+			input:    `{"note": {"episodes": []}} {"episodes": [1]}`,
+			expected: `{"episodes": [1]}`,
+		},
+		{
+			name:     "Falls back to last complete object",
+			input:    `{"a": 1} then {"b": 2}`,
+			expected: `{"b": 2}`,
+		},
+		{
+			name:     "Unclosed brace in prose",
+			input:    `Use { for blocks. {"episodes": []}`,
+			expected: `{"episodes": []}`,
+		},
+		{
+			name:     "Braces inside strings",
+			input:    `{"thinking": "a } b {"} {"episodes": [], "text": "{}"}`,
+			expected: `{"episodes": [], "text": "{}"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -404,6 +474,8 @@ func TestEpisodeValidation(t *testing.T) {
 				Phase:       "implementation",
 				Confidence:  1.5,
 				Description: "Invalid confidence",
+				StartLine:   1,
+				EndLine:     10,
 			},
 			expectErrors: 1,
 		},
@@ -414,6 +486,8 @@ func TestEpisodeValidation(t *testing.T) {
 				Phase:       "implementation",
 				Confidence:  -0.1,
 				Description: "Negative confidence",
+				StartLine:   1,
+				EndLine:     10,
 			},
 			expectErrors: 1,
 		},
@@ -427,14 +501,14 @@ func TestEpisodeValidation(t *testing.T) {
 					Workflow:   "test",
 					Efficiency: "test",
 				},
-				Metadata: llm.AnalysisMetadata{},
+				Metadata: llm.AnalysisMetadata{Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
 			}
 
 			result := &ValidationResult{
 				Errors:   []string{},
 				Warnings: []string{},
 			}
-			result = validateAnalysisStructure(analysis, result)
+			result = validateAnalysisStructure(analysis, result, Options{})
 
 			if len(result.Errors) != tt.expectErrors {
 				t.Errorf("Expected %d errors, got %d: %v", tt.expectErrors, len(result.Errors), result.Errors)
@@ -442,3 +516,471 @@ func TestEpisodeValidation(t *testing.T) {
 		})
 	}
 }
+
+// TestFutureTimestamps tests that timestamps ahead of the clock are flagged: a future
+// metadata timestamp fails validation, while future episode times only warn
+func TestFutureTimestamps(t *testing.T) {
+	fixedNow := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	now = func() time.Time { return fixedNow }
+	defer func() { now = oldNow }()
+
+	newAnalysis := func(metadataTime, episodeStart, episodeEnd time.Time) *llm.Analysis {
+		return &llm.Analysis{
+			Episodes: []*llm.Episode{
+				{
+					ID:          "ep1",
+					Phase:       "implementation",
+					Confidence:  0.9,
+					Description: "Test episode",
+					StartLine:   1,
+					EndLine:     10,
+					StartTime:   episodeStart,
+					EndTime:     episodeEnd,
+				},
+			},
+			Patterns: &llm.WorkflowPatterns{Workflow: "iterative", Efficiency: "high"},
+			Metadata: llm.AnalysisMetadata{
+				Model:           "test-model",
+				AnalysisVersion: "1.0",
+				Timestamp:       metadataTime,
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		analysis         *llm.Analysis
+		expectedError    string
+		expectedWarnings []string
+	}{
+		{
+			name:     "Present timestamps pass",
+			analysis: newAnalysis(fixedNow, fixedNow.Add(-time.Hour), fixedNow.Add(-30*time.Minute)),
+		},
+		{
+			name:     "Within clock skew passes",
+			analysis: newAnalysis(fixedNow.Add(MaxClockSkew-time.Second), fixedNow, fixedNow),
+		},
+		{
+			name:          "Future metadata timestamp",
+			analysis:      newAnalysis(fixedNow.Add(24*time.Hour), fixedNow, fixedNow),
+			expectedError: "Metadata timestamp 2025-01-16T12:00:00Z is in the future",
+		},
+		{
+			name:     "Future episode times",
+			analysis: newAnalysis(fixedNow, fixedNow.Add(time.Hour), fixedNow.Add(2*time.Hour)),
+			expectedWarnings: []string{
+				"Episode 0 start_time is in the future",
+				"Episode 0 end_time is in the future",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateAnalysisStructure(tt.analysis, &ValidationResult{Errors: []string{}, Warnings: []string{}}, Options{})
+			if tt.expectedError != "" {
+				if result.Valid || len(result.Errors) != 1 || result.Errors[0] != tt.expectedError {
+					t.Fatalf("Expected error %q, got valid=%v errors=%v", tt.expectedError, result.Valid, result.Errors)
+				}
+			} else if !result.Valid {
+				t.Fatalf("Future episode times should warn, not fail. Errors: %v", result.Errors)
+			}
+
+			var futureWarnings []string
+			for _, warning := range result.Warnings {
+				if strings.Contains(warning, "in the future") {
+					futureWarnings = append(futureWarnings, warning)
+				}
+			}
+			if len(futureWarnings) != len(tt.expectedWarnings) {
+				t.Fatalf("Expected warnings %v, got %v", tt.expectedWarnings, futureWarnings)
+			}
+			for i, expected := range tt.expectedWarnings {
+				if futureWarnings[i] != expected {
+					t.Errorf("Warning %d = %q, want %q", i, futureWarnings[i], expected)
+				}
+			}
+		})
+	}
+}
+
+// TestEpisodeDurationWarnings tests that an unparseable duration, or one far from the
+// episode's timestamp span, warns without failing validation
+func TestEpisodeDurationWarnings(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		duration string
+		end      time.Time
+		expected string
+	}{
+		{name: "No duration", end: start.Add(time.Hour)},
+		{name: "Matching span", duration: "1h", end: start.Add(time.Hour)},
+		{name: "Within tolerance", duration: "50 minutes", end: start.Add(time.Hour)},
+		{name: "Small absolute difference", duration: "90s", end: start.Add(2 * time.Minute)},
+		{name: "Without timestamps", duration: "3h"},
+		{name: "Unparseable", duration: "a while", end: start.Add(time.Hour), expected: `Episode 0 duration "a while" is not a recognizable duration`},
+		{name: "Mismatched span", duration: "3h", end: start.Add(time.Hour), expected: "Episode 0 duration 3h doesn't match its start_time to end_time span of 1h0m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			episode := &llm.Episode{ID: "ep1", Phase: "implementation", Confidence: 0.9, Description: "Test episode", StartLine: 1, EndLine: 10, Duration: tt.duration}
+			if !tt.end.IsZero() {
+				episode.StartTime, episode.EndTime = start, tt.end
+			}
+			analysis := &llm.Analysis{
+				Episodes: []*llm.Episode{episode},
+				Patterns: &llm.WorkflowPatterns{Workflow: "iterative", Efficiency: "high"},
+			}
+
+			result := validateAnalysisStructure(analysis, &ValidationResult{Errors: []string{}, Warnings: []string{}}, Options{AllowMissingTimestamp: true})
+			if !result.Valid {
+				t.Fatalf("Duration problems should warn, not fail. Errors: %v", result.Errors)
+			}
+
+			var durationWarnings []string
+			for _, warning := range result.Warnings {
+				if strings.Contains(warning, "duration") {
+					durationWarnings = append(durationWarnings, warning)
+				}
+			}
+			if tt.expected == "" {
+				if len(durationWarnings) != 0 {
+					t.Errorf("Expected no duration warnings, got %v", durationWarnings)
+				}
+			} else if len(durationWarnings) != 1 || durationWarnings[0] != tt.expected {
+				t.Errorf("Expected warning %q, got %v", tt.expected, durationWarnings)
+			}
+		})
+	}
+}
+
+// TestInvalidEpisodes tests that failing episodes are collected with the JSON paths of their errors
+func TestInvalidEpisodes(t *testing.T) {
+	analysis := &llm.Analysis{
+		Episodes: []*llm.Episode{
+			{ID: "ep1", Phase: "implementation", Confidence: 0.5, Description: "ok", StartLine: 1, EndLine: 5},
+			{ID: "ep2", Confidence: -1, StartLine: 6, EndLine: 9},
+		},
+		Patterns: &llm.WorkflowPatterns{Workflow: "test", Efficiency: "test"},
+	}
+
+	result := validateAnalysisStructure(analysis, &ValidationResult{}, Options{})
+
+	if len(result.InvalidEpisodes) != 1 {
+		t.Fatalf("Expected 1 invalid episode, got %+v", result.InvalidEpisodes)
+	}
+	invalid := result.InvalidEpisodes[0]
+	if invalid.Index != 1 || invalid.Episode != analysis.Episodes[1] {
+		t.Errorf("Unexpected invalid episode: %+v", invalid)
+	}
+	paths := []string{}
+	for _, fieldErr := range invalid.Errors {
+		paths = append(paths, fieldErr.Path)
+	}
+	if strings.Join(paths, ",") != "episodes[1].phase,episodes[1].confidence" {
+		t.Errorf("Unexpected paths: %v", paths)
+	}
+}
+
+// TestNullEpisode tests that a null episode is reported instead of dereferenced
+func TestNullEpisode(t *testing.T) {
+	text := `{"episodes":[null,{"id":"ep1","phase":"implementation","confidence":0.9,"description":"ok","start_line":1,"end_line":3}],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":["r"],"metadata":{"timestamp":"2025-01-01T00:00:00Z"}}`
+
+	result := ValidateAnalysisJSON(text)
+
+	if result.Valid {
+		t.Fatal("Expected a null episode to fail validation")
+	}
+	if len(result.InvalidEpisodes) != 1 {
+		t.Fatalf("Expected 1 invalid episode, got %+v", result.InvalidEpisodes)
+	}
+	invalid := result.InvalidEpisodes[0]
+	if invalid.Index != 0 || invalid.Episode != nil || len(invalid.Errors) != 1 || invalid.Errors[0].Path != "episodes[0]" {
+		t.Errorf("Unexpected invalid episode: %+v", invalid)
+	}
+}
+
+// TestMinEpisodes tests that Options.MinEpisodes fails analyses with too few episodes
+func TestMinEpisodes(t *testing.T) {
+	twoEpisodes := `{"episodes": [
+		{"id": "ep1", "phase": "exploration", "confidence": 0.8, "description": "a", "start_line": 1, "end_line": 5},
+		{"id": "ep2", "phase": "implementation", "confidence": 0.9, "description": "b", "start_line": 6, "end_line": 9}
+	], "patterns": {"workflow": "linear", "efficiency": "high"}, "metadata": {"timestamp": "2025-01-01T00:00:00Z"}}`
+
+	tests := []struct {
+		name        string
+		minEpisodes int
+		expectValid bool
+	}{
+		{"No minimum", 0, true},
+		{"Exactly enough", 2, true},
+		{"Too few", 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateAnalysisJSONWithOptions(twoEpisodes, Options{MinEpisodes: tt.minEpisodes})
+			if result.Valid != tt.expectValid {
+				t.Fatalf("Valid = %v, want %v (errors: %v)", result.Valid, tt.expectValid, result.Errors)
+			}
+			if !tt.expectValid {
+				if result.Extracted != nil {
+					t.Error("Expected no extracted analysis for an under-count")
+				}
+				if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "fewer than the minimum of 3") {
+					t.Errorf("Unexpected errors: %v", result.Errors)
+				}
+			}
+		})
+	}
+}
+
+// TestMetadataTimestampAndRecommendations tests that a missing timestamp fails unless
+// allowed, and that an analysis without recommendations only warns
+func TestMetadataTimestampAndRecommendations(t *testing.T) {
+	newAnalysis := func(timestamp time.Time, recommendations []string) *llm.Analysis {
+		return &llm.Analysis{
+			Episodes:        []*llm.Episode{{ID: "ep1", Phase: "implementation", Confidence: 0.9, Description: "ok", StartLine: 1, EndLine: 5}},
+			Patterns:        &llm.WorkflowPatterns{Workflow: "linear", Efficiency: "high"},
+			Recommendations: recommendations,
+			Metadata:        llm.AnalysisMetadata{Model: "test-model", Timestamp: timestamp},
+		}
+	}
+	stamped := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		analysis      *llm.Analysis
+		opts          Options
+		expectError   string
+		expectWarning bool // "No recommendations"
+	}{
+		{name: "Complete", analysis: newAnalysis(stamped, []string{"Add tests"})},
+		{name: "Missing timestamp", analysis: newAnalysis(time.Time{}, []string{"Add tests"}), expectError: "Missing metadata timestamp"},
+		{name: "Missing timestamp allowed", analysis: newAnalysis(time.Time{}, []string{"Add tests"}), opts: Options{AllowMissingTimestamp: true}},
+		{name: "Nil recommendations", analysis: newAnalysis(stamped, nil), expectWarning: true},
+		{name: "Empty recommendations", analysis: newAnalysis(stamped, []string{}), expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateAnalysisStructure(tt.analysis, &ValidationResult{}, tt.opts)
+
+			if tt.expectError == "" {
+				if !result.Valid {
+					t.Errorf("Expected valid, got errors %v", result.Errors)
+				}
+			} else if result.Valid || len(result.Errors) != 1 || result.Errors[0] != tt.expectError {
+				t.Errorf("Expected error %q, got %v", tt.expectError, result.Errors)
+			}
+
+			warned := false
+			for _, warning := range result.Warnings {
+				warned = warned || warning == "No recommendations"
+			}
+			if warned != tt.expectWarning {
+				t.Errorf("Expected recommendations warning = %v, got warnings %v", tt.expectWarning, result.Warnings)
+			}
+		})
+	}
+}
+
+// TestPhaseValidation tests that unknown phases warn without failing and that callers
+// can replace the allowed set
+func TestPhaseValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		phase         string
+		opts          Options
+		expectWarning bool
+	}{
+		{name: "Default phase", phase: "debugging"},
+		{name: "Case-insensitive", phase: "Testing"},
+		{name: "Typo", phase: "implmentation", expectWarning: true},
+		{name: "Novel phase", phase: "deployment", expectWarning: true},
+		{name: "Overridden set allows novel phase", phase: "deployment", opts: Options{AllowedPhases: []string{"deployment"}}},
+		{name: "Overridden set excludes defaults", phase: "debugging", opts: Options{AllowedPhases: []string{"deployment"}}, expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := &llm.Analysis{
+				Episodes:        []*llm.Episode{{ID: "ep1", Phase: tt.phase, Confidence: 0.9, Description: "ok", StartLine: 1, EndLine: 5}},
+				Patterns:        &llm.WorkflowPatterns{Workflow: "linear", Efficiency: "high"},
+				Recommendations: []string{"Add tests"},
+				Metadata:        llm.AnalysisMetadata{Model: "test-model", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}
+
+			result := validateAnalysisStructure(analysis, &ValidationResult{}, tt.opts)
+			if !result.Valid {
+				t.Fatalf("Unknown phases should warn, not fail. Errors: %v", result.Errors)
+			}
+
+			var phaseWarnings []string
+			for _, warning := range result.Warnings {
+				if strings.Contains(warning, "unknown phase") {
+					phaseWarnings = append(phaseWarnings, warning)
+				}
+			}
+			if (len(phaseWarnings) > 0) != tt.expectWarning {
+				t.Errorf("Expected phase warning = %v, got %v", tt.expectWarning, result.Warnings)
+			}
+			if tt.expectWarning && !strings.Contains(phaseWarnings[0], `"`+tt.phase+`"`) {
+				t.Errorf("Expected warning to name the phase, got %q", phaseWarnings[0])
+			}
+		})
+	}
+}
+
+// TestLineRangeValidation tests that impossible line ranges fail and overlapping
+// episodes warn
+func TestLineRangeValidation(t *testing.T) {
+	tests := []struct {
+		name            string
+		ranges          [][2]int
+		expectErrors    []string
+		expectOverlaps  []string
+		expectErrorPath string
+	}{
+		{name: "Sequential ranges", ranges: [][2]int{{1, 10}, {11, 20}}},
+		{name: "Single-line episode", ranges: [][2]int{{5, 5}}},
+		{
+			name:            "Zero start line",
+			ranges:          [][2]int{{0, 10}},
+			expectErrors:    []string{"Episode 0 start_line must be at least 1, got 0"},
+			expectErrorPath: "episodes[0].start_line",
+		},
+		{
+			name:            "Reversed range",
+			ranges:          [][2]int{{1, 10}, {50, 10}},
+			expectErrors:    []string{"Episode 1 end_line 10 is before start_line 50"},
+			expectErrorPath: "episodes[1].end_line",
+		},
+		{
+			name:           "Overlapping ranges",
+			ranges:         [][2]int{{1, 10}, {8, 20}, {30, 40}, {35, 36}},
+			expectOverlaps: []string{"Episodes 0 and 1 have overlapping line ranges (1-10 and 8-20)", "Episodes 2 and 3 have overlapping line ranges (30-40 and 35-36)"},
+		},
+		{
+			name:            "Invalid range isn't also an overlap",
+			ranges:          [][2]int{{1, 10}, {9, 2}},
+			expectErrors:    []string{"Episode 1 end_line 2 is before start_line 9"},
+			expectErrorPath: "episodes[1].end_line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := &llm.Analysis{
+				Patterns:        &llm.WorkflowPatterns{Workflow: "linear", Efficiency: "high"},
+				Recommendations: []string{"Add tests"},
+				Metadata:        llm.AnalysisMetadata{Model: "test-model", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}
+			for i, r := range tt.ranges {
+				analysis.Episodes = append(analysis.Episodes, &llm.Episode{
+					ID: fmt.Sprintf("ep%d", i+1), Phase: "testing", Confidence: 0.9, Description: "ok",
+					StartLine: r[0], EndLine: r[1],
+				})
+			}
+
+			result := validateAnalysisStructure(analysis, &ValidationResult{}, Options{})
+
+			if strings.Join(result.Errors, "|") != strings.Join(tt.expectErrors, "|") {
+				t.Errorf("Expected errors %v, got %v", tt.expectErrors, result.Errors)
+			}
+			if tt.expectErrorPath != "" {
+				if len(result.InvalidEpisodes) != 1 || result.InvalidEpisodes[0].Errors[0].Path != tt.expectErrorPath {
+					t.Errorf("Expected invalid episode at %s, got %+v", tt.expectErrorPath, result.InvalidEpisodes)
+				}
+			}
+
+			var overlaps []string
+			for _, warning := range result.Warnings {
+				if strings.Contains(warning, "overlapping") {
+					overlaps = append(overlaps, warning)
+				}
+			}
+			if strings.Join(overlaps, "|") != strings.Join(tt.expectOverlaps, "|") {
+				t.Errorf("Expected overlap warnings %v, got %v", tt.expectOverlaps, overlaps)
+			}
+		})
+	}
+}
+
+// TestSyntaxErrorLocation tests that a syntax error is located in the original
+// response, past any prose before the JSON
+func TestSyntaxErrorLocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantOffset  int
+		wantLine    int
+		wantColumn  int
+		wantSnippet string
+	}{
+		{
+			name:        "Missing value",
+			input:       `{"episodes": [], "patterns": }`,
+			wantOffset:  29,
+			wantLine:    1,
+			wantColumn:  30,
+			wantSnippet: `{"episodes": [], "patterns": }` + "\n" + strings.Repeat(" ", 29) + "^",
+		},
+		{
+			name:        "After prose on a later line",
+			input:       "Here is the analysis:\n```json\n{\n  \"episodes\": [\n    {\"id\": \"ep1\" \"phase\": \"testing\"}\n  ]\n}\n```",
+			wantOffset:  strings.Index("Here is the analysis:\n```json\n{\n  \"episodes\": [\n    {\"id\": \"ep1\" \"phase\": \"testing\"}\n  ]\n}\n```", `"phase"`),
+			wantLine:    5,
+			wantColumn:  18,
+			wantSnippet: `    {"id": "ep1" "phase": "testing"}` + "\n" + strings.Repeat(" ", 17) + "^",
+		},
+		{
+			name:        "Wrong type points at the end of the value",
+			input:       `Result: {"episodes": "none"}`,
+			wantOffset:  26,
+			wantLine:    1,
+			wantColumn:  27,
+			wantSnippet: `Result: {"episodes": "none"}` + "\n" + strings.Repeat(" ", 26) + "^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateAnalysisJSON(tt.input)
+			loc := result.SyntaxError
+			if loc == nil {
+				t.Fatalf("Expected a syntax error location, got errors %v", result.Errors)
+			}
+			if loc.Offset != tt.wantOffset || loc.Line != tt.wantLine || loc.Column != tt.wantColumn {
+				t.Errorf("Got offset %d line %d column %d, want %d %d %d", loc.Offset, loc.Line, loc.Column, tt.wantOffset, tt.wantLine, tt.wantColumn)
+			}
+			if loc.Snippet != tt.wantSnippet {
+				t.Errorf("Snippet =\n%s\nwant\n%s", loc.Snippet, tt.wantSnippet)
+			}
+			wantPrefix := fmt.Sprintf("Invalid JSON syntax: line %d, column %d: ", tt.wantLine, tt.wantColumn)
+			if len(result.Errors) != 1 || !strings.HasPrefix(result.Errors[0], wantPrefix) {
+				t.Errorf("Expected error starting %q, got %v", wantPrefix, result.Errors)
+			}
+		})
+	}
+}
+
+// TestSyntaxErrorSnippetTruncated tests that a long line is cut down around the error
+func TestSyntaxErrorSnippetTruncated(t *testing.T) {
+	input := `{"episodes": [], "recommendations": ["` + strings.Repeat("x", 100) + `" "` + strings.Repeat("y", 100) + `"]}`
+	loc := ValidateAnalysisJSON(input).SyntaxError
+	if loc == nil {
+		t.Fatal("Expected a syntax error location")
+	}
+	lines := strings.Split(loc.Snippet, "\n")
+	if len([]rune(lines[0])) != 2*snippetRadius {
+		t.Errorf("Expected a %d-character snippet, got %q", 2*snippetRadius, lines[0])
+	}
+	if caret := strings.Index(lines[1], "^"); lines[0][caret] != '"' || caret != snippetRadius {
+		t.Errorf("Expected caret under the unexpected quote, got\n%s", loc.Snippet)
+	}
+}
@@ -0,0 +1,72 @@
+package validator
+
+import "strings"
+
+// relaxJSON rewrites the JSON5-style slips models make into strict JSON: // line
+// comments are removed, and so is a comma directly before a closing } or ]. Removed
+// bytes become spaces, so offsets in the result match the input. Text inside strings is
+// left alone. Anything else that isn't valid JSON is passed through for json.Unmarshal
+// to report.
+func relaxJSON(text string) string {
+	if !strings.Contains(text, "//") && !strings.Contains(text, ",") {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	inString := false
+	escape := false
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			b.WriteByte(c)
+			switch {
+			case escape:
+				escape = false
+			case c == '\\':
+				escape = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			// Blank the comment but keep the newline that ends it
+			b.WriteByte(' ')
+			for i+1 < len(text) && text[i+1] != '\n' {
+				b.WriteByte(' ')
+				i++
+			}
+		case c == ',' && closesAfterComma(text, i+1):
+			// Trailing comma
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// closesAfterComma reports whether the next significant character at or after i, skipping
+// whitespace and // comments, closes an object or array
+func closesAfterComma(text string, i int) bool {
+	for i < len(text) {
+		switch c := text[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		default:
+			return c == '}' || c == ']'
+		}
+	}
+	return false
+}
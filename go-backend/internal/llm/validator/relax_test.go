@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRelaxJSON tests blanking of trailing commas and line comments outside strings
+func TestRelaxJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Strict JSON unchanged", `{"a": [1, 2], "b": "x"}`, `{"a": [1, 2], "b": "x"}`},
+		{"Trailing comma in object", `{"a": 1,}`, `{"a": 1 }`},
+		{"Trailing comma in array", "[1, 2,\n]", "[1, 2 \n]"},
+		{"Line comment", "{\n  // eps\n  \"a\": 1 // one\n}", "{\n        \n  \"a\": 1       \n}"},
+		{"Comment after trailing comma", "{\"a\": 1, // z\n}", "{\"a\": 1      \n}"},
+		{"Comma and slashes in strings kept", `{"url": "https://x.io/a,", "s": ",}"}`, `{"url": "https://x.io/a,", "s": ",}"}`},
+		{"Escaped quote in string", `{"q": "say \"hi,\"",}`, `{"q": "say \"hi,\"" }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := relaxJSON(tt.input)
+			if got != tt.want {
+				t.Errorf("relaxJSON(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if len(got) != len(tt.input) {
+				t.Errorf("Expected offsets preserved, length %d became %d", len(tt.input), len(got))
+			}
+		})
+	}
+}
+
+// sloppyAnalysis has a trailing comma and comments, as models sometimes write
+const sloppyAnalysis = `{
+	// Episodes found in the session
+	"episodes": [
+		{"id": "ep1", "phase": "testing", "confidence": 0.9, "description": "ok", "start_line": 1, "end_line": 2},
+	],
+	"patterns": {"workflow": "linear", "efficiency": "high",},
+	"recommendations": ["Add tests"], // only one
+	"metadata": {"model": "m", "timestamp": "2024-01-01T00:00:00Z"}
+}`
+
+// TestValidateLenientJSON tests that trailing commas and comments are repaired by
+// default, in bare and fenced replies, and rejected with Options.Strict
+func TestValidateLenientJSON(t *testing.T) {
+	for _, input := range []string{sloppyAnalysis, "Analysis:\n```json\n" + sloppyAnalysis + "\n```"} {
+		result := ValidateAnalysisJSON(input)
+		if !result.Valid {
+			t.Fatalf("Expected lenient validation to pass, got %v", result.Errors)
+		}
+		if len(result.Extracted.Episodes) != 1 || result.Extracted.Recommendations[0] != "Add tests" {
+			t.Errorf("Unexpected analysis: %+v", result.Extracted)
+		}
+		if !strings.Contains(strings.Join(result.Warnings, "|"), "Removed trailing commas or comments") {
+			t.Errorf("Expected a repair warning, got %v", result.Warnings)
+		}
+
+		strict := ValidateAnalysisJSONWithOptions(input, Options{Strict: true})
+		if strict.Valid || len(strict.Errors) != 1 || !strings.HasPrefix(strict.Errors[0], "Invalid JSON syntax") {
+			t.Errorf("Expected a syntax error in strict mode, got %v", strict.Errors)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package window
+
+import (
+	"sort"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// MergeWindowResults combines per-window analyses into a single Analysis.
+// Episodes from different windows that share a phase and whose line ranges overlap
+// or touch are treated as the same episode seen across a window boundary (typically
+// the tail of window N and the head of window N+1) and stitched into one.
+// Recommendations are ranked by how many windows made them (see RankRecommendations).
+// Input results are not modified.
+func MergeWindowResults(results []*llm.WindowResult) *llm.Analysis {
+	ordered := make([]*llm.WindowResult, 0, len(results))
+	for _, result := range results {
+		if result != nil {
+			ordered = append(ordered, result)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].WindowIndex < ordered[j].WindowIndex
+	})
+
+	type sourcedEpisode struct {
+		episode *llm.Episode
+		window  int
+	}
+
+	var candidates []sourcedEpisode
+	for _, result := range ordered {
+		for _, episode := range result.Episodes {
+			if episode == nil {
+				continue
+			}
+			copied := *episode
+			candidates = append(candidates, sourcedEpisode{episode: &copied, window: result.WindowIndex})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].episode.StartLine < candidates[j].episode.StartLine
+	})
+
+	episodes := []*llm.Episode{}
+	lastWindow := -1
+	for _, candidate := range candidates {
+		if n := len(episodes); n > 0 {
+			last := episodes[n-1]
+			if candidate.window != lastWindow && continuesEpisode(last, candidate.episode) {
+				last.Merge(candidate.episode)
+				lastWindow = candidate.window
+				continue
+			}
+		}
+		episodes = append(episodes, candidate.episode)
+		lastWindow = candidate.window
+	}
+
+	lists := make([][]string, len(ordered))
+	for i, result := range ordered {
+		lists[i] = result.Recommendations
+	}
+	ranked := RankRecommendations(lists...)
+	recommendations := make([]string, len(ranked))
+	for i, rec := range ranked {
+		recommendations[i] = rec.Text
+	}
+
+	analysis := &llm.Analysis{
+		Episodes:              episodes,
+		Recommendations:       recommendations,
+		RankedRecommendations: ranked,
+		Metadata: llm.AnalysisMetadata{
+			ProcessingTier: 2,
+			WindowCount:    len(ordered),
+		},
+	}
+	analysis.Metadata.SetInfo(llm.InfoPromptTemplate, string(llm.PromptTier2Window))
+	return analysis
+}
+
+// continuesEpisode reports whether next is the same episode as prev: same phase with
+// a line range that overlaps or directly follows prev's range
+func continuesEpisode(prev, next *llm.Episode) bool {
+	return prev.Phase == next.Phase && next.StartLine <= prev.EndLine+1
+}
@@ -0,0 +1,139 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestMergeWindowResultsStitchesBoundaryEpisode tests an episode at the tail of window N
+// and the head of window N+1 being merged into one
+func TestMergeWindowResultsStitchesBoundaryEpisode(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	results := []*llm.WindowResult{
+		{
+			WindowIndex: 0,
+			ContinuesTo: true,
+			Episodes: []*llm.Episode{
+				{ID: "w0-ep1", Phase: "exploration", StartLine: 1, EndLine: 10, Confidence: 0.8},
+				{
+					ID: "w0-ep2", Phase: "debugging", StartLine: 11, EndLine: 20, Confidence: 0.6,
+					Description: "Debugging flaky test",
+					StartTime:   base, EndTime: base.Add(10 * time.Minute),
+					KeyInsights: []string{"race in setup"},
+				},
+			},
+			OverlapRegion: &llm.OverlapInfo{StartLine: 16, EndLine: 20, Phase: "debugging"},
+		},
+		{
+			WindowIndex:   1,
+			ContinuesFrom: true,
+			Episodes: []*llm.Episode{
+				{
+					ID: "w1-ep1", Phase: "debugging", StartLine: 16, EndLine: 30, Confidence: 0.9,
+					Description: "Debugging flaky integration test",
+					StartTime:   base.Add(5 * time.Minute), EndTime: base.Add(30 * time.Minute),
+					KeyInsights: []string{"race in setup", "fixed with mutex"},
+				},
+				{ID: "w1-ep2", Phase: "testing", StartLine: 31, EndLine: 40, Confidence: 0.7},
+			},
+		},
+	}
+
+	analysis := MergeWindowResults(results)
+
+	if len(analysis.Episodes) != 3 {
+		t.Fatalf("Expected 3 episodes after merge, got %d", len(analysis.Episodes))
+	}
+
+	merged := analysis.Episodes[1]
+	if merged.ID != "w0-ep2" {
+		t.Errorf("Expected merged episode to keep first ID, got %q", merged.ID)
+	}
+	if merged.StartLine != 11 || merged.EndLine != 30 {
+		t.Errorf("Expected merged range 11-30, got %d-%d", merged.StartLine, merged.EndLine)
+	}
+	if merged.Confidence != 0.9 {
+		t.Errorf("Expected highest confidence 0.9, got %v", merged.Confidence)
+	}
+	if merged.Description != "Debugging flaky integration test" {
+		t.Errorf("Expected the more detailed description, got %q", merged.Description)
+	}
+	if !merged.StartTime.Equal(base) || !merged.EndTime.Equal(base.Add(30*time.Minute)) {
+		t.Errorf("Expected merged time range, got %v - %v", merged.StartTime, merged.EndTime)
+	}
+	if merged.Duration != "30m0s" {
+		t.Errorf("Expected recomputed duration 30m0s, got %q", merged.Duration)
+	}
+	if len(merged.KeyInsights) != 2 {
+		t.Errorf("Expected unioned insights, got %v", merged.KeyInsights)
+	}
+
+	if analysis.Metadata.ProcessingTier != 2 || analysis.Metadata.WindowCount != 2 {
+		t.Errorf("Unexpected metadata: %+v", analysis.Metadata)
+	}
+	if template := analysis.Metadata.PromptTemplate(); template != llm.PromptTier2Window {
+		t.Errorf("Expected prompt template %s, got %q", llm.PromptTier2Window, template)
+	}
+
+	// Inputs must not be modified
+	if results[0].Episodes[1].EndLine != 20 {
+		t.Error("MergeWindowResults modified its input episodes")
+	}
+}
+
+// TestMergeWindowResultsKeepsDistinctEpisodes tests that episodes which don't continue
+// each other stay separate
+func TestMergeWindowResultsKeepsDistinctEpisodes(t *testing.T) {
+	results := []*llm.WindowResult{
+		{
+			WindowIndex: 1,
+			Episodes: []*llm.Episode{
+				{ID: "b", Phase: "implementation", StartLine: 25, EndLine: 40},
+			},
+		},
+		{
+			WindowIndex: 0,
+			Episodes: []*llm.Episode{
+				{ID: "a1", Phase: "implementation", StartLine: 1, EndLine: 10},
+				// Same phase and adjacent, but within one window: left for the model's split
+				{ID: "a2", Phase: "implementation", StartLine: 11, EndLine: 20},
+			},
+		},
+	}
+
+	analysis := MergeWindowResults(results)
+
+	expectedIDs := []string{"a1", "a2", "b"}
+	if len(analysis.Episodes) != len(expectedIDs) {
+		t.Fatalf("Expected %d episodes, got %d", len(expectedIDs), len(analysis.Episodes))
+	}
+	for i, id := range expectedIDs {
+		if analysis.Episodes[i].ID != id {
+			t.Errorf("Episode %d = %q, want %q", i, analysis.Episodes[i].ID, id)
+		}
+	}
+}
+
+// TestMergeWindowResultsDifferentPhases tests that overlapping episodes of different
+// phases are not merged
+func TestMergeWindowResultsDifferentPhases(t *testing.T) {
+	results := []*llm.WindowResult{
+		{WindowIndex: 0, Episodes: []*llm.Episode{{ID: "a", Phase: "debugging", StartLine: 1, EndLine: 20}}},
+		{WindowIndex: 1, Episodes: []*llm.Episode{{ID: "b", Phase: "testing", StartLine: 15, EndLine: 30}}},
+	}
+
+	if analysis := MergeWindowResults(results); len(analysis.Episodes) != 2 {
+		t.Errorf("Expected 2 episodes, got %d", len(analysis.Episodes))
+	}
+}
+
+// TestMergeWindowResultsEmpty tests merging nothing
+func TestMergeWindowResultsEmpty(t *testing.T) {
+	analysis := MergeWindowResults(nil)
+	if analysis.Episodes == nil || len(analysis.Episodes) != 0 {
+		t.Errorf("Expected empty non-nil episodes, got %v", analysis.Episodes)
+	}
+}
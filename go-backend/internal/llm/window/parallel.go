@@ -0,0 +1,171 @@
+package window
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/prompts"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm/validator"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/metrics"
+)
+
+// AnalyzeFunc analyzes a single window and returns its result
+type AnalyzeFunc func(ctx context.Context, w Window) (*llm.WindowResult, error)
+
+// Prompter sends a prompt to the model and returns its raw text response.
+// *claude.Wrapper satisfies this interface.
+type Prompter interface {
+	SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error)
+}
+
+// WindowError records the failure of a single window
+type WindowError struct {
+	Index int
+	Err   error
+}
+
+func (e *WindowError) Error() string {
+	return fmt.Sprintf("window %d: %v", e.Index, e.Err)
+}
+
+func (e *WindowError) Unwrap() error {
+	return e.Err
+}
+
+// Counters recorded by AnalyzeWindows in the registry carried by its context
+const (
+	MetricWindowsAnalyzed = "session_viewer_windows_analyzed_total"
+	MetricWindowsFailed   = "session_viewer_windows_failed_total"
+)
+
+// AnalyzeWindows runs analyze over every window using at most cfg.ParallelWindows
+// goroutines (1 when unset) and returns the results in window order.
+//
+// The first failure cancels the context passed to the remaining calls and windows not
+// yet started are skipped. The returned error joins a *WindowError for every window
+// that failed on its own; calls that only failed because of that cancellation are not
+// reported separately.
+//
+// Each finished window is counted in the metrics registry from ctx (see
+// metrics.WithRegistry), so concurrent batches can share or separate their counts.
+func AnalyzeWindows(ctx context.Context, windows []Window, cfg llm.ProcessingConfig, analyze AnalyzeFunc) ([]*llm.WindowResult, error) {
+	workers := cfg.ParallelWindows
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*llm.WindowResult, len(windows))
+	sem := make(chan struct{}, workers)
+	registry := metrics.FromContext(ctx)
+
+	var (
+		mu       sync.Mutex
+		errs     []error
+		failed   bool
+		wg       sync.WaitGroup
+		canceled = ctx.Done()
+	)
+
+	for i, w := range windows {
+		select {
+		case sem <- struct{}{}:
+		case <-canceled:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, w Window) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := analyze(ctx, w)
+			if err != nil {
+				registry.Counter(MetricWindowsFailed, "Windows whose analysis failed").Inc()
+				mu.Lock()
+				// Once one window has failed, cancellation errors from the rest are noise
+				if !failed || !errors.Is(err, context.Canceled) {
+					errs = append(errs, &WindowError{Index: w.Index, Err: err})
+				}
+				failed = true
+				mu.Unlock()
+				cancel()
+				return
+			}
+			registry.Counter(MetricWindowsAnalyzed, "Windows analyzed successfully").Inc()
+			results[i] = result
+		}(i, w)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, errors.Join(errs...)
+	}
+	// The caller's context ended before every window was scheduled
+	return results, ctx.Err()
+}
+
+// ClaudeAnalyzer returns an AnalyzeFunc that sends each window to the model with the
+// tier 2 window prompt from templates and validates the structured response
+func ClaudeAnalyzer(prompter Prompter, templates *prompts.Set, totalWindows int) AnalyzeFunc {
+	return func(ctx context.Context, w Window) (*llm.WindowResult, error) {
+		prompt, err := BuildWindowPrompt(templates, w, totalWindows)
+		if err != nil {
+			return nil, err
+		}
+		response, err := prompter.SendConversationalPrompt(ctx, prompt, "")
+		if err != nil {
+			return nil, err
+		}
+
+		// Window replies leave metadata empty; the merged analysis is stamped by the caller
+		validation := validator.ValidateAnalysisJSONWithOptions(response, validator.Options{AllowMissingTimestamp: true})
+		if !validation.Valid {
+			return nil, fmt.Errorf("invalid analysis: %s", strings.Join(validation.Errors, "; "))
+		}
+
+		return &llm.WindowResult{
+			WindowID:        w.Index + 1,
+			WindowIndex:     w.Index,
+			TotalWindows:    totalWindows,
+			Episodes:        validation.Extracted.Episodes,
+			Recommendations: validation.Extracted.Recommendations,
+			ContinuesFrom:   w.Index > 0,
+			ContinuesTo:     w.Index < totalWindows-1,
+			Metadata: map[string]interface{}{
+				llm.InfoPromptTemplate: string(llm.PromptTier2Window),
+				"start_line":           w.StartLine,
+				"end_line":             w.EndLine,
+			},
+		}, nil
+	}
+}
+
+// BuildWindowPrompt renders the tier 2 prompt for a window from templates: its messages
+// with their source line numbers
+func BuildWindowPrompt(templates *prompts.Set, w Window, totalWindows int) (string, error) {
+	var b strings.Builder
+	for i, msg := range w.Messages {
+		line := msg.Line
+		if line == 0 {
+			line = w.StartLine + i
+		}
+		fmt.Fprintf(&b, "[line %d] %s: %s\n", line, msg.Type, msg.Content)
+	}
+	return templates.Render(llm.PromptTier2Window, prompts.Data{
+		Content:      b.String(),
+		Window:       w.Index + 1,
+		TotalWindows: totalWindows,
+		StartLine:    w.StartLine,
+		EndLine:      w.EndLine,
+	})
+}
@@ -0,0 +1,182 @@
+package window
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/metrics"
+)
+
+// makeWindows builds n single-message windows
+func makeWindows(n int) []Window {
+	windows := make([]Window, n)
+	for i := range windows {
+		windows[i] = Window{
+			Index:     i,
+			StartLine: i + 1,
+			EndLine:   i + 1,
+			Messages:  []llm.FilteredMessage{{Type: "user", Content: fmt.Sprintf("message %d", i), Line: i + 1}},
+		}
+	}
+	return windows
+}
+
+// TestAnalyzeWindowsBoundsConcurrency tests the worker limit and result ordering
+func TestAnalyzeWindowsBoundsConcurrency(t *testing.T) {
+	var running, peak int32
+	analyze := func(ctx context.Context, w Window) (*llm.WindowResult, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &llm.WindowResult{WindowIndex: w.Index}, nil
+	}
+
+	results, err := AnalyzeWindows(context.Background(), makeWindows(30), llm.ProcessingConfig{ParallelWindows: 4}, analyze)
+	if err != nil {
+		t.Fatalf("AnalyzeWindows failed: %v", err)
+	}
+
+	if peak > 4 {
+		t.Errorf("Expected at most 4 concurrent calls, got %d", peak)
+	}
+	if peak < 2 {
+		t.Errorf("Expected windows to run concurrently, peak was %d", peak)
+	}
+	for i, result := range results {
+		if result == nil || result.WindowIndex != i {
+			t.Fatalf("Result %d out of order: %+v", i, result)
+		}
+	}
+}
+
+// TestAnalyzeWindowsCancelsOnFailure tests that one failure stops the remaining windows
+func TestAnalyzeWindowsCancelsOnFailure(t *testing.T) {
+	var started int32
+	analyze := func(ctx context.Context, w Window) (*llm.WindowResult, error) {
+		atomic.AddInt32(&started, 1)
+		if w.Index == 1 {
+			return nil, errors.New("model unavailable")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return &llm.WindowResult{WindowIndex: w.Index}, nil
+		}
+	}
+
+	_, err := AnalyzeWindows(context.Background(), makeWindows(20), llm.ProcessingConfig{ParallelWindows: 2}, analyze)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	var windowErr *WindowError
+	if !errors.As(err, &windowErr) || windowErr.Index != 1 {
+		t.Errorf("Expected WindowError for window 1, got %v", err)
+	}
+	if strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("Expected cancellation noise to be dropped, got %v", err)
+	}
+	if n := atomic.LoadInt32(&started); n >= 20 {
+		t.Errorf("Expected remaining windows to be skipped, %d started", n)
+	}
+}
+
+// TestAnalyzeWindowsMetrics tests that concurrent batches sharing a registry count
+// every window exactly once
+func TestAnalyzeWindowsMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	ctx := metrics.WithRegistry(context.Background(), registry)
+	const batches, windowsPerBatch = 8, 25
+
+	analyze := func(ctx context.Context, w Window) (*llm.WindowResult, error) {
+		if w.Index == windowsPerBatch-1 {
+			return nil, errors.New("last window fails")
+		}
+		return &llm.WindowResult{WindowIndex: w.Index}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < batches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Cancellation is irrelevant here: the failing window is scheduled last
+			AnalyzeWindows(ctx, makeWindows(windowsPerBatch), llm.ProcessingConfig{ParallelWindows: 1}, analyze)
+		}()
+	}
+	wg.Wait()
+
+	snapshot := registry.Snapshot()
+	if got, want := snapshot[MetricWindowsAnalyzed], int64(batches*(windowsPerBatch-1)); got != want {
+		t.Errorf("%s = %d, want %d", MetricWindowsAnalyzed, got, want)
+	}
+	if got := snapshot[MetricWindowsFailed]; got != batches {
+		t.Errorf("%s = %d, want %d", MetricWindowsFailed, got, batches)
+	}
+}
+
+// TestAnalyzeWindowsAggregatesErrors tests that independent failures are all reported
+func TestAnalyzeWindowsAggregatesErrors(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	analyze := func(ctx context.Context, w Window) (*llm.WindowResult, error) {
+		// Both windows fail before either sees the other's cancellation
+		wg.Done()
+		wg.Wait()
+		return nil, fmt.Errorf("bad window %d", w.Index)
+	}
+
+	_, err := AnalyzeWindows(context.Background(), makeWindows(2), llm.ProcessingConfig{ParallelWindows: 2}, analyze)
+	if err == nil || !strings.Contains(err.Error(), "bad window 0") || !strings.Contains(err.Error(), "bad window 1") {
+		t.Errorf("Expected both window errors, got %v", err)
+	}
+}
+
+type fakePrompter struct {
+	response string
+	prompts  chan string
+}
+
+func (f *fakePrompter) SendConversationalPrompt(ctx context.Context, prompt string, sessionID string) (string, error) {
+	f.prompts <- prompt
+	return f.response, nil
+}
+
+// TestClaudeAnalyzer tests prompt construction and result parsing
+func TestClaudeAnalyzer(t *testing.T) {
+	prompter := &fakePrompter{
+		response: `{"episodes":[{"id":"ep1","phase":"debugging","confidence":0.9,"description":"Fix","start_line":1,"end_line":1}],"patterns":{"workflow":"linear","efficiency":"high"},"recommendations":[],"metadata":{}}`,
+		prompts:  make(chan string, 1),
+	}
+
+	windows := makeWindows(3)
+	result, err := ClaudeAnalyzer(prompter, nil, len(windows))(context.Background(), windows[1])
+	if err != nil {
+		t.Fatalf("ClaudeAnalyzer failed: %v", err)
+	}
+
+	prompt := <-prompter.prompts
+	if !strings.Contains(prompt, "window 2 of 3") || !strings.Contains(prompt, "[line 2] user: message 1") {
+		t.Errorf("Unexpected prompt: %s", prompt)
+	}
+	if result.WindowIndex != 1 || result.TotalWindows != 3 || !result.ContinuesFrom || !result.ContinuesTo {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.Episodes) != 1 || result.Episodes[0].Phase != "debugging" {
+		t.Errorf("Expected parsed episode, got %+v", result.Episodes)
+	}
+}
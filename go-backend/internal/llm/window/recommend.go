@@ -0,0 +1,48 @@
+package window
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// RankRecommendations merges the recommendation lists of several analyses, ordered by
+// how many lists mention each recommendation. Recommendations are compared ignoring
+// case, surrounding whitespace, and trailing punctuation; the first wording seen is
+// kept. A recommendation repeated within one list counts once, and ties keep the
+// order of first appearance.
+func RankRecommendations(lists ...[]string) []llm.RankedRecommendation {
+	var ranked []llm.RankedRecommendation
+	index := make(map[string]int)
+
+	for _, list := range lists {
+		counted := make(map[string]bool)
+		for _, text := range list {
+			key := recommendationKey(text)
+			if key == "" || counted[key] {
+				continue
+			}
+			counted[key] = true
+
+			if i, ok := index[key]; ok {
+				ranked[i].Count++
+				continue
+			}
+			index[key] = len(ranked)
+			ranked = append(ranked, llm.RankedRecommendation{Text: strings.TrimSpace(text), Count: 1})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Count > ranked[j].Count
+	})
+	return ranked
+}
+
+// recommendationKey normalizes a recommendation for comparison
+func recommendationKey(text string) string {
+	key := strings.ToLower(strings.TrimSpace(text))
+	key = strings.TrimRight(key, ".!;: ")
+	return strings.Join(strings.Fields(key), " ")
+}
@@ -0,0 +1,54 @@
+package window
+
+import (
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// TestRankRecommendations tests that shared recommendations rank highest with their counts
+func TestRankRecommendations(t *testing.T) {
+	ranked := RankRecommendations(
+		[]string{"Add tests", "Use a linter", "add tests."},
+		[]string{"Write smaller commits", "Add Tests"},
+		[]string{"Use a linter", "add tests", ""},
+	)
+
+	want := []llm.RankedRecommendation{
+		{Text: "Add tests", Count: 3},
+		{Text: "Use a linter", Count: 2},
+		{Text: "Write smaller commits", Count: 1},
+	}
+	if len(ranked) != len(want) {
+		t.Fatalf("Expected %d recommendations, got %+v", len(want), ranked)
+	}
+	for i := range want {
+		if ranked[i] != want[i] {
+			t.Errorf("ranked[%d] = %+v, want %+v", i, ranked[i], want[i])
+		}
+	}
+}
+
+// TestRankRecommendationsTiesKeepOrder tests that equally frequent recommendations stay in first-seen order
+func TestRankRecommendationsTiesKeepOrder(t *testing.T) {
+	ranked := RankRecommendations([]string{"b", "a"}, []string{"c"})
+
+	if len(ranked) != 3 || ranked[0].Text != "b" || ranked[1].Text != "a" || ranked[2].Text != "c" {
+		t.Errorf("Unexpected order: %+v", ranked)
+	}
+}
+
+// TestMergeWindowResultsRanksRecommendations tests ranking across merged windows
+func TestMergeWindowResultsRanksRecommendations(t *testing.T) {
+	analysis := MergeWindowResults([]*llm.WindowResult{
+		{WindowIndex: 0, Recommendations: []string{"Profile first", "Cache results"}},
+		{WindowIndex: 1, Recommendations: []string{"Cache results"}},
+	})
+
+	if len(analysis.Recommendations) != 2 || analysis.Recommendations[0] != "Cache results" {
+		t.Errorf("Expected the shared recommendation first, got %v", analysis.Recommendations)
+	}
+	if len(analysis.RankedRecommendations) != 2 || analysis.RankedRecommendations[0].Count != 2 {
+		t.Errorf("Unexpected ranked recommendations: %+v", analysis.RankedRecommendations)
+	}
+}
@@ -0,0 +1,74 @@
+package window
+
+import (
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// Defaults for windowed (tier 2) analysis
+const (
+	DefaultWindowSize      = 40 // Messages per window
+	DefaultOverlapSize     = 5  // Messages shared with the previous window
+	DefaultParallelWindows = 3  // Windows analyzed concurrently
+)
+
+// Window is a contiguous slice of a conversation analyzed as one unit
+type Window struct {
+	Index     int                   // 0-based position among all windows
+	StartLine int                   // Source line of the first message
+	EndLine   int                   // Source line of the last message
+	Messages  []llm.FilteredMessage // Messages in this window, in order
+}
+
+// SplitIntoWindows splits messages into windows of cfg.WindowSize messages, each
+// overlapping the previous one by cfg.OverlapSize messages so episodes that cross a
+// boundary are seen whole by at least one window.
+//
+// A non-positive WindowSize yields a single window. OverlapSize is clamped to
+// [0, WindowSize-1] so windows always advance.
+func SplitIntoWindows(messages []llm.FilteredMessage, cfg llm.ProcessingConfig) []Window {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	size := cfg.WindowSize
+	if size <= 0 || size > len(messages) {
+		size = len(messages)
+	}
+
+	overlap := cfg.OverlapSize
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= size {
+		overlap = size - 1
+	}
+	step := size - overlap
+
+	var windows []Window
+	for start := 0; ; start += step {
+		end := start + size
+		if end > len(messages) {
+			end = len(messages)
+		}
+
+		windows = append(windows, Window{
+			Index:     len(windows),
+			StartLine: lineOf(messages, start),
+			EndLine:   lineOf(messages, end-1),
+			Messages:  messages[start:end],
+		})
+
+		if end == len(messages) {
+			return windows
+		}
+	}
+}
+
+// lineOf returns the source line of the message at index i, falling back to its
+// 1-based position when the message carries no line number
+func lineOf(messages []llm.FilteredMessage, i int) int {
+	if messages[i].Line > 0 {
+		return messages[i].Line
+	}
+	return i + 1
+}
@@ -0,0 +1,140 @@
+package window
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tadschnitzer/universal-session-viewer/go-backend/internal/llm"
+)
+
+// makeMessages returns n messages on source lines 1..n
+func makeMessages(n int) []llm.FilteredMessage {
+	messages := make([]llm.FilteredMessage, n)
+	for i := range messages {
+		messages[i] = llm.FilteredMessage{
+			Type:    "user",
+			Content: fmt.Sprintf("Message %d", i+1),
+			Line:    i + 1,
+		}
+	}
+	return messages
+}
+
+// TestSplitIntoWindows tests window sizes, overlap, and line bounds
+func TestSplitIntoWindows(t *testing.T) {
+	tests := []struct {
+		name          string
+		count         int
+		windowSize    int
+		overlapSize   int
+		expectedLines [][2]int // start/end line per window
+	}{
+		{
+			name:          "Exact fit without overlap",
+			count:         10,
+			windowSize:    5,
+			overlapSize:   0,
+			expectedLines: [][2]int{{1, 5}, {6, 10}},
+		},
+		{
+			name:          "Overlapping windows",
+			count:         10,
+			windowSize:    4,
+			overlapSize:   1,
+			expectedLines: [][2]int{{1, 4}, {4, 7}, {7, 10}},
+		},
+		{
+			name:          "Short final window",
+			count:         10,
+			windowSize:    4,
+			overlapSize:   2,
+			expectedLines: [][2]int{{1, 4}, {3, 6}, {5, 8}, {7, 10}},
+		},
+		{
+			name:          "Window larger than input",
+			count:         3,
+			windowSize:    10,
+			overlapSize:   2,
+			expectedLines: [][2]int{{1, 3}},
+		},
+		{
+			name:          "Zero window size yields single window",
+			count:         7,
+			windowSize:    0,
+			overlapSize:   0,
+			expectedLines: [][2]int{{1, 7}},
+		},
+		{
+			name:          "Overlap clamped below window size",
+			count:         5,
+			windowSize:    3,
+			overlapSize:   3,
+			expectedLines: [][2]int{{1, 3}, {2, 4}, {3, 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := SplitIntoWindows(makeMessages(tt.count), llm.ProcessingConfig{
+				WindowSize:  tt.windowSize,
+				OverlapSize: tt.overlapSize,
+			})
+
+			if len(windows) != len(tt.expectedLines) {
+				t.Fatalf("Expected %d windows, got %d", len(tt.expectedLines), len(windows))
+			}
+			for i, w := range windows {
+				if w.Index != i {
+					t.Errorf("Window %d has index %d", i, w.Index)
+				}
+				if w.StartLine != tt.expectedLines[i][0] || w.EndLine != tt.expectedLines[i][1] {
+					t.Errorf("Window %d lines = %d-%d, want %d-%d", i, w.StartLine, w.EndLine, tt.expectedLines[i][0], tt.expectedLines[i][1])
+				}
+				if w.Messages[0].Line != w.StartLine || w.Messages[len(w.Messages)-1].Line != w.EndLine {
+					t.Errorf("Window %d bounds don't match its messages", i)
+				}
+			}
+		})
+	}
+}
+
+// TestSplitIntoWindowsSourceLines tests that sparse source line numbers are preserved
+func TestSplitIntoWindowsSourceLines(t *testing.T) {
+	messages := []llm.FilteredMessage{
+		{Type: "user", Content: "a", Line: 3},
+		{Type: "assistant", Content: "b", Line: 7},
+		{Type: "user", Content: "c", Line: 12},
+		{Type: "assistant", Content: "d", Line: 20},
+	}
+
+	windows := SplitIntoWindows(messages, llm.ProcessingConfig{WindowSize: 2, OverlapSize: 0})
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(windows))
+	}
+	if windows[0].StartLine != 3 || windows[0].EndLine != 7 {
+		t.Errorf("Window 0 lines = %d-%d, want 3-7", windows[0].StartLine, windows[0].EndLine)
+	}
+	if windows[1].StartLine != 12 || windows[1].EndLine != 20 {
+		t.Errorf("Window 1 lines = %d-%d, want 12-20", windows[1].StartLine, windows[1].EndLine)
+	}
+}
+
+// TestSplitIntoWindowsWithoutLineNumbers tests positional fallback for unnumbered messages
+func TestSplitIntoWindowsWithoutLineNumbers(t *testing.T) {
+	messages := []llm.FilteredMessage{{Content: "a"}, {Content: "b"}, {Content: "c"}}
+
+	windows := SplitIntoWindows(messages, llm.ProcessingConfig{WindowSize: 2, OverlapSize: 1})
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(windows))
+	}
+	if windows[1].StartLine != 2 || windows[1].EndLine != 3 {
+		t.Errorf("Window 1 lines = %d-%d, want 2-3", windows[1].StartLine, windows[1].EndLine)
+	}
+}
+
+// TestSplitIntoWindowsEmpty tests that no windows are produced for no messages
+func TestSplitIntoWindowsEmpty(t *testing.T) {
+	if windows := SplitIntoWindows(nil, llm.ProcessingConfig{WindowSize: 5}); windows != nil {
+		t.Errorf("Expected no windows, got %d", len(windows))
+	}
+}
@@ -0,0 +1,169 @@
+// Package metrics aggregates counters and gauges across concurrent workers and renders
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value that is safe for concurrent use
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add adds n to the counter; negative values are ignored so counters never decrease
+func (c *Counter) Add(n int64) {
+	if n > 0 {
+		c.value.Add(n)
+	}
+}
+
+// Value returns the current count
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Gauge is a value that can go up and down, such as the number of requests in
+// flight, and is safe for concurrent use
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the gauge
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec subtracts one from the gauge
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Value returns the current value
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+// Registry holds named counters and gauges. Lookups are guarded by a mutex and
+// updates are atomic, so workers can share one registry without coordinating.
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]*Counter
+	gauges   map[string]*Gauge
+	help     map[string]string
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*Gauge),
+		help:     make(map[string]string),
+	}
+}
+
+// Default is the process-wide registry used when a context carries none
+var Default = NewRegistry()
+
+// Counter returns the counter registered under name, creating it on first use.
+// The help text of the first registration is kept.
+func (r *Registry) Counter(name, help string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter, ok := r.counters[name]
+	if !ok {
+		counter = &Counter{}
+		r.counters[name] = counter
+		r.help[name] = help
+	}
+	return counter
+}
+
+// Gauge returns the gauge registered under name, creating it on first use.
+// The help text of the first registration is kept.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	gauge, ok := r.gauges[name]
+	if !ok {
+		gauge = &Gauge{}
+		r.gauges[name] = gauge
+		r.help[name] = help
+	}
+	return gauge
+}
+
+// Snapshot returns the current value of every counter and gauge
+func (r *Registry) Snapshot() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[string]int64, len(r.counters)+len(r.gauges))
+	for name, counter := range r.counters {
+		values[name] = counter.Value()
+	}
+	for name, gauge := range r.gauges {
+		values[name] = gauge.Value()
+	}
+	return values
+}
+
+// WritePrometheus writes every counter and gauge, sorted by name, in the Prometheus
+// text format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	type entry struct {
+		name, help, kind string
+		value            func() int64
+	}
+
+	r.mu.Lock()
+	entries := make([]entry, 0, len(r.counters)+len(r.gauges))
+	for name, counter := range r.counters {
+		entries = append(entries, entry{name: name, help: r.help[name], kind: "counter", value: counter.Value})
+	}
+	for name, gauge := range r.gauges {
+		entries = append(entries, entry{name: name, help: r.help[name], kind: "gauge", value: gauge.Value})
+	}
+	r.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	for _, e := range entries {
+		if e.help != "" {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n", e.name, e.help); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n%s %d\n", e.name, e.kind, e.name, e.value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type contextKey struct{}
+
+// WithRegistry returns a context whose work is counted in r, e.g. to scope metrics
+// to one batch
+func WithRegistry(ctx context.Context, r *Registry) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the registry set with WithRegistry, or Default
+func FromContext(ctx context.Context) *Registry {
+	if r, ok := ctx.Value(contextKey{}).(*Registry); ok && r != nil {
+		return r
+	}
+	return Default
+}
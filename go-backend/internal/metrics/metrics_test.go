@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestRegistryConcurrentCounters tests that increments from many goroutines are all counted
+func TestRegistryConcurrentCounters(t *testing.T) {
+	registry := NewRegistry()
+	const workers, perWorker = 16, 500
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				// Look the counter up each time so registration races too
+				registry.Counter("ops_total", "Operations").Inc()
+				if j%2 == 0 {
+					registry.Counter("even_total", "").Add(2)
+				}
+			}
+			_ = registry.Snapshot()
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := registry.Snapshot()
+	if snapshot["ops_total"] != workers*perWorker {
+		t.Errorf("ops_total = %d, want %d", snapshot["ops_total"], workers*perWorker)
+	}
+	if snapshot["even_total"] != workers*perWorker {
+		t.Errorf("even_total = %d, want %d", snapshot["even_total"], workers*perWorker)
+	}
+}
+
+// TestCounterIgnoresNegative tests that counters never decrease
+func TestCounterIgnoresNegative(t *testing.T) {
+	var c Counter
+	c.Add(3)
+	c.Add(-5)
+	if c.Value() != 3 {
+		t.Errorf("Value() = %d, want 3", c.Value())
+	}
+}
+
+// TestWritePrometheus tests the text exposition output
+func TestWritePrometheus(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("b_total", "").Add(2)
+	registry.Counter("a_total", "Things done").Inc()
+
+	var b strings.Builder
+	if err := registry.WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+
+	want := "# HELP a_total Things done\n# TYPE a_total counter\na_total 1\n" +
+		"# TYPE b_total counter\nb_total 2\n"
+	if b.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+// TestGauge tests a gauge moving in both directions and its exposition type
+func TestGauge(t *testing.T) {
+	registry := NewRegistry()
+	gauge := registry.Gauge("in_flight", "Requests in flight")
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+	if registry.Gauge("in_flight", "") != gauge || gauge.Value() != 1 {
+		t.Fatalf("Expected the same gauge at 1, got %d", gauge.Value())
+	}
+	if registry.Snapshot()["in_flight"] != 1 {
+		t.Errorf("Expected the gauge in the snapshot: %v", registry.Snapshot())
+	}
+
+	var b strings.Builder
+	if err := registry.WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	if want := "# HELP in_flight Requests in flight\n# TYPE in_flight gauge\nin_flight 1\n"; b.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+// TestFromContext tests the context-scoped registry and the default fallback
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != Default {
+		t.Error("Expected Default without a registry in the context")
+	}
+	registry := NewRegistry()
+	if FromContext(WithRegistry(context.Background(), registry)) != registry {
+		t.Error("Expected the registry set with WithRegistry")
+	}
+}
@@ -0,0 +1,32 @@
+package version
+
+import (
+	"runtime"
+)
+
+// Build metadata, overridden at link time:
+//
+//	go build -ldflags "-X github.com/tadschnitzer/universal-session-viewer/go-backend/internal/version.Version=1.2.3"
+var (
+	Version   = "dev"     // Semantic version of the build
+	Commit    = "unknown" // Git commit the binary was built from
+	BuildDate = "unknown" // Build timestamp (RFC 3339)
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata for the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
@@ -0,0 +1,40 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestGet tests that build metadata is reported
+func TestGet(t *testing.T) {
+	oldVersion, oldCommit, oldDate := Version, Commit, BuildDate
+	defer func() { Version, Commit, BuildDate = oldVersion, oldCommit, oldDate }()
+
+	Version = "1.2.3"
+	Commit = "abc1234"
+	BuildDate = "2025-01-01T00:00:00Z"
+
+	info := Get()
+	if info.Version != "1.2.3" {
+		t.Errorf("Expected version %q, got %q", "1.2.3", info.Version)
+	}
+	if info.Commit != "abc1234" {
+		t.Errorf("Expected commit %q, got %q", "abc1234", info.Commit)
+	}
+	if info.BuildDate != "2025-01-01T00:00:00Z" {
+		t.Errorf("Expected build date %q, got %q", "2025-01-01T00:00:00Z", info.BuildDate)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Expected Go version %q, got %q", runtime.Version(), info.GoVersion)
+	}
+}
+
+// TestDefaults tests the values used when no ldflags are provided
+func TestDefaults(t *testing.T) {
+	if Version != "dev" {
+		t.Errorf("Expected default version %q, got %q", "dev", Version)
+	}
+	if Commit != "unknown" || BuildDate != "unknown" {
+		t.Errorf("Expected unknown commit/build date by default, got %q/%q", Commit, BuildDate)
+	}
+}